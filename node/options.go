@@ -21,6 +21,7 @@ type NodeOption struct {
 	IsDiscordScraper  bool
 	IsTelegramScraper bool
 	IsWebScraper      bool
+	IsRedditScraper   bool
 
 	Bootnodes            []string
 	RandomIdentity       bool
@@ -87,6 +88,10 @@ var IsWebScraper = func(o *NodeOption) {
 	o.IsWebScraper = true
 }
 
+var IsRedditScraper = func(o *NodeOption) {
+	o.IsRedditScraper = true
+}
+
 func (a *NodeOption) Apply(opts ...Option) {
 	for _, opt := range opts {
 		opt(a)
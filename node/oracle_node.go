@@ -187,6 +187,7 @@ func (node *OracleNode) getNodeData() *pubsub.NodeData {
 	nodeData.IsDiscordScraper = node.Options.IsDiscordScraper
 	nodeData.IsTelegramScraper = node.Options.IsTelegramScraper
 	nodeData.IsWebScraper = node.Options.IsWebScraper
+	nodeData.IsRedditScraper = node.Options.IsRedditScraper
 	nodeData.IsValidator = node.Options.IsValidator
 	nodeData.IsActive = true
 	nodeData.Version = versioning.ProtocolVersion
@@ -326,14 +327,15 @@ func (node *OracleNode) handleStream(stream network.Stream) {
 
 // IsWorker determines if the OracleNode is configured to act as an actor.
 // An actor node is one that has at least one of the following scrapers enabled:
-// TwitterScraper, DiscordScraper, TelegramScraper or WebScraper.
+// TwitterScraper, DiscordScraper, TelegramScraper, WebScraper or RedditScraper.
 // It returns true if any of these scrapers are enabled, otherwise false.
 func (node *OracleNode) IsWorker() bool {
 	// need to get this by node data
 	return node.Options.IsTwitterScraper ||
 		node.Options.IsDiscordScraper ||
 		node.Options.IsTelegramScraper ||
-		node.Options.IsWebScraper
+		node.Options.IsWebScraper ||
+		node.Options.IsRedditScraper
 }
 
 // IsPublisher returns true if this node is a publisher node.
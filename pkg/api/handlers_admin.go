@@ -0,0 +1,115 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Gzgod/masa-oracle/pkg/scrapers/telegram"
+	"github.com/Gzgod/masa-oracle/pkg/scrapers/twitter"
+)
+
+// GetTwitterAccountsHandler returns a gin.HandlerFunc that lists the Twitter accounts of this node's pool
+// together with their status (active, rate-limited, auth-failing or disabled) and usage counters.
+func (api *API) GetTwitterAccountsHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !api.Node.Options.IsTwitterScraper {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Node is not a Twitter scraper and cannot access this endpoint"})
+			return
+		}
+
+		accounts := twitter.GetAccountManager(api.Node.Options.MasaDir).GetAccounts()
+		c.JSON(http.StatusOK, gin.H{
+			"success":    true,
+			"data":       accounts,
+			"totalCount": len(accounts),
+		})
+	}
+}
+
+// SetTwitterAccountDisabledHandler returns a gin.HandlerFunc that disables or enables the Twitter account
+// given by the "username" path parameter. Disabled accounts are not used for scraping until re-enabled.
+func (api *API) SetTwitterAccountDisabledHandler(disabled bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !api.Node.Options.IsTwitterScraper {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Node is not a Twitter scraper and cannot access this endpoint"})
+			return
+		}
+
+		username := c.Param("username")
+		err := twitter.GetAccountManager(api.Node.Options.MasaDir).SetAccountDisabled(username, disabled)
+		if err != nil {
+			handleTwitterAccountError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "username": username, "disabled": disabled})
+	}
+}
+
+// ReloginTwitterAccountHandler returns a gin.HandlerFunc that forces a fresh login for the Twitter account
+// given by the "username" path parameter, replacing its stored cookies.
+func (api *API) ReloginTwitterAccountHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !api.Node.Options.IsTwitterScraper {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Node is not a Twitter scraper and cannot access this endpoint"})
+			return
+		}
+
+		username := c.Param("username")
+		if err := twitter.ReloginAccount(api.Node.Options.MasaDir, username); err != nil {
+			handleTwitterAccountError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "username": username})
+	}
+}
+
+func handleTwitterAccountError(c *gin.Context, err error) {
+	if errors.Is(err, twitter.ErrAccountNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+// GetTelegramSessionsHandler returns a gin.HandlerFunc that lists the Telegram sessions of this node
+// together with their status (active, flood-wait, unauthorized or disabled) and usage counters.
+func (api *API) GetTelegramSessionsHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !api.Node.Options.IsTelegramScraper {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Node is not a Telegram scraper and cannot access this endpoint"})
+			return
+		}
+
+		sessions := telegram.GetSessionManager().GetSessions()
+		c.JSON(http.StatusOK, gin.H{
+			"success":    true,
+			"data":       sessions,
+			"totalCount": len(sessions),
+		})
+	}
+}
+
+// SetTelegramSessionDisabledHandler returns a gin.HandlerFunc that disables or enables the Telegram
+// session given by the "name" path parameter. Disabled sessions are not used for scraping until re-enabled.
+func (api *API) SetTelegramSessionDisabledHandler(disabled bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !api.Node.Options.IsTelegramScraper {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Node is not a Telegram scraper and cannot access this endpoint"})
+			return
+		}
+
+		name := c.Param("name")
+		err := telegram.GetSessionManager().SetSessionDisabled(name, disabled)
+		if errors.Is(err, telegram.ErrSessionNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "name": name, "disabled": disabled})
+	}
+}
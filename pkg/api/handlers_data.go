@@ -5,10 +5,12 @@ import (
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"regexp"
 	"strconv"
 	"strings"
 	"sync"
@@ -16,14 +18,18 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	tgauth "github.com/gotd/td/telegram/auth"
 	"github.com/multiformats/go-multiaddr"
 	"github.com/sirupsen/logrus"
 
+	"github.com/Gzgod/masa-oracle/pkg/blobstore"
 	"github.com/Gzgod/masa-oracle/pkg/chain"
 	"github.com/Gzgod/masa-oracle/pkg/config"
 	pubsub2 "github.com/Gzgod/masa-oracle/pkg/pubsub"
 	"github.com/Gzgod/masa-oracle/pkg/scrapers/discord"
+	"github.com/Gzgod/masa-oracle/pkg/scrapers/normalized"
 	"github.com/Gzgod/masa-oracle/pkg/scrapers/telegram"
+	"github.com/Gzgod/masa-oracle/pkg/scrapers/web"
 	"github.com/Gzgod/masa-oracle/pkg/workers"
 	data_types "github.com/Gzgod/masa-oracle/pkg/workers/types"
 )
@@ -48,6 +54,7 @@ func (api *API) sendWorkRequest(requestID string, workType data_types.WorkerType
 		Data:      bodyBytes,
 	}
 	response := api.WorkManager.DistributeWork(api.Node, request)
+	api.storeBlobs(&response)
 	responseChannel, exists := workers.GetResponseChannelMap().Get(requestID)
 	if !exists {
 		return fmt.Errorf("response channel not found")
@@ -63,6 +70,27 @@ func (api *API) sendWorkRequest(requestID string, workType data_types.WorkerType
 	return nil
 }
 
+// storeBlobs verifies the blobs returned by a worker against their CIDs and keeps them in this
+// node's blob store, so that clients can retrieve them from the blobs endpoint. The content is
+// removed from the response, which only refers to the blobs by CID.
+func (api *API) storeBlobs(response *data_types.WorkResponse) {
+	if len(response.Blobs) == 0 {
+		return
+	}
+	store, err := blobstore.New(blobstore.Dir(api.Node.Options.MasaDir))
+	if err != nil {
+		logrus.Errorf("[-] Unable to open blob store: %v", err)
+		response.Blobs = nil
+		return
+	}
+	for cidKey, data := range response.Blobs {
+		if _, err := store.PutVerified(cidKey, data); err != nil {
+			logrus.Errorf("[-] Unable to store blob %s from worker %s: %v", cidKey, response.WorkerPeerId, err)
+		}
+	}
+	response.Blobs = nil
+}
+
 // handleWorkResponse processes the response from a worker and sends it back to the client.
 // It listens on the provided response channel for a response or a timeout signal.
 // If a response is received within the timeout period, it unmarshals the JSON response and sends it back to the client.
@@ -121,6 +149,14 @@ func handleErrorResponse(c *gin.Context, response data_types.WorkResponse) {
 	switch {
 	case strings.Contains(response.Error, "Twitter API rate limit exceeded (429 error)"):
 		errorResponse(http.StatusTooManyRequests, "Twitter API rate limit exceeded")
+	case strings.Contains(response.Error, "discord rate limit exceeded (429 error)"):
+		errorResponse(http.StatusTooManyRequests, "Discord API rate limit exceeded")
+	case strings.Contains(response.Error, "reddit rate limit exceeded (429 error)"):
+		errorResponse(http.StatusTooManyRequests, "Reddit API rate limit exceeded")
+	case strings.Contains(response.Error, "web scraper policy:"):
+		errorResponse(http.StatusForbidden, "The URL is not allowed by the web scraper policy")
+	case strings.Contains(response.Error, web.ErrNoFeed.Error()), strings.Contains(response.Error, web.ErrNoSitemap.Error()):
+		errorResponse(http.StatusNotFound, "No feed or sitemap found for the URL")
 	case strings.Contains(response.Error, "no workers could process"):
 		errorResponse(http.StatusServiceUnavailable, "No available workers to process the request")
 	default:
@@ -137,6 +173,20 @@ func handleTimeout(c *gin.Context) {
 	c.JSON(http.StatusGatewayTimeout, gin.H{"error": "Request timed out in API layer"})
 }
 
+// bindFormat reads the result format from the "format" query parameter unless it was already
+// given in the request body, and validates it. It responds with 400 and returns false if the
+// format is not supported.
+func bindFormat(c *gin.Context, format *string) bool {
+	if *format == "" {
+		*format = c.Query("format")
+	}
+	if err := normalized.ValidateFormat(*format); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	return true
+}
+
 // SearchTweetsProfile returns a gin.HandlerFunc that processes a request to search for tweets from a specific user profile.
 // It expects a URL parameter "username" representing the Twitter username to search for.
 // The handler validates the username, ensuring it is provided.
@@ -146,12 +196,16 @@ func (api *API) SearchTweetsProfile() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var reqBody struct {
 			Username string `json:"username"`
+			Format   string `json:"format,omitempty"`
 		}
 		if c.Param("username") == "" {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Username must be provided and valid"})
 			return
 		}
 		reqBody.Username = c.Param("username")
+		if !bindFormat(c, &reqBody.Format) {
+			return
+		}
 
 		// worker handler implementation
 		bodyBytes, err := json.Marshal(reqBody)
@@ -182,14 +236,19 @@ func (api *API) SearchTweetsProfile() gin.HandlerFunc {
 func (api *API) SearchTweetsRecent() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var reqBody struct {
-			Query string `json:"query"`
-			Count int    `json:"count"`
+			Query         string `json:"query"`
+			Count         int    `json:"count"`
+			Format        string `json:"format,omitempty"`
+			DownloadMedia bool   `json:"downloadMedia,omitempty"`
 		}
 
 		if err := c.ShouldBindJSON(&reqBody); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
 			return
 		}
+		if !bindFormat(c, &reqBody.Format) {
+			return
+		}
 
 		if reqBody.Query == "" || reqBody.Count <= 0 {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Query and count must be provided and valid"})
@@ -226,6 +285,7 @@ func (api *API) SearchTwitterFollowers() gin.HandlerFunc {
 		var reqBody struct {
 			Username string `json:"username"`
 			Count    int    `json:"count"`
+			Format   string `json:"format,omitempty"`
 		}
 
 		username := c.Param("username")
@@ -234,6 +294,9 @@ func (api *API) SearchTwitterFollowers() gin.HandlerFunc {
 			return
 		}
 		reqBody.Username = username
+		if !bindFormat(c, &reqBody.Format) {
+			return
+		}
 		if reqBody.Count == 0 {
 			reqBody.Count = 20
 		}
@@ -268,6 +331,7 @@ func (api *API) SearchDiscordProfile() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var reqBody struct {
 			UserID string `json:"userID"`
+			Format string `json:"format,omitempty"`
 		}
 
 		reqBody.UserID = c.Param("userID")
@@ -276,6 +340,9 @@ func (api *API) SearchDiscordProfile() gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "UserID must be provided and valid"})
 			return
 		}
+		if !bindFormat(c, &reqBody.Format) {
+			return
+		}
 
 		// worker handler implementation
 		bodyBytes, err := json.Marshal(reqBody)
@@ -305,6 +372,7 @@ func (api *API) SearchChannelMessages() gin.HandlerFunc {
 			ChannelID string `json:"channelID"`
 			Limit     string `json:"limit"`
 			Before    string `json:"before"`
+			Format    string `json:"format,omitempty"`
 		}
 
 		reqParams.ChannelID = c.Param("channelID")
@@ -315,6 +383,9 @@ func (api *API) SearchChannelMessages() gin.HandlerFunc {
 
 		reqParams.Limit = c.Query("limit")
 		reqParams.Before = c.Query("before")
+		if !bindFormat(c, &reqParams.Format) {
+			return
+		}
 
 		if reqParams.Limit != "" {
 			if _, err := strconv.Atoi(reqParams.Limit); err != nil {
@@ -346,6 +417,269 @@ func (api *API) SearchChannelMessages() gin.HandlerFunc {
 	}
 }
 
+// ExportChannelHistory returns a gin.HandlerFunc that exports the history of a Discord channel.
+// The history is walked by as many work requests as needed, each continuing from the cursor of the
+// previous one, and every result is streamed to the client as a line of newline delimited JSON as
+// soon as it arrives. The walk ends when the channel, the date bound or the limit is reached. If it
+// fails, the last line holds the error and the cursor to resume from.
+func (api *API) ExportChannelHistory() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody struct {
+			ChannelID string `json:"channelID"`
+			Direction string `json:"direction,omitempty"`
+			Before    string `json:"before,omitempty"`
+			After     string `json:"after,omitempty"`
+			Since     string `json:"since,omitempty"`
+			Until     string `json:"until,omitempty"`
+			Limit     int    `json:"limit,omitempty"`
+			ChunkSize int    `json:"chunkSize,omitempty"`
+			Format    string `json:"format,omitempty"`
+		}
+		if err := c.ShouldBindJSON(&reqBody); err != nil && !errors.Is(err, io.EOF) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON body"})
+			return
+		}
+		reqBody.ChannelID = c.Param("channelID")
+		if reqBody.ChannelID == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "ChannelID must be provided and valid"})
+			return
+		}
+		if reqBody.Direction != "" && reqBody.Direction != discord.DirectionBackward && reqBody.Direction != discord.DirectionForward {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Direction must be backward or forward"})
+			return
+		}
+		for _, date := range []string{reqBody.Since, reqBody.Until} {
+			if _, err := time.Parse(time.RFC3339, date); date != "" && err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Dates must be in RFC 3339 format"})
+				return
+			}
+		}
+		if reqBody.Limit < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit parameter"})
+			return
+		}
+		if !bindFormat(c, &reqBody.Format) {
+			return
+		}
+
+		encoder := json.NewEncoder(c.Writer)
+		started := false
+		for {
+			bodyBytes, err := json.Marshal(reqBody)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			api.sendTrackingEvent(data_types.DiscordChannelHistory, bodyBytes)
+			response := api.WorkManager.DistributeWork(api.Node, data_types.WorkRequest{
+				WorkType:  data_types.DiscordChannelHistory,
+				RequestId: uuid.New().String(),
+				Data:      bodyBytes,
+			})
+
+			var history discord.ChannelHistory
+			if response.Error == "" {
+				if err = remarshal(response.Data, &history); err != nil {
+					response.Error = fmt.Sprintf("unable to parse channel history: %v", err)
+				}
+			}
+			if response.Error != "" {
+				if !started {
+					handleErrorResponse(c, response)
+					return
+				}
+				cursor := reqBody.Before
+				if reqBody.Direction == discord.DirectionForward {
+					cursor = reqBody.After
+				}
+				_ = encoder.Encode(gin.H{"error": response.Error, "cursor": cursor, "workerPeerId": response.WorkerPeerId})
+				c.Writer.Flush()
+				return
+			}
+
+			if !started {
+				c.Header("Content-Type", "application/x-ndjson")
+				c.Status(http.StatusOK)
+				started = true
+			}
+			if err = encoder.Encode(struct {
+				*discord.ChannelHistory
+				WorkerPeerId string `json:"workerPeerId,omitempty"`
+			}{&history, response.WorkerPeerId}); err != nil {
+				logrus.Errorf("[-] Unable to stream channel history: %v", err)
+				return
+			}
+			c.Writer.Flush()
+
+			if history.Complete || history.MessageCount == 0 || c.Request.Context().Err() != nil ||
+				history.StopReason == discord.StopLimit || history.StopReason == discord.StopError {
+				return
+			}
+			if reqBody.Direction == discord.DirectionForward {
+				reqBody.After = history.Cursor
+			} else {
+				reqBody.Before = history.Cursor
+			}
+			if reqBody.Limit > 0 {
+				reqBody.Limit -= history.MessageCount
+			}
+		}
+	}
+}
+
+// remarshal converts the data of a work response, which is a map when it comes from a remote
+// worker, into the result type of the work.
+func remarshal(data interface{}, v interface{}) error {
+	bytes, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(bytes, v)
+}
+
+// SearchChannelThreads returns a gin.HandlerFunc that processes a request for the threads of a Discord channel,
+// including the posts of a forum channel. Active threads are returned unless the "archived" query parameter is
+// set to "public" or "private".
+func (api *API) SearchChannelThreads() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqParams struct {
+			ChannelID string `json:"channelID"`
+			Archived  string `json:"archived,omitempty"`
+			Before    string `json:"before,omitempty"`
+			Limit     string `json:"limit,omitempty"`
+		}
+
+		reqParams.ChannelID = c.Param("channelID")
+		if reqParams.ChannelID == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "ChannelID must be provided and valid"})
+			return
+		}
+		reqParams.Archived = c.Query("archived")
+		if reqParams.Archived != "" && reqParams.Archived != "public" && reqParams.Archived != "private" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Archived must be public or private"})
+			return
+		}
+		reqParams.Before = c.Query("before")
+		reqParams.Limit = c.Query("limit")
+		if !validLimit(c, reqParams.Limit) {
+			return
+		}
+
+		api.handleWorkRequest(c, data_types.DiscordChannelThreads, reqParams)
+	}
+}
+
+// SearchThreadMessages returns a gin.HandlerFunc that processes a request for the messages of a Discord thread or forum post.
+func (api *API) SearchThreadMessages() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqParams struct {
+			ThreadID string `json:"threadID"`
+			Limit    string `json:"limit,omitempty"`
+			Before   string `json:"before,omitempty"`
+			Format   string `json:"format,omitempty"`
+		}
+
+		reqParams.ThreadID = c.Param("threadID")
+		if reqParams.ThreadID == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "ThreadID must be provided and valid"})
+			return
+		}
+		reqParams.Limit = c.Query("limit")
+		reqParams.Before = c.Query("before")
+		if !validLimit(c, reqParams.Limit) || !bindFormat(c, &reqParams.Format) {
+			return
+		}
+
+		api.handleWorkRequest(c, data_types.DiscordThreadMessages, reqParams)
+	}
+}
+
+// SearchPinnedMessages returns a gin.HandlerFunc that processes a request for the pinned messages of a Discord channel.
+func (api *API) SearchPinnedMessages() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqParams struct {
+			ChannelID string `json:"channelID"`
+			Format    string `json:"format,omitempty"`
+		}
+
+		reqParams.ChannelID = c.Param("channelID")
+		if reqParams.ChannelID == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "ChannelID must be provided and valid"})
+			return
+		}
+		if !bindFormat(c, &reqParams.Format) {
+			return
+		}
+
+		api.handleWorkRequest(c, data_types.DiscordPinnedMessages, reqParams)
+	}
+}
+
+// SearchReactionUsers returns a gin.HandlerFunc that processes a request for the users who reacted to a Discord
+// message with an emoji.
+func (api *API) SearchReactionUsers() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqParams struct {
+			ChannelID string `json:"channelID"`
+			MessageID string `json:"messageID"`
+			Emoji     string `json:"emoji"`
+			After     string `json:"after,omitempty"`
+			Limit     string `json:"limit,omitempty"`
+			Format    string `json:"format,omitempty"`
+		}
+
+		reqParams.ChannelID = c.Param("channelID")
+		reqParams.MessageID = c.Param("messageID")
+		reqParams.Emoji = c.Param("emoji")
+		if reqParams.ChannelID == "" || reqParams.MessageID == "" || reqParams.Emoji == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "ChannelID, MessageID and Emoji must be provided and valid"})
+			return
+		}
+		reqParams.After = c.Query("after")
+		reqParams.Limit = c.Query("limit")
+		if !validLimit(c, reqParams.Limit) || !bindFormat(c, &reqParams.Format) {
+			return
+		}
+
+		api.handleWorkRequest(c, data_types.DiscordReactionUsers, reqParams)
+	}
+}
+
+// handleWorkRequest sends a work request with the given parameters and writes the work response.
+func (api *API) handleWorkRequest(c *gin.Context, workType data_types.WorkerType, reqParams interface{}) {
+	bodyBytes, err := json.Marshal(reqParams)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	api.sendTrackingEvent(workType, bodyBytes)
+	requestID := uuid.New().String()
+	responseCh := workers.GetResponseChannelMap().CreateChannel(requestID)
+	wg := &sync.WaitGroup{}
+	defer workers.GetResponseChannelMap().Delete(requestID)
+	go handleWorkResponse(c, responseCh, wg)
+
+	err = api.sendWorkRequest(requestID, workType, bodyBytes, wg)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	wg.Wait()
+}
+
+// validLimit writes a 400 response and returns false if limit is set and not a number.
+func validLimit(c *gin.Context, limit string) bool {
+	if limit == "" {
+		return true
+	}
+	if _, err := strconv.Atoi(limit); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit parameter"})
+		return false
+	}
+	return true
+}
+
 // SearchGuildChannels returns a gin.HandlerFunc that processes a request to search for channels in a Discord guild.
 func (api *API) SearchGuildChannels() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -521,6 +855,13 @@ func (api *API) SearchAllGuilds() gin.HandlerFunc {
 
 // WebData returns a gin.HandlerFunc that processes web scraping requests.
 // It expects a JSON body with fields "url" (string) and "depth" (int), representing the URL to scrape and the depth of the scrape, respectively.
+// The optional fields "maxPages", "maxBytes" and "timeBudget" (seconds) bound the crawl, "scope" (any, same-domain or same-prefix)
+// and the "include" and "exclude" regular expressions select the links it follows. The optional "content" field (markdown or text)
+// adds the main content of every page, without navigation and other boilerplate, to the response, and "metadata" (bool)
+// the structured data of every page: JSON-LD, OpenGraph and Twitter Card tags, microdata, feeds, dates and authors.
+// The optional "extract" field lists named fields to read from every page with CSS or XPath selectors.
+// With "discover" set to sitemap or feed, the pages listed in the sitemaps or feeds of the URL are scraped instead of
+// following links, and "since" (RFC 3339) skips the pages that did not change after it.
 // The handler validates the request body, ensuring the URL is not empty and the depth is positive.
 // If the node has not staked, it returns an error indicating the node cannot participate.
 // On a valid request, it attempts to scrape web data using the specified URL and depth.
@@ -532,13 +873,28 @@ func (api *API) WebData() gin.HandlerFunc {
 			return
 		}
 		var reqBody struct {
-			Url   string `json:"url"`
-			Depth int    `json:"depth"`
+			Url        string      `json:"url"`
+			Depth      int         `json:"depth"`
+			MaxPages   int         `json:"maxPages,omitempty"`
+			MaxBytes   int         `json:"maxBytes,omitempty"`
+			TimeBudget float64     `json:"timeBudget,omitempty"`
+			Scope      string      `json:"scope,omitempty"`
+			Include    []string    `json:"include,omitempty"`
+			Exclude    []string    `json:"exclude,omitempty"`
+			Content    string      `json:"content,omitempty"`
+			Metadata   bool        `json:"metadata,omitempty"`
+			Extract    []web.Field `json:"extract,omitempty"`
+			Discover   string      `json:"discover,omitempty"`
+			Since      string      `json:"since,omitempty"`
+			Format     string      `json:"format,omitempty"`
 		}
 		if err := c.ShouldBindJSON(&reqBody); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
 			return
 		}
+		if !bindFormat(c, &reqBody.Format) {
+			return
+		}
 
 		if reqBody.Url == "" {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "URL parameter is missing"})
@@ -547,6 +903,41 @@ func (api *API) WebData() gin.HandlerFunc {
 		if reqBody.Depth <= 0 {
 			reqBody.Depth = 1 // Default count
 		}
+		if reqBody.MaxPages < 0 || reqBody.MaxBytes < 0 || reqBody.TimeBudget < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Crawl budgets must not be negative"})
+			return
+		}
+		switch reqBody.Scope {
+		case "", web.ScopeAny, web.ScopeSameDomain, web.ScopeSamePrefix:
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid scope parameter"})
+			return
+		}
+		switch reqBody.Content {
+		case "", web.ContentMarkdown, web.ContentText:
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid content parameter"})
+			return
+		}
+		switch reqBody.Discover {
+		case "", web.DiscoverLinks, web.DiscoverSitemap, web.DiscoverFeed:
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid discover parameter"})
+			return
+		}
+		if !validSince(c, reqBody.Since) {
+			return
+		}
+		if err := web.ValidateFields(reqBody.Extract); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		for _, pattern := range append(reqBody.Include, reqBody.Exclude...) {
+			if _, err := regexp.Compile(pattern); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid pattern %q: %v", pattern, err)})
+				return
+			}
+		}
 
 		// worker handler implementation
 		bodyBytes, err := json.Marshal(reqBody)
@@ -569,10 +960,106 @@ func (api *API) WebData() gin.HandlerFunc {
 	}
 }
 
-// StartAuth starts the authentication process with Telegram.
+// WebFeed returns a gin.HandlerFunc that reads RSS, Atom and JSON feeds. It expects a JSON body with the field
+// "url", the address of a feed or of a web page that links to feeds. The optional field "since" (RFC 3339) skips the
+// entries that were not published or updated after it, and "limit" bounds the number of entries, the newest first.
+// With "format" set to normalized the entries are returned as normalized posts.
+func (api *API) WebFeed() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !api.Node.Options.IsStaked {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Node has not staked and cannot participate"})
+			return
+		}
+		var reqBody struct {
+			Url    string `json:"url"`
+			Since  string `json:"since,omitempty"`
+			Limit  int    `json:"limit,omitempty"`
+			Format string `json:"format,omitempty"`
+		}
+		if err := c.ShouldBindJSON(&reqBody); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+		if !bindFormat(c, &reqBody.Format) {
+			return
+		}
+		if reqBody.Url == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "URL parameter is missing"})
+			return
+		}
+		if reqBody.Limit < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Limit must not be negative"})
+			return
+		}
+		if !validSince(c, reqBody.Since) {
+			return
+		}
+
+		bodyBytes, err := json.Marshal(reqBody)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		api.sendTrackingEvent(data_types.WebFeed, bodyBytes)
+		requestID := uuid.New().String()
+		responseCh := workers.GetResponseChannelMap().CreateChannel(requestID)
+		wg := &sync.WaitGroup{}
+		defer workers.GetResponseChannelMap().Delete(requestID)
+		go handleWorkResponse(c, responseCh, wg)
+
+		err = api.sendWorkRequest(requestID, data_types.WebFeed, bodyBytes, wg)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		wg.Wait()
+	}
+}
+
+// validSince responds with 400 and returns false if since is set and is not an RFC 3339 date.
+func validSince(c *gin.Context, since string) bool {
+	if since == "" {
+		return true
+	}
+	if _, err := time.Parse(time.RFC3339, since); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid since parameter, expected an RFC 3339 date"})
+		return false
+	}
+	return true
+}
+
+// GetBlob returns a gin.HandlerFunc that serves the content of a blob, such as downloaded media,
+// from this node's blob store. It expects the CID of the blob as the "cid" URL parameter.
+func (api *API) GetBlob() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		store, err := blobstore.New(blobstore.Dir(api.Node.Options.MasaDir))
+		if err != nil {
+			handleError(c, "Failed to open blob store", err)
+			return
+		}
+		data, info, err := store.Get(c.Param("cid"))
+		if err != nil {
+			switch {
+			case errors.Is(err, blobstore.ErrInvalidCID):
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			case errors.Is(err, blobstore.ErrBlobNotFound):
+				c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			default:
+				handleError(c, "Failed to read blob", err)
+			}
+			return
+		}
+		c.Header("Cache-Control", "public, max-age=31536000, immutable")
+		c.Data(http.StatusOK, info.MimeType, data)
+	}
+}
+
+// StartAuth starts the authentication process with Telegram. The optional "session" field names
+// the session to sign in, so that a node can hold several Telegram accounts.
 func (api *API) StartAuth() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var reqBody struct {
+			Session     string `json:"session"`
 			PhoneNumber string `json:"phone_number"`
 		}
 		if err := c.ShouldBindJSON(&reqBody); err != nil {
@@ -580,7 +1067,11 @@ func (api *API) StartAuth() gin.HandlerFunc {
 			return
 		}
 
-		phoneCodeHash, err := telegram.StartAuthentication(context.Background(), reqBody.PhoneNumber)
+		phoneCodeHash, err := telegram.StartAuthentication(context.Background(), reqBody.Session, reqBody.PhoneNumber)
+		if errors.Is(err, telegram.ErrInvalidSessionName) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start authentication"})
 			return
@@ -590,10 +1081,11 @@ func (api *API) StartAuth() gin.HandlerFunc {
 	}
 }
 
-// CompleteAuth completes the authentication process with Telegram.
+// CompleteAuth completes the authentication process with Telegram for the session given to StartAuth.
 func (api *API) CompleteAuth() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var reqBody struct {
+			Session       string `json:"session"`
 			PhoneNumber   string `json:"phone_number"`
 			Code          string `json:"code"`
 			PhoneCodeHash string `json:"phone_code_hash"`
@@ -604,13 +1096,17 @@ func (api *API) CompleteAuth() gin.HandlerFunc {
 			return
 		}
 
-		auth, err := telegram.CompleteAuthentication(context.Background(), reqBody.PhoneNumber, reqBody.Code, reqBody.PhoneCodeHash, reqBody.Password)
+		auth, err := telegram.CompleteAuthentication(context.Background(), reqBody.Session, reqBody.PhoneNumber, reqBody.Code, reqBody.PhoneCodeHash, reqBody.Password)
 		if err != nil {
 			// Check if 2FA is required
-			if err.Error() == "2FA required" {
+			if errors.Is(err, tgauth.ErrPasswordAuthNeeded) {
 				c.JSON(http.StatusUnauthorized, gin.H{"error": "Two-factor authentication is required"})
 				return
 			}
+			if errors.Is(err, telegram.ErrSessionNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Unknown session, start the authentication first"})
+				return
+			}
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to complete authentication", "details": err.Error()})
 			return
 		}
@@ -622,7 +1118,14 @@ func (api *API) CompleteAuth() gin.HandlerFunc {
 func (api *API) GetChannelMessagesHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var reqBody struct {
-			Username string `json:"username"` // Telegram usernames are used instead of channel IDs
+			Username string `json:"username,omitempty"` // Telegram usernames are used instead of channel IDs
+			ChatID   int64  `json:"chatId,omitempty"`   // Basic groups have no username
+			PostID   int    `json:"postId,omitempty"`
+			OffsetID int    `json:"offsetId,omitempty"`
+			Since    string `json:"since,omitempty"`
+			Until    string `json:"until,omitempty"`
+			Limit    int    `json:"limit,omitempty"`
+			Format   string `json:"format,omitempty"`
 		}
 
 		// Bind the JSON body to the struct
@@ -630,9 +1133,22 @@ func (api *API) GetChannelMessagesHandler() gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
 			return
 		}
+		if !bindFormat(c, &reqBody.Format) {
+			return
+		}
 
-		if reqBody.Username == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Username parameter is missing"})
+		if reqBody.Username == "" && reqBody.ChatID == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Username or chatId parameter is missing"})
+			return
+		}
+		for _, date := range []string{reqBody.Since, reqBody.Until} {
+			if _, err := time.Parse(time.RFC3339, date); date != "" && err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Dates must be in RFC 3339 format"})
+				return
+			}
+		}
+		if reqBody.PostID < 0 || reqBody.OffsetID < 0 || reqBody.Limit < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid postId, offsetId or limit parameter"})
 			return
 		}
 
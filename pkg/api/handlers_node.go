@@ -382,6 +382,7 @@ func (api *API) NodeStatusPageHandler() gin.HandlerFunc {
 			"IsDiscordScraper":  false,
 			"IsTelegramScraper": false,
 			"IsWebScraper":      false,
+			"IsRedditScraper":   false,
 			"FirstJoined":       fromUnixTime(time.Now().Unix()),
 			"LastJoined":        fromUnixTime(time.Now().Unix()),
 			"CurrentUptime":     "0",
@@ -401,6 +402,7 @@ func (api *API) NodeStatusPageHandler() gin.HandlerFunc {
 				templateData["IsDiscordScraper"] = nd.IsDiscordScraper
 				templateData["IsTelegramScraper"] = nd.IsTelegramScraper
 				templateData["IsWebScraper"] = nd.IsWebScraper
+				templateData["IsRedditScraper"] = nd.IsRedditScraper
 				templateData["FirstJoined"] = fromUnixTime(nd.FirstJoinedUnix)
 				templateData["LastJoined"] = fromUnixTime(nd.LastJoinedUnix)
 				templateData["CurrentUptime"] = pubsub.PrettyDuration(nd.GetCurrentUptime())
@@ -0,0 +1,146 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	data_types "github.com/Gzgod/masa-oracle/pkg/workers/types"
+)
+
+// redditListingParams are the query parameters shared by the Reddit listing endpoints.
+type redditListingParams struct {
+	Sort   string `json:"sort,omitempty"`
+	Time   string `json:"t,omitempty"`
+	After  string `json:"after,omitempty"`
+	Limit  int    `json:"limit,omitempty"`
+	Format string `json:"format,omitempty"`
+}
+
+// bindRedditListing reads the listing parameters from the query string. It writes a 400 response
+// and returns false if they are invalid.
+func bindRedditListing(c *gin.Context, params *redditListingParams) bool {
+	params.Sort = c.Query("sort")
+	params.Time = c.Query("t")
+	params.After = c.Query("after")
+	if !queryInt(c, "limit", &params.Limit) {
+		return false
+	}
+	return bindFormat(c, &params.Format)
+}
+
+// queryInt parses the named query parameter into value if it is set. It writes a 400 response
+// and returns false if it is not a positive number.
+func queryInt(c *gin.Context, name string, value *int) bool {
+	raw := c.Query(name)
+	if raw == "" {
+		return true
+	}
+	parsed, err := strconv.Atoi(raw)
+	if err != nil || parsed < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + name + " parameter"})
+		return false
+	}
+	*value = parsed
+	return true
+}
+
+// SearchSubredditPosts returns a gin.HandlerFunc that processes a request for the posts of a subreddit.
+// The "sort" query parameter selects hot (default), new, top or rising posts, "t" the time window of top posts,
+// "after" the pagination token of the previous page and "limit" the number of posts.
+func (api *API) SearchSubredditPosts() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqParams struct {
+			Subreddit string `json:"subreddit"`
+			redditListingParams
+		}
+
+		reqParams.Subreddit = c.Param("subreddit")
+		if reqParams.Subreddit == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Subreddit must be provided and valid"})
+			return
+		}
+		if !bindRedditListing(c, &reqParams.redditListingParams) {
+			return
+		}
+
+		api.handleWorkRequest(c, data_types.RedditSubreddit, reqParams)
+	}
+}
+
+// SearchRedditUserPosts returns a gin.HandlerFunc that processes a request for the posts submitted by a Reddit user,
+// with the same query parameters as SearchSubredditPosts.
+func (api *API) SearchRedditUserPosts() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqParams struct {
+			Username string `json:"username"`
+			redditListingParams
+		}
+
+		reqParams.Username = c.Param("username")
+		if reqParams.Username == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Username must be provided and valid"})
+			return
+		}
+		if !bindRedditListing(c, &reqParams.redditListingParams) {
+			return
+		}
+
+		api.handleWorkRequest(c, data_types.RedditUserPosts, reqParams)
+	}
+}
+
+// SearchRedditComments returns a gin.HandlerFunc that processes a request for a Reddit post and its comment tree.
+func (api *API) SearchRedditComments() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqParams struct {
+			PostID string `json:"postId"`
+			Sort   string `json:"sort,omitempty"`
+			Depth  int    `json:"depth,omitempty"`
+			Limit  int    `json:"limit,omitempty"`
+			Format string `json:"format,omitempty"`
+		}
+
+		reqParams.PostID = c.Param("postID")
+		if reqParams.PostID == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "PostID must be provided and valid"})
+			return
+		}
+		reqParams.Sort = c.Query("sort")
+		if !queryInt(c, "depth", &reqParams.Depth) || !queryInt(c, "limit", &reqParams.Limit) || !bindFormat(c, &reqParams.Format) {
+			return
+		}
+
+		api.handleWorkRequest(c, data_types.RedditComments, reqParams)
+	}
+}
+
+// SearchReddit returns a gin.HandlerFunc that processes a Reddit search request. It expects a JSON body with
+// the "query" and optionally a "subreddit" to search in, and the sort, time window and pagination options.
+func (api *API) SearchReddit() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody struct {
+			Query     string `json:"query"`
+			Subreddit string `json:"subreddit,omitempty"`
+			redditListingParams
+		}
+		if err := c.ShouldBindJSON(&reqBody); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+		if reqBody.Query == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Query parameter is missing"})
+			return
+		}
+		if reqBody.Limit < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit parameter"})
+			return
+		}
+		if !bindFormat(c, &reqBody.Format) {
+			return
+		}
+
+		api.handleWorkRequest(c, data_types.RedditSearch, reqBody)
+	}
+}
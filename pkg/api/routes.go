@@ -166,6 +166,7 @@ func SetupRoutes(node *node.OracleNode, workerManager *workers.WorkHandlerManage
 		// @Produce  json
 		// @Param   username   path    string  true  "Twitter Username"
 		// @Param   count   query   int     false  "Maximum number of users to return"  default(20)
+		// @Param   format   query   string  false  "Result format: raw (default) or normalized"
 		// @Success 200 {array} Profile "Array of profiles a user has as followers"
 		// @Failure 400 {object} ErrorResponse "Invalid username or error fetching followers"
 		// @Router /data/twitter/followers/{username} [get]
@@ -177,6 +178,7 @@ func SetupRoutes(node *node.OracleNode, workerManager *workers.WorkHandlerManage
 		// @Accept  json
 		// @Produce  json
 		// @Param   username   path    string  true  "Twitter Username"
+		// @Param   format   query   string  false  "Result format: raw (default) or normalized"
 		// @Success 200 {array} Tweet "List of tweets from the profile"
 		// @Failure 400 {object} ErrorResponse "Invalid username or error fetching tweets"
 		// @Router /data/twitter/profile/{username} [get]
@@ -188,6 +190,7 @@ func SetupRoutes(node *node.OracleNode, workerManager *workers.WorkHandlerManage
 		// @Accept json
 		// @Produce json
 		// @Param body body object true "Search Query"
+		// @Param   format   query   string  false  "Result format: raw (default) or normalized"
 		// @Success 200 {array} Tweet "List of recent tweets"
 		// @Failure 400 {object} ErrorResponse "Invalid query or error fetching tweets"
 		// @Router /data/twitter/tweets/recent [post]
@@ -198,6 +201,7 @@ func SetupRoutes(node *node.OracleNode, workerManager *workers.WorkHandlerManage
 		// @Example toUser {"query": "to:getmasafi", "count": 10}
 		// @Example language {"query": "Masa lang:en", "count": 10}
 		// @Example dateRange {"query": "Masa since:2021-01-01 until:2021-12-31", "count": 10}
+		// @Example withMedia {"query": "#MasaNode", "count": 10, "downloadMedia": true}
 		// @Example excludeRetweets {"query": "Masa -filter:retweets", "count": 10}
 		// @Example minLikes {"query": "Masa min_faves:100", "count": 10}
 		// @Example minRetweets {"query": "Masa min_retweets:50", "count": 10}
@@ -209,49 +213,130 @@ func SetupRoutes(node *node.OracleNode, workerManager *workers.WorkHandlerManage
 		// @Example safeSearch {"query": "Masa filter:safe", "count": 10}
 		v1.POST("/data/twitter/tweets/recent", API.SearchTweetsRecent())
 
+		// @Summary List Twitter accounts
+		// @Description Lists the Twitter accounts of this node with their status and usage counters
+		// @Tags Admin
+		// @Accept  json
+		// @Produce  json
+		// @Success 200 {array} AccountInfo "List of Twitter accounts"
+		// @Failure 400 {object} ErrorResponse "Node is not a Twitter scraper"
+		// @Router /admin/twitter/accounts [get]
+		v1.GET("/admin/twitter/accounts", API.GetTwitterAccountsHandler())
+
+		// @Summary Disable a Twitter account
+		// @Description Removes a Twitter account from the scraping rotation
+		// @Tags Admin
+		// @Accept  json
+		// @Produce  json
+		// @Param   username   path    string  true  "Twitter Username"
+		// @Success 200 {object} SuccessResponse "Account disabled"
+		// @Failure 404 {object} ErrorResponse "Account not found"
+		// @Router /admin/twitter/accounts/{username}/disable [post]
+		v1.POST("/admin/twitter/accounts/:username/disable", API.SetTwitterAccountDisabledHandler(true))
+
+		// @Summary Enable a Twitter account
+		// @Description Returns a disabled Twitter account to the scraping rotation
+		// @Tags Admin
+		// @Accept  json
+		// @Produce  json
+		// @Param   username   path    string  true  "Twitter Username"
+		// @Success 200 {object} SuccessResponse "Account enabled"
+		// @Failure 404 {object} ErrorResponse "Account not found"
+		// @Router /admin/twitter/accounts/{username}/enable [post]
+		v1.POST("/admin/twitter/accounts/:username/enable", API.SetTwitterAccountDisabledHandler(false))
+
+		// @Summary Force Twitter account re-login
+		// @Description Discards the stored cookies of a Twitter account and logs in again
+		// @Tags Admin
+		// @Accept  json
+		// @Produce  json
+		// @Param   username   path    string  true  "Twitter Username"
+		// @Success 200 {object} SuccessResponse "Re-login successful"
+		// @Failure 404 {object} ErrorResponse "Account not found"
+		// @Failure 500 {object} ErrorResponse "Login failed"
+		// @Router /admin/twitter/accounts/{username}/relogin [post]
+		v1.POST("/admin/twitter/accounts/:username/relogin", API.ReloginTwitterAccountHandler())
+
 		// @Summary Search Discord Profile
 		// @Description Retrieves a Discord user profile by user ID.
 		// @Tags Discord
 		// @Accept  json
 		// @Produce  json
 		// @Param   userID   path    string  true  "Discord User ID"
+		// @Param   format   query   string  false  "Result format: raw (default) or normalized"
 		// @Success 200 {object} UserProfile "Successfully retrieved Discord user profile"
 		// @Failure 400 {object} ErrorResponse "Invalid user ID or error fetching profile"
 		// @Router /discord/profile/{userID} [get]
 		v1.GET("/data/discord/profile/:userID", API.SearchDiscordProfile())
 
 		// @Summary Start Telegram Authentication
-		// @Description Initiates the authentication process with Telegram by sending a code to the provided phone number.
+		// @Description Initiates the authentication process with Telegram by sending a code to the provided phone number. The optional session field names the session to sign in, so that a node can hold several Telegram accounts; the default session is used if omitted.
 		// @Tags Authentication
 		// @Accept  json
 		// @Produce  json
+		// @Param   session        body    string  false "Session Name"
 		// @Param   phone_number   body    string  true  "Phone Number"
 		// @Success 200 {object} map[string]interface{} "Successfully sent authentication code"
-		// @Failure 400 {object} ErrorResponse "Invalid request body"
+		// @Failure 400 {object} ErrorResponse "Invalid request body or session name"
 		// @Failure 500 {object} ErrorResponse "Failed to initialize Telegram client or to start authentication"
 		// @Router /auth/telegram/start [post]
 		v1.POST("/auth/telegram/start", API.StartAuth())
 
 		// @Summary Complete Telegram Authentication
-		// @Description Completes the authentication process with Telegram using the code sent to the phone number.
+		// @Description Completes the authentication process with Telegram using the code sent to the phone number, for the session given when it was started.
 		// @Tags Authentication
 		// @Accept  json
 		// @Produce  json
+		// @Param   session        body    string  false "Session Name"
 		// @Param   phone_number   body    string  true  "Phone Number"
 		// @Param   code           body    string  true  "Authentication Code"
 		// @Param   phone_code_hash body   string  true  "Phone Code Hash"
 		// @Success 200 {object} map[string]interface{} "Successfully authenticated"
 		// @Failure 400 {object} ErrorResponse "Invalid request body"
 		// @Failure 401 {object} ErrorResponse "Two-factor authentication is required"
+		// @Failure 404 {object} ErrorResponse "Unknown session"
 		// @Failure 500 {object} ErrorResponse "Failed to initialize Telegram client or to complete authentication"
 		// @Router /auth/telegram/complete [post]
 		v1.POST("/auth/telegram/complete", API.CompleteAuth())
 
+		// @Summary List Telegram sessions
+		// @Description Lists the Telegram sessions of this node with their status and usage counters
+		// @Tags Admin
+		// @Accept  json
+		// @Produce  json
+		// @Success 200 {array} SessionInfo "List of Telegram sessions"
+		// @Failure 400 {object} ErrorResponse "Node is not a Telegram scraper"
+		// @Router /admin/telegram/sessions [get]
+		v1.GET("/admin/telegram/sessions", API.GetTelegramSessionsHandler())
+
+		// @Summary Disable a Telegram session
+		// @Description Removes a Telegram session from the scraping rotation
+		// @Tags Admin
+		// @Accept  json
+		// @Produce  json
+		// @Param   name   path    string  true  "Session Name"
+		// @Success 200 {object} SuccessResponse "Session disabled"
+		// @Failure 404 {object} ErrorResponse "Session not found"
+		// @Router /admin/telegram/sessions/{name}/disable [post]
+		v1.POST("/admin/telegram/sessions/:name/disable", API.SetTelegramSessionDisabledHandler(true))
+
+		// @Summary Enable a Telegram session
+		// @Description Returns a disabled Telegram session to the scraping rotation
+		// @Tags Admin
+		// @Accept  json
+		// @Produce  json
+		// @Param   name   path    string  true  "Session Name"
+		// @Success 200 {object} SuccessResponse "Session enabled"
+		// @Failure 404 {object} ErrorResponse "Session not found"
+		// @Router /admin/telegram/sessions/{name}/enable [post]
+		v1.POST("/admin/telegram/sessions/:name/enable", API.SetTelegramSessionDisabledHandler(false))
+
 		// @Summary Get Telegram Channel Messages
-		// @Description Retrieves messages from a specified Telegram channel.
+		// @Description Retrieves messages from a Telegram channel or supergroup by username, or a basic group by chatId, from the newest to the oldest. Set postId to retrieve the comments of a channel post. The body may set offsetId (start below this message ID), since and until (RFC 3339 dates) and limit (total messages, 100 by default).
 		// @Tags Telegram
 		// @Accept  json
 		// @Produce  json
+		// @Param   format   query   string  false  "Result format: raw (default) or normalized"
 		// @Success 200 {object} map[string][]Message "Successfully retrieved messages"
 		// @Failure 400 {object} ErrorResponse "Username must be provided"
 		// @Failure 500 {object} ErrorResponse "Failed to fetch channel messages"
@@ -267,11 +352,81 @@ func SetupRoutes(node *node.OracleNode, workerManager *workers.WorkHandlerManage
 		// @Accept  json
 		// @Produce  json
 		// @Param   channelID   path    string  true  "Discord Channel ID"
+		// @Param   format   query   string  false  "Result format: raw (default) or normalized"
 		// @Success 200 {array} ChannelMessage "Successfully retrieved messages from the Discord channel"
 		// @Failure 400 {object} ErrorResponse "Invalid channel ID or error fetching messages"
 		// @Router /channels/{channelID}/messages [get]
 		v1.GET("data/discord/channels/:channelID/messages", API.SearchChannelMessages())
 
+		// @Summary Export the history of a Discord channel
+		// @Description Walks the message history of a Discord channel page by page, backward from the newest message or from the "before" cursor, or forward from the "after" cursor, until the date bound, the limit or the end of the channel is reached. Results are streamed as newline delimited JSON, one line per work request, each with its chunks of messages and the cursor to resume from.
+		// @Tags Discord
+		// @Accept  json
+		// @Produce  application/x-ndjson
+		// @Param   channelID   path    string  true  "Discord Channel ID"
+		// @Param   body        body    object  false "Export options: direction (backward or forward), before, after, since and until (RFC 3339), limit, chunkSize and format"
+		// @Param   format   query   string  false  "Result format: raw (default) or normalized"
+		// @Success 200 {object} discord.ChannelHistory "Stream of channel history results"
+		// @Failure 400 {object} ErrorResponse "Invalid channel ID or export options"
+		// @Router /data/discord/channels/{channelID}/history [post]
+		v1.POST("/data/discord/channels/:channelID/history", API.ExportChannelHistory())
+
+		// @Summary Get threads of a Discord channel
+		// @Description Retrieves the active threads of a Discord channel, or a page of its archived threads. For a forum channel the threads are its posts.
+		// @Tags Discord
+		// @Accept  json
+		// @Produce  json
+		// @Param   channelID   path    string  true  "Discord Channel ID"
+		// @Param   archived   query   string  false  "Archived threads to list: public or private. Active threads are listed if omitted"
+		// @Param   before   query   string  false  "List archived threads archived before this ISO 8601 timestamp"
+		// @Param   limit   query   string  false  "Maximum number of archived threads to return, at most 100"
+		// @Success 200 {object} discord.ThreadList "Successfully retrieved threads of the Discord channel"
+		// @Failure 400 {object} ErrorResponse "Invalid channel ID or error fetching threads"
+		// @Router /data/discord/channels/{channelID}/threads [get]
+		v1.GET("/data/discord/channels/:channelID/threads", API.SearchChannelThreads())
+
+		// @Summary Get messages from a Discord thread
+		// @Description Retrieves messages from a Discord thread or forum post.
+		// @Tags Discord
+		// @Accept  json
+		// @Produce  json
+		// @Param   threadID   path    string  true  "Discord Thread ID"
+		// @Param   limit   query   string  false  "Maximum number of messages to return, at most 100"
+		// @Param   before   query   string  false  "Return messages before this message ID"
+		// @Param   format   query   string  false  "Result format: raw (default) or normalized"
+		// @Success 200 {array} discord.ChannelMessage "Successfully retrieved messages from the Discord thread"
+		// @Failure 400 {object} ErrorResponse "Invalid thread ID or error fetching messages"
+		// @Router /data/discord/threads/{threadID}/messages [get]
+		v1.GET("/data/discord/threads/:threadID/messages", API.SearchThreadMessages())
+
+		// @Summary Get pinned messages of a Discord channel
+		// @Description Retrieves the pinned messages of a Discord channel or thread.
+		// @Tags Discord
+		// @Accept  json
+		// @Produce  json
+		// @Param   channelID   path    string  true  "Discord Channel ID"
+		// @Param   format   query   string  false  "Result format: raw (default) or normalized"
+		// @Success 200 {array} discord.ChannelMessage "Successfully retrieved pinned messages"
+		// @Failure 400 {object} ErrorResponse "Invalid channel ID or error fetching pinned messages"
+		// @Router /data/discord/channels/{channelID}/pins [get]
+		v1.GET("/data/discord/channels/:channelID/pins", API.SearchPinnedMessages())
+
+		// @Summary Get users who reacted to a Discord message
+		// @Description Retrieves the users who reacted to a Discord message with an emoji, paged by user ID.
+		// @Tags Discord
+		// @Accept  json
+		// @Produce  json
+		// @Param   channelID   path    string  true  "Discord Channel ID"
+		// @Param   messageID   path    string  true  "Discord Message ID"
+		// @Param   emoji   path    string  true  "Unicode emoji, or name:id for a custom emoji"
+		// @Param   after   query   string  false  "Return users after this user ID"
+		// @Param   limit   query   string  false  "Maximum number of users to return, at most 100"
+		// @Param   format   query   string  false  "Result format: raw (default) or normalized"
+		// @Success 200 {array} discord.UserProfile "Successfully retrieved reaction users"
+		// @Failure 400 {object} ErrorResponse "Invalid parameters or error fetching reaction users"
+		// @Router /data/discord/channels/{channelID}/messages/{messageID}/reactions/{emoji} [get]
+		v1.GET("/data/discord/channels/:channelID/messages/:messageID/reactions/:emoji", API.SearchReactionUsers())
+
 		// @Summary Get channels from a Discord guild
 		// @Description Retrieves channels from a specified Discord guild.
 		// @Tags Discord
@@ -308,12 +463,97 @@ func SetupRoutes(node *node.OracleNode, workerManager *workers.WorkHandlerManage
 		// @Tags Web
 		// @Accept  json
 		// @Produce  json
-		// @Param   url   body    object  true  "Web Data Request"  example({"url": "https://hedgey.finance/"})
+		// @Param   url   body    object  true  "Web Data Request"  example({"url": "https://hedgey.finance/", "depth": 2, "maxPages": 20, "scope": "same-domain", "exclude": ["/tag/"]})
+		// @Param   format   query   string  false  "Result format: raw (default) or normalized"
 		// @Success 200 {object} WebDataResponse "Successfully retrieved web data"
 		// @Failure 400 {object} ErrorResponse "Invalid URL or error fetching web data"
 		// @Router /data/web [post]
 		v1.POST("/data/web", API.WebData())
 
+		// @Summary Web Feed
+		// @Description Reads the entries of an RSS, Atom or JSON feed, or of the feeds a web page links to, the newest first
+		// @Tags Web
+		// @Accept  json
+		// @Produce  json
+		// @Param   url   body    object  true  "Web Feed Request"  example({"url": "https://blog.example.com/feed.xml", "since": "2024-06-01T00:00:00Z", "limit": 20})
+		// @Param   format   query   string  false  "Result format: raw (default) or normalized"
+		// @Success 200 {object} web.FeedResult "Successfully retrieved the feed entries"
+		// @Failure 400 {object} ErrorResponse "Invalid URL or parameters"
+		// @Failure 404 {object} ErrorResponse "No feed found for the URL"
+		// @Router /data/web/feed [post]
+		v1.POST("/data/web/feed", API.WebFeed())
+
+		// @Summary Get Subreddit Posts
+		// @Description Retrieves the posts of a subreddit, from the first page or from the page after the given token
+		// @Tags Reddit
+		// @Accept  json
+		// @Produce  json
+		// @Param   subreddit   path    string  true  "Subreddit name, without the r/ prefix"
+		// @Param   sort   query   string  false  "hot (default), new, top or rising"
+		// @Param   t   query   string  false  "Time window of top posts: hour, day, week, month, year or all"
+		// @Param   after   query   string  false  "Pagination token returned as after by the previous page"
+		// @Param   limit   query   int  false  "Number of posts, 25 by default and at most 1000"
+		// @Param   format   query   string  false  "Result format: raw (default) or normalized"
+		// @Success 200 {object} reddit.Listing "Successfully retrieved the posts of the subreddit"
+		// @Failure 400 {object} ErrorResponse "Invalid subreddit or parameters"
+		// @Failure 429 {object} ErrorResponse "Reddit API rate limit exceeded"
+		// @Router /data/reddit/subreddits/{subreddit}/posts [get]
+		v1.GET("/data/reddit/subreddits/:subreddit/posts", API.SearchSubredditPosts())
+
+		// @Summary Get Reddit Comments
+		// @Description Retrieves a Reddit post and its comment tree. Comments Reddit leaves out of large trees are listed under more
+		// @Tags Reddit
+		// @Accept  json
+		// @Produce  json
+		// @Param   postID   path    string  true  "Post ID, with or without the t3_ prefix"
+		// @Param   sort   query   string  false  "confidence (default), top, new, controversial, old or qa"
+		// @Param   depth   query   int  false  "Number of reply levels"
+		// @Param   limit   query   int  false  "Number of comments"
+		// @Param   format   query   string  false  "Result format: raw (default) or normalized"
+		// @Success 200 {object} reddit.CommentTree "Successfully retrieved the comments of the post"
+		// @Failure 400 {object} ErrorResponse "Invalid post ID or parameters"
+		// @Router /data/reddit/posts/{postID}/comments [get]
+		v1.GET("/data/reddit/posts/:postID/comments", API.SearchRedditComments())
+
+		// @Summary Get Reddit User Posts
+		// @Description Retrieves the posts submitted by a Reddit user
+		// @Tags Reddit
+		// @Accept  json
+		// @Produce  json
+		// @Param   username   path    string  true  "Reddit username, without the u/ prefix"
+		// @Param   sort   query   string  false  "hot (default), new or top"
+		// @Param   t   query   string  false  "Time window of top posts: hour, day, week, month, year or all"
+		// @Param   after   query   string  false  "Pagination token returned as after by the previous page"
+		// @Param   limit   query   int  false  "Number of posts, 25 by default and at most 1000"
+		// @Param   format   query   string  false  "Result format: raw (default) or normalized"
+		// @Success 200 {object} reddit.Listing "Successfully retrieved the posts of the user"
+		// @Failure 400 {object} ErrorResponse "Invalid username or parameters"
+		// @Router /data/reddit/users/{username}/posts [get]
+		v1.GET("/data/reddit/users/:username/posts", API.SearchRedditUserPosts())
+
+		// @Summary Search Reddit
+		// @Description Searches the posts of Reddit, or of one subreddit
+		// @Tags Reddit
+		// @Accept  json
+		// @Produce  json
+		// @Param   search   body    object  true  "Search Request"  example({"query": "masa", "subreddit": "cryptocurrency", "sort": "new", "limit": 50})
+		// @Param   format   query   string  false  "Result format: raw (default) or normalized"
+		// @Success 200 {object} reddit.Listing "Successfully retrieved the matching posts"
+		// @Failure 400 {object} ErrorResponse "Invalid search request"
+		// @Router /data/reddit/search [post]
+		v1.POST("/data/reddit/search", API.SearchReddit())
+
+		// @Summary Get Blob
+		// @Description Retrieves a blob, such as media downloaded with a Twitter request, by its CID.
+		// @Tags Data
+		// @Produce  octet-stream
+		// @Param   cid   path    string  true  "CID of the blob"
+		// @Success 200 {file} file "Content of the blob"
+		// @Failure 400 {object} ErrorResponse "Invalid CID"
+		// @Failure 404 {object} ErrorResponse "Blob not found"
+		// @Router /data/blobs/{cid} [get]
+		v1.GET("/data/blobs/:cid", API.GetBlob())
+
 		// @Summary Get DHT Data
 		// @Description Retrieves data from the DHT (Distributed Hash Table)
 		// @Tags DHT
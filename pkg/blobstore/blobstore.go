@@ -0,0 +1,157 @@
+// Package blobstore is a content-addressed store for binary data, such as media downloaded by
+// the scrapers. Blobs are addressed by their CID (CIDv1, raw codec, SHA2-256), so the same content
+// is only stored once and every copy of a blob can be verified against its address.
+package blobstore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ipfs/go-cid"
+
+	"github.com/Gzgod/masa-oracle/pkg/masacrypto"
+)
+
+// DirName is the name of the blob store directory inside the masa directory.
+const DirName = "blobs"
+
+var (
+	// ErrBlobNotFound is returned when the store has no blob with the requested CID.
+	ErrBlobNotFound = errors.New("blob not found")
+	// ErrInvalidCID is returned when a CID cannot be parsed.
+	ErrInvalidCID = errors.New("invalid CID")
+	// ErrCIDMismatch is returned when the content of a blob does not match the CID it was given under.
+	ErrCIDMismatch = errors.New("content does not match CID")
+)
+
+// BlobInfo holds the metadata stored next to every blob.
+type BlobInfo struct {
+	CID       string    `json:"cid"`
+	MimeType  string    `json:"mimeType"`
+	Size      int64     `json:"size"`
+	SourceURL string    `json:"sourceUrl,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Store keeps blobs as files named after their CID in a single directory.
+type Store struct {
+	dir string
+}
+
+// Dir returns the blob store directory for the given masa directory.
+func Dir(masaDir string) string {
+	return filepath.Join(masaDir, DirName)
+}
+
+// New returns a Store rooted at dir, creating the directory if needed.
+func New(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("error creating blob store: %v", err)
+	}
+	return &Store{dir: dir}, nil
+}
+
+// Put stores data and returns its CID. sourceURL records where the data was fetched from and may be empty.
+func (s *Store) Put(data []byte, sourceURL string) (BlobInfo, error) {
+	cidKey, err := masacrypto.ComputeSha256CidFromBytes(data)
+	if err != nil {
+		return BlobInfo{}, fmt.Errorf("error computing CID: %v", err)
+	}
+	return s.write(cidKey, data, sourceURL)
+}
+
+// PutVerified stores data under the given CID after checking that the CID matches the content.
+// It is used for blobs received from other nodes.
+func (s *Store) PutVerified(cidKey string, data []byte) (BlobInfo, error) {
+	if _, err := cid.Decode(cidKey); err != nil {
+		return BlobInfo{}, fmt.Errorf("%w: %s", ErrInvalidCID, cidKey)
+	}
+	actual, err := masacrypto.ComputeSha256CidFromBytes(data)
+	if err != nil {
+		return BlobInfo{}, fmt.Errorf("error computing CID: %v", err)
+	}
+	if actual != cidKey {
+		return BlobInfo{}, fmt.Errorf("%w: expected %s, got %s", ErrCIDMismatch, cidKey, actual)
+	}
+	return s.write(cidKey, data, "")
+}
+
+// Get returns the content and metadata of the blob with the given CID.
+func (s *Store) Get(cidKey string) ([]byte, BlobInfo, error) {
+	info, err := s.Stat(cidKey)
+	if err != nil {
+		return nil, BlobInfo{}, err
+	}
+	data, err := os.ReadFile(s.blobPath(cidKey))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, BlobInfo{}, ErrBlobNotFound
+		}
+		return nil, BlobInfo{}, fmt.Errorf("error reading blob: %v", err)
+	}
+	return data, info, nil
+}
+
+// Stat returns the metadata of the blob with the given CID.
+func (s *Store) Stat(cidKey string) (BlobInfo, error) {
+	if _, err := cid.Decode(cidKey); err != nil {
+		return BlobInfo{}, fmt.Errorf("%w: %s", ErrInvalidCID, cidKey)
+	}
+	data, err := os.ReadFile(s.infoPath(cidKey))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return BlobInfo{}, ErrBlobNotFound
+		}
+		return BlobInfo{}, fmt.Errorf("error reading blob info: %v", err)
+	}
+	var info BlobInfo
+	if err = json.Unmarshal(data, &info); err != nil {
+		return BlobInfo{}, fmt.Errorf("error unmarshaling blob info: %v", err)
+	}
+	return info, nil
+}
+
+// Has reports whether the store holds the blob with the given CID.
+func (s *Store) Has(cidKey string) bool {
+	_, err := s.Stat(cidKey)
+	return err == nil
+}
+
+func (s *Store) write(cidKey string, data []byte, sourceURL string) (BlobInfo, error) {
+	if info, err := s.Stat(cidKey); err == nil {
+		return info, nil
+	}
+
+	info := BlobInfo{
+		CID:       cidKey,
+		MimeType:  http.DetectContentType(data),
+		Size:      int64(len(data)),
+		SourceURL: sourceURL,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := os.WriteFile(s.blobPath(cidKey), data, 0644); err != nil {
+		return BlobInfo{}, fmt.Errorf("error writing blob: %v", err)
+	}
+	infoBytes, err := json.Marshal(info)
+	if err != nil {
+		return BlobInfo{}, fmt.Errorf("error marshaling blob info: %v", err)
+	}
+	// The metadata is written last, so a blob only becomes visible once its content is complete.
+	if err = os.WriteFile(s.infoPath(cidKey), infoBytes, 0644); err != nil {
+		return BlobInfo{}, fmt.Errorf("error writing blob info: %v", err)
+	}
+	return info, nil
+}
+
+func (s *Store) blobPath(cidKey string) string {
+	return filepath.Join(s.dir, cidKey)
+}
+
+func (s *Store) infoPath(cidKey string) string {
+	return filepath.Join(s.dir, cidKey+".json")
+}
@@ -0,0 +1,77 @@
+package blobstore
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/Gzgod/masa-oracle/pkg/masacrypto"
+)
+
+func TestPutAndGet(t *testing.T) {
+	store, err := New(t.TempDir())
+	if err != nil {
+		t.Fatal("[-] Failed to create blob store:", err)
+	}
+
+	content := []byte("\x89PNG\r\n\x1a\nnot really an image")
+	info, err := store.Put(content, "https://pbs.twimg.com/media/a.png")
+	if err != nil {
+		t.Fatal("[-] Failed to store blob:", err)
+	}
+
+	expected, _ := masacrypto.ComputeSha256Cid(string(content))
+	if info.CID != expected {
+		t.Fatalf("[-] Expected CID %s, got %s", expected, info.CID)
+	}
+	if info.MimeType != "image/png" {
+		t.Fatalf("[-] Expected image/png, got %s", info.MimeType)
+	}
+
+	data, stored, err := store.Get(info.CID)
+	if err != nil {
+		t.Fatal("[-] Failed to read blob:", err)
+	}
+	if !bytes.Equal(data, content) || stored.SourceURL != info.SourceURL {
+		t.Fatal("[-] Stored blob does not match")
+	}
+
+	again, err := store.Put(content, "")
+	if err != nil || again.CID != info.CID {
+		t.Fatal("[-] Storing the same content twice should return the same CID")
+	}
+}
+
+func TestPutVerified(t *testing.T) {
+	store, err := New(t.TempDir())
+	if err != nil {
+		t.Fatal("[-] Failed to create blob store:", err)
+	}
+
+	content := []byte("video bytes")
+	cidKey, _ := masacrypto.ComputeSha256CidFromBytes(content)
+
+	if _, err = store.PutVerified(cidKey, []byte("tampered")); !errors.Is(err, ErrCIDMismatch) {
+		t.Fatalf("[-] Expected ErrCIDMismatch, got %v", err)
+	}
+	if _, err = store.PutVerified("../../etc/passwd", content); !errors.Is(err, ErrInvalidCID) {
+		t.Fatalf("[-] Expected ErrInvalidCID, got %v", err)
+	}
+	if _, err = store.PutVerified(cidKey, content); err != nil {
+		t.Fatal("[-] Failed to store verified blob:", err)
+	}
+	if !store.Has(cidKey) {
+		t.Fatal("[-] Verified blob was not stored")
+	}
+}
+
+func TestGetMissing(t *testing.T) {
+	store, err := New(t.TempDir())
+	if err != nil {
+		t.Fatal("[-] Failed to create blob store:", err)
+	}
+	cidKey, _ := masacrypto.ComputeSha256CidFromBytes([]byte("missing"))
+	if _, _, err = store.Get(cidKey); !errors.Is(err, ErrBlobNotFound) {
+		t.Fatalf("[-] Expected ErrBlobNotFound, got %v", err)
+	}
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"log"
+	"os"
 	"os/user"
 	"path/filepath"
 	"reflect"
@@ -47,6 +48,11 @@ type AppConfig struct {
 	CachePath            string   `mapstructure:"cachePath"`
 	Faucet               bool     `mapstructure:"faucet"`
 
+	// CredentialPassphrase is only read from the environment, never from a flag, so that it does
+	// not show in the process list. CredentialPassphraseFile holds it otherwise.
+	CredentialPassphrase     string `mapstructure:"-"`
+	CredentialPassphraseFile string `mapstructure:"credentialPassphraseFile"`
+
 	// These may be moved to a separate struct
 	TwitterCookiesPath string `mapstructure:"twitterCookiesPath"`
 	TwitterUsername    string `mapstructure:"twitterUsername"`
@@ -57,8 +63,34 @@ type AppConfig struct {
 	DiscordScraper     bool   `mapstructure:"discordScraper"`
 	TelegramScraper    bool   `mapstructure:"telegramScraper"`
 	WebScraper         bool   `mapstructure:"webScraper"`
+	RedditScraper      bool   `mapstructure:"redditScraper"`
 	APIEnabled         bool   `mapstructure:"api_enabled"`
 
+	DiscordGateway         bool   `mapstructure:"discordGateway"`
+	DiscordGatewayGuilds   string `mapstructure:"discordGatewayGuilds"`
+	DiscordGatewayChannels string `mapstructure:"discordGatewayChannels"`
+	DiscordGatewayPublish  bool   `mapstructure:"discordGatewayPublish"`
+	DiscordGatewayStore    bool   `mapstructure:"discordGatewayStore"`
+
+	TelegramUpdates         bool   `mapstructure:"telegramUpdates"`
+	TelegramUpdatesChannels string `mapstructure:"telegramUpdatesChannels"`
+	TelegramUpdatesGroups   string `mapstructure:"telegramUpdatesGroups"`
+	TelegramUpdatesPublish  bool   `mapstructure:"telegramUpdatesPublish"`
+	TelegramUpdatesWebhook  string `mapstructure:"telegramUpdatesWebhook"`
+	TelegramUpdatesSession  string `mapstructure:"telegramUpdatesSession"`
+
+	WebAllowedDomains   string `mapstructure:"webAllowedDomains"`
+	WebDeniedDomains    string `mapstructure:"webDeniedDomains"`
+	WebUserAgent        string `mapstructure:"webUserAgent"`
+	WebRespectRobotsTxt bool   `mapstructure:"webRespectRobotsTxt"`
+	WebParallelism      int    `mapstructure:"webParallelism"`
+	WebDelay            string `mapstructure:"webDelay"`
+	WebDomainLimits     string `mapstructure:"webDomainLimits"`
+	WebWatchURLs        string `mapstructure:"webWatchUrls"`
+	WebWatchInterval    string `mapstructure:"webWatchInterval"`
+	WebWatchPublish     bool   `mapstructure:"webWatchPublish"`
+	WebWatchWebhook     string `mapstructure:"webWatchWebhook"`
+
 	KeyManager   *masacrypto.KeyManager
 	TelegramStop bg.StopFunc
 }
@@ -88,6 +120,7 @@ func GetConfig() (*AppConfig, error) {
 	}
 
 	instance.APIEnabled = viper.GetBool("api_enabled")
+	instance.CredentialPassphrase = viper.GetString(CredentialPassphrase)
 
 	keyManager, err := masacrypto.NewKeyManager(instance.PrivateKey, instance.PrivateKeyFile)
 	if err != nil {
@@ -95,9 +128,44 @@ func GetConfig() (*AppConfig, error) {
 	}
 	instance.KeyManager = keyManager
 
+	if err := instance.initCredentialStore(); err != nil {
+		return nil, err
+	}
+
 	return instance, nil
 }
 
+// initCredentialStore sets up the store used to encrypt scraper credentials at rest. The key is
+// derived from the operator passphrase if one is configured, in the environment or in a file,
+// otherwise from the node's private key.
+func (c *AppConfig) initCredentialStore() error {
+	var (
+		store *masacrypto.CredentialStore
+		err   error
+	)
+	passphrase := c.CredentialPassphrase
+	if passphrase == "" && c.CredentialPassphraseFile != "" {
+		data, err := os.ReadFile(c.CredentialPassphraseFile)
+		if err != nil {
+			return fmt.Errorf("Failed to read credential passphrase file: %v", err)
+		}
+		passphrase = strings.TrimRight(string(data), "\r\n")
+		if passphrase == "" {
+			return fmt.Errorf("Credential passphrase file %s is empty", c.CredentialPassphraseFile)
+		}
+	}
+	if passphrase != "" {
+		store, err = masacrypto.NewCredentialStoreFromPassphrase(passphrase, filepath.Join(c.MasaDir, masacrypto.PassphraseSaltFile))
+	} else {
+		store, err = masacrypto.NewCredentialStoreFromKey(c.KeyManager.Libp2pPrivKey)
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to initialize credential store: %v", err)
+	}
+	masacrypto.SetDefaultCredentialStore(store)
+	return nil
+}
+
 // setDefaultConfig sets the default configuration values for the AppConfig instance.
 // It retrieves the user's home directory and sets default values for various configuration options
 // such as the MasaDir, Bootnodes, RpcUrl, Environment, FilePath, Validator, and CachePath.
@@ -124,6 +192,10 @@ func (c *AppConfig) setDefaultConfig() {
 	viper.SetDefault(PrivKeyFile, filepath.Join(viper.GetString(MasaDir), "masa_oracle_key"))
 
 	viper.SetDefault("api_enabled", false)
+	viper.SetDefault(DiscordGatewayPublish, true)
+	viper.SetDefault(TelegramUpdatesPublish, true)
+	viper.SetDefault(WebWatchInterval, "1h")
+	viper.SetDefault(WebWatchPublish, true)
 }
 
 // setFileConfig loads configuration from a YAML file.
@@ -184,7 +256,31 @@ func (c *AppConfig) setCommandLineConfig() error {
 	pflag.BoolVar(&c.DiscordScraper, "discordScraper", viper.GetBool(DiscordScraper), "Discord Scraper")
 	pflag.BoolVar(&c.TelegramScraper, "telegramScraper", viper.GetBool(TelegramScraper), "Telegram Scraper")
 	pflag.BoolVar(&c.WebScraper, "webScraper", viper.GetBool(WebScraper), "Web Scraper")
+	pflag.BoolVar(&c.RedditScraper, "redditScraper", viper.GetBool(RedditScraper), "Reddit Scraper")
+	pflag.BoolVar(&c.DiscordGateway, "discordGateway", viper.GetBool(DiscordGateway), "Receive Discord messages in real time over the gateway")
+	pflag.StringVar(&c.DiscordGatewayGuilds, "discordGatewayGuilds", viper.GetString(DiscordGatewayGuilds), "Comma-separated list of Discord guild IDs to receive messages from")
+	pflag.StringVar(&c.DiscordGatewayChannels, "discordGatewayChannels", viper.GetString(DiscordGatewayChannels), "Comma-separated list of Discord channel IDs to receive messages from")
+	pflag.BoolVar(&c.DiscordGatewayPublish, "discordGatewayPublish", viper.GetBool(DiscordGatewayPublish), "Publish received Discord messages to the pubsub topic")
+	pflag.BoolVar(&c.DiscordGatewayStore, "discordGatewayStore", viper.GetBool(DiscordGatewayStore), "Store received Discord messages in the masa directory")
+	pflag.BoolVar(&c.TelegramUpdates, "telegramUpdates", viper.GetBool(TelegramUpdates), "Receive new Telegram messages in real time")
+	pflag.StringVar(&c.TelegramUpdatesChannels, "telegramUpdatesChannels", viper.GetString(TelegramUpdatesChannels), "Comma-separated list of Telegram channel and supergroup usernames to receive messages from")
+	pflag.StringVar(&c.TelegramUpdatesGroups, "telegramUpdatesGroups", viper.GetString(TelegramUpdatesGroups), "Comma-separated list of Telegram basic group IDs to receive messages from")
+	pflag.BoolVar(&c.TelegramUpdatesPublish, "telegramUpdatesPublish", viper.GetBool(TelegramUpdatesPublish), "Publish received Telegram messages to the pubsub topic")
+	pflag.StringVar(&c.TelegramUpdatesWebhook, "telegramUpdatesWebhook", viper.GetString(TelegramUpdatesWebhook), "URL to post received Telegram messages to")
+	pflag.StringVar(&c.TelegramUpdatesSession, "telegramUpdatesSession", viper.GetString(TelegramUpdatesSession), "Name of the Telegram session that receives the messages, the default session if empty")
+	pflag.StringVar(&c.WebAllowedDomains, "webAllowedDomains", viper.GetString(WebAllowedDomains), "Comma-separated list of domains the web scraper is limited to, all public domains if empty")
+	pflag.StringVar(&c.WebDeniedDomains, "webDeniedDomains", viper.GetString(WebDeniedDomains), "Comma-separated list of domains the web scraper never fetches")
+	pflag.StringVar(&c.WebUserAgent, "webUserAgent", viper.GetString(WebUserAgent), "User agent of the web scraper, a default one naming MasaWebScraper if empty")
+	pflag.BoolVar(&c.WebRespectRobotsTxt, "webRespectRobotsTxt", viper.GetBool(WebRespectRobotsTxt), "Skip the pages robots.txt disallows and wait its crawl delay between requests")
+	pflag.IntVar(&c.WebParallelism, "webParallelism", viper.GetInt(WebParallelism), "Number of requests the web scraper sends to a host at the same time, 4 if zero")
+	pflag.StringVar(&c.WebDelay, "webDelay", viper.GetString(WebDelay), "Time between two requests of the web scraper to a host, such as 500ms")
+	pflag.StringVar(&c.WebDomainLimits, "webDomainLimits", viper.GetString(WebDomainLimits), "Comma-separated list of domain=parallelism/delay rates, such as example.com=1/2s")
+	pflag.StringVar(&c.WebWatchURLs, "webWatchUrls", viper.GetString(WebWatchURLs), "Comma-separated list of web pages to check for changes")
+	pflag.StringVar(&c.WebWatchInterval, "webWatchInterval", viper.GetString(WebWatchInterval), "Time between two checks of the watched web pages, such as 30m or 6h")
+	pflag.BoolVar(&c.WebWatchPublish, "webWatchPublish", viper.GetBool(WebWatchPublish), "Publish the changes of the watched web pages to the pubsub topic")
+	pflag.StringVar(&c.WebWatchWebhook, "webWatchWebhook", viper.GetString(WebWatchWebhook), "URL to post the changes of the watched web pages to")
 	pflag.BoolVar(&c.Faucet, "faucet", viper.GetBool(Faucet), "Faucet")
+	pflag.StringVar(&c.CredentialPassphraseFile, "credentialPassphraseFile", viper.GetString(CredentialPassphraseFile), "File holding the passphrase used to encrypt stored scraper credentials (defaults to a key derived from the node key)")
 	pflag.BoolVar(&c.APIEnabled, "api-enabled", viper.GetBool("api_enabled"), "Enable API server")
 
 	pflag.Parse()
@@ -214,7 +310,7 @@ func (c *AppConfig) LogConfig() {
 		value := val.Field(i).Interface()
 
 		// Skipping sensitive fields
-		if field.Name == "PrivateKey" || field.Name == "Signature" || field.Name == "PrivateKeyFile" {
+		if field.Name == "PrivateKey" || field.Name == "Signature" || field.Name == "PrivateKeyFile" || field.Name == "CredentialPassphrase" {
 			continue
 		}
 		logrus.Infof("%s: %v", field.Name, value)
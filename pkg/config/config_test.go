@@ -22,6 +22,7 @@ var _ = Describe("InitOptions", func() {
 			DiscordScraper:  true,
 			TelegramScraper: true,
 			WebScraper:      true,
+			RedditScraper:   true,
 		}
 
 		opts, _, _ := InitOptions(&conf)
@@ -48,6 +49,7 @@ var _ = Describe("InitOptions", func() {
 			IsDiscordScraper:     conf.DiscordScraper,
 			IsTelegramScraper:    conf.TelegramScraper,
 			IsWebScraper:         conf.WebScraper,
+			IsRedditScraper:      conf.RedditScraper,
 			Bootnodes:            conf.Bootnodes,
 			RandomIdentity:       false,
 			ProtocolHandlers:     nil,
@@ -21,15 +21,21 @@ const (
 	CachePath   = "CACHE_PATH"
 	Faucet      = "FAUCET"
 
-	OracleProtocol       = "oracle_protocol"
-	WorkerProtocol       = "worker_protocol"
-	NodeDataSyncProtocol = "nodeDataSync"
-	NodeGossipTopic      = "gossip"
-	PublicKeyTopic       = "bootNodePublicKey"
-	WorkerTopic          = "workerTopic"
-	BlockTopic           = "blockTopic"
-	Rendezvous           = "masa-mdns"
-	PageSize             = 25
+	CredentialPassphrase     = "CREDENTIAL_PASSPHRASE"
+	CredentialPassphraseFile = "CREDENTIAL_PASSPHRASE_FILE"
+
+	OracleProtocol        = "oracle_protocol"
+	WorkerProtocol        = "worker_protocol"
+	NodeDataSyncProtocol  = "nodeDataSync"
+	NodeGossipTopic       = "gossip"
+	PublicKeyTopic        = "bootNodePublicKey"
+	WorkerTopic           = "workerTopic"
+	BlockTopic            = "blockTopic"
+	DiscordMessagesTopic  = "discordMessages"
+	TelegramMessagesTopic = "telegramMessages"
+	WebChangesTopic       = "webChanges"
+	Rendezvous            = "masa-mdns"
+	PageSize              = 25
 
 	TwitterUsername = "TWITTER_USERNAME"
 	TwitterPassword = "TWITTER_PASSWORD"
@@ -37,7 +43,31 @@ const (
 	DiscordBotToken = "DISCORD_BOT_TOKEN"
 	TwitterScraper  = "TWITTER_SCRAPER"
 	DiscordScraper  = "DISCORD_SCRAPER"
-	TelegramScraper = "TELEGRAM_SCRAPER"
-	WebScraper      = "WEB_SCRAPER"
-	APIEnabled      = "API_ENABLED"
+
+	DiscordGateway          = "DISCORD_GATEWAY"
+	DiscordGatewayGuilds    = "DISCORD_GATEWAY_GUILDS"
+	DiscordGatewayChannels  = "DISCORD_GATEWAY_CHANNELS"
+	DiscordGatewayPublish   = "DISCORD_GATEWAY_PUBLISH"
+	DiscordGatewayStore     = "DISCORD_GATEWAY_STORE"
+	TelegramScraper         = "TELEGRAM_SCRAPER"
+	TelegramUpdates         = "TELEGRAM_UPDATES"
+	TelegramUpdatesChannels = "TELEGRAM_UPDATES_CHANNELS"
+	TelegramUpdatesGroups   = "TELEGRAM_UPDATES_GROUPS"
+	TelegramUpdatesPublish  = "TELEGRAM_UPDATES_PUBLISH"
+	TelegramUpdatesWebhook  = "TELEGRAM_UPDATES_WEBHOOK"
+	TelegramUpdatesSession  = "TELEGRAM_UPDATES_SESSION"
+	WebScraper              = "WEB_SCRAPER"
+	WebAllowedDomains       = "WEB_ALLOWED_DOMAINS"
+	WebDeniedDomains        = "WEB_DENIED_DOMAINS"
+	WebUserAgent            = "WEB_USER_AGENT"
+	WebRespectRobotsTxt     = "WEB_RESPECT_ROBOTS_TXT"
+	WebParallelism          = "WEB_PARALLELISM"
+	WebDelay                = "WEB_DELAY"
+	WebDomainLimits         = "WEB_DOMAIN_LIMITS"
+	WebWatchURLs            = "WEB_WATCH_URLS"
+	WebWatchInterval        = "WEB_WATCH_INTERVAL"
+	WebWatchPublish         = "WEB_WATCH_PUBLISH"
+	WebWatchWebhook         = "WEB_WATCH_WEBHOOK"
+	RedditScraper           = "REDDIT_SCRAPER"
+	APIEnabled              = "API_ENABLED"
 )
@@ -1,8 +1,18 @@
 package config
 
 import (
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
 	"github.com/Gzgod/masa-oracle/node"
 	"github.com/Gzgod/masa-oracle/pkg/pubsub"
+	"github.com/Gzgod/masa-oracle/pkg/scrapers/discord"
+	"github.com/Gzgod/masa-oracle/pkg/scrapers/telegram"
+	"github.com/Gzgod/masa-oracle/pkg/scrapers/web"
 	"github.com/Gzgod/masa-oracle/pkg/workers"
 )
 
@@ -46,22 +56,111 @@ func InitOptions(cfg *AppConfig) ([]node.Option, *workers.WorkHandlerManager, *p
 
 	if cfg.TwitterScraper {
 		workerManagerOptions = append(workerManagerOptions, workers.EnableTwitterWorker)
-		masaNodeOptions = append(masaNodeOptions, node.IsTwitterScraper)
+		masaNodeOptions = append(masaNodeOptions,
+			node.IsTwitterScraper,
+			node.WithService(workers.AdvertiseTwitterBudget(cfg.MasaDir)),
+		)
 	}
 
 	if cfg.TelegramScraper {
-		// TODO: Telegram scraper is not implemented yet in the worker (?)
+		workerManagerOptions = append(workerManagerOptions, workers.EnableTelegramWorker)
 		masaNodeOptions = append(masaNodeOptions, node.IsTelegramScraper)
+		cfg.TelegramStop = telegram.Stop
+
+		if cfg.TelegramUpdates {
+			updatesOptions := workers.TelegramUpdatesOptions{
+				Updates: telegram.UpdatesConfig{
+					Channels:  splitList(cfg.TelegramUpdatesChannels),
+					Session:   cfg.TelegramUpdatesSession,
+					StateFile: filepath.Join(cfg.MasaDir, workers.TelegramUpdatesDir, "updates-state.json"),
+				},
+				WebhookURL: cfg.TelegramUpdatesWebhook,
+			}
+			for _, group := range splitList(cfg.TelegramUpdatesGroups) {
+				id, err := strconv.ParseInt(group, 10, 64)
+				if err != nil {
+					logrus.Warnf("[-] Ignoring invalid Telegram group ID %q", group)
+					continue
+				}
+				updatesOptions.Updates.Groups = append(updatesOptions.Updates.Groups, id)
+			}
+			if cfg.TelegramUpdatesPublish {
+				updatesOptions.Topic = TelegramMessagesTopic
+			}
+			masaNodeOptions = append(masaNodeOptions, node.WithService(workers.IngestTelegramUpdates(updatesOptions)))
+		}
 	}
 
 	if cfg.DiscordScraper {
 		workerManagerOptions = append(workerManagerOptions, workers.EnableDiscordScraperWorker)
 		masaNodeOptions = append(masaNodeOptions, node.IsDiscordScraper)
+
+		if cfg.DiscordGateway {
+			gatewayOptions := workers.DiscordGatewayOptions{
+				Gateway: discord.GatewayConfig{
+					Token:      cfg.DiscordBotToken,
+					GuildIDs:   splitList(cfg.DiscordGatewayGuilds),
+					ChannelIDs: splitList(cfg.DiscordGatewayChannels),
+				},
+			}
+			if cfg.DiscordGatewayPublish {
+				gatewayOptions.Topic = DiscordMessagesTopic
+			}
+			if cfg.DiscordGatewayStore {
+				gatewayOptions.StoreDir = filepath.Join(cfg.MasaDir, workers.DiscordGatewayDir)
+			}
+			masaNodeOptions = append(masaNodeOptions, node.WithService(workers.IngestDiscordGateway(gatewayOptions)))
+		}
 	}
 
 	if cfg.WebScraper {
 		workerManagerOptions = append(workerManagerOptions, workers.EnableWebScraperWorker)
 		masaNodeOptions = append(masaNodeOptions, node.IsWebScraper)
+		web.SetDefaultPolicy(web.Policy{
+			AllowedDomains: splitList(cfg.WebAllowedDomains),
+			DeniedDomains:  splitList(cfg.WebDeniedDomains),
+		})
+		politeness := web.Politeness{
+			UserAgent:        cfg.WebUserAgent,
+			RespectRobotsTxt: cfg.WebRespectRobotsTxt,
+			Parallelism:      cfg.WebParallelism,
+		}
+		if delay, err := time.ParseDuration(cfg.WebDelay); err == nil {
+			politeness.Delay = delay
+		} else if cfg.WebDelay != "" {
+			logrus.Warnf("[-] Ignoring invalid web scraper delay %q", cfg.WebDelay)
+		}
+		for _, spec := range splitList(cfg.WebDomainLimits) {
+			limit, err := web.ParseDomainLimit(spec)
+			if err != nil {
+				logrus.Warnf("[-] Ignoring %v", err)
+				continue
+			}
+			politeness.Domains = append(politeness.Domains, limit)
+		}
+		web.SetDefaultPoliteness(politeness)
+
+		if pages := splitList(cfg.WebWatchURLs); len(pages) > 0 {
+			watchOptions := workers.WebWatchOptions{
+				Watch:      web.WatchConfig{URLs: pages},
+				StoreDir:   filepath.Join(cachePath, workers.WebWatchDir),
+				WebhookURL: cfg.WebWatchWebhook,
+			}
+			if interval, err := time.ParseDuration(cfg.WebWatchInterval); err == nil {
+				watchOptions.Watch.Interval = interval
+			} else if cfg.WebWatchInterval != "" {
+				logrus.Warnf("[-] Ignoring invalid web watch interval %q", cfg.WebWatchInterval)
+			}
+			if cfg.WebWatchPublish {
+				watchOptions.Topic = WebChangesTopic
+			}
+			masaNodeOptions = append(masaNodeOptions, node.WithService(workers.WatchWebPages(watchOptions)))
+		}
+	}
+
+	if cfg.RedditScraper {
+		workerManagerOptions = append(workerManagerOptions, workers.EnableRedditWorker)
+		masaNodeOptions = append(masaNodeOptions, node.IsRedditScraper)
 	}
 
 	workHandlerManager := workers.NewWorkHandlerManager(workerManagerOptions...)
@@ -102,3 +201,14 @@ func InitOptions(cfg *AppConfig) ([]node.Option, *workers.WorkHandlerManager, *p
 
 	return masaNodeOptions, workHandlerManager, pubKeySub
 }
+
+// splitList splits a comma-separated configuration value, dropping empty items.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
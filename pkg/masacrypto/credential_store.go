@@ -0,0 +1,262 @@
+package masacrypto
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/hkdf"
+	"golang.org/x/crypto/scrypt"
+)
+
+// ErrCredentialDecrypt is returned when a credential file cannot be decrypted, usually because it
+// was written with a different node key or passphrase.
+var ErrCredentialDecrypt = errors.New("unable to decrypt credential file")
+
+const (
+	credentialMagic    = "MASACRED1"
+	credentialSaltSize = 16
+	credentialFileMode = 0600
+	credentialDirMode  = 0700
+	credentialKDFInfo  = "masa-oracle credential store"
+
+	// PassphraseSaltFile is the name of the file in the masa dir that holds the node's random salt
+	// for deriving the credential key from a passphrase.
+	PassphraseSaltFile = "credential_passphrase.salt"
+)
+
+var (
+	defaultCredentialStore *CredentialStore
+	credentialStoreMutex   sync.RWMutex
+)
+
+// CredentialStore encrypts files that hold account credentials, such as scraper cookies and
+// sessions, with AES-256-GCM. Every file gets its own key, derived with HKDF from the store's
+// secret and a random salt stored in the file header.
+//
+// A nil *CredentialStore is valid and stores files unencrypted, but still with strict permissions.
+type CredentialStore struct {
+	secret []byte
+}
+
+// NewCredentialStoreFromKey returns a CredentialStore whose secret is the node's private key.
+func NewCredentialStoreFromKey(privKey crypto.PrivKey) (*CredentialStore, error) {
+	raw, err := privKey.Raw()
+	if err != nil {
+		return nil, fmt.Errorf("error reading private key: %v", err)
+	}
+	return &CredentialStore{secret: raw}, nil
+}
+
+// NewCredentialStoreFromPassphrase returns a CredentialStore whose secret is derived from an
+// operator supplied passphrase and the salt stored at saltPath. The salt is generated randomly the
+// first time, so the same passphrase yields a different key on every node.
+func NewCredentialStoreFromPassphrase(passphrase, saltPath string) (*CredentialStore, error) {
+	if passphrase == "" {
+		return nil, errors.New("passphrase must not be empty")
+	}
+	salt, err := loadPassphraseSalt(saltPath)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, 32)
+	if err != nil {
+		return nil, fmt.Errorf("error deriving key from passphrase: %v", err)
+	}
+	return &CredentialStore{secret: secret}, nil
+}
+
+// loadPassphraseSalt reads the salt at path, or generates and writes a new one if there is none.
+func loadPassphraseSalt(path string) ([]byte, error) {
+	salt, err := os.ReadFile(path)
+	if err == nil {
+		if len(salt) != credentialSaltSize {
+			return nil, fmt.Errorf("invalid passphrase salt in %s", path)
+		}
+		return salt, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("error reading passphrase salt: %v", err)
+	}
+
+	salt = make([]byte, credentialSaltSize)
+	if _, err = rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("error generating passphrase salt: %v", err)
+	}
+	if err = MkdirPrivate(filepath.Dir(path)); err != nil {
+		return nil, err
+	}
+	if err = os.WriteFile(path, salt, credentialFileMode); err != nil {
+		return nil, fmt.Errorf("error writing passphrase salt: %v", err)
+	}
+	return salt, nil
+}
+
+// MkdirPrivate creates dir, readable only by the current user, and tightens the permissions of
+// an existing dir that allows access to other users.
+func MkdirPrivate(dir string) error {
+	if err := os.MkdirAll(dir, credentialDirMode); err != nil {
+		return fmt.Errorf("error creating credential directory: %v", err)
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("error reading credential directory: %v", err)
+	}
+	if info.Mode().Perm()&^credentialDirMode != 0 {
+		if err = os.Chmod(dir, credentialDirMode); err != nil {
+			return fmt.Errorf("error setting credential directory permissions: %v", err)
+		}
+	}
+	return nil
+}
+
+// SetDefaultCredentialStore sets the store used by the scrapers to persist credentials.
+func SetDefaultCredentialStore(store *CredentialStore) {
+	credentialStoreMutex.Lock()
+	defer credentialStoreMutex.Unlock()
+	defaultCredentialStore = store
+}
+
+// DefaultCredentialStore returns the store set with SetDefaultCredentialStore, or nil if none was set.
+func DefaultCredentialStore() *CredentialStore {
+	credentialStoreMutex.RLock()
+	defer credentialStoreMutex.RUnlock()
+	return defaultCredentialStore
+}
+
+// WriteFile encrypts data and atomically writes it to path, readable only by the current user.
+func (s *CredentialStore) WriteFile(path string, data []byte) error {
+	if s != nil {
+		encrypted, err := s.encrypt(data)
+		if err != nil {
+			return err
+		}
+		data = encrypted
+	}
+
+	if err := MkdirPrivate(filepath.Dir(path)); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return fmt.Errorf("error creating credential file: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("error writing credential file: %v", err)
+	}
+	if err = tmp.Chmod(credentialFileMode); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("error setting credential file permissions: %v", err)
+	}
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("error writing credential file: %v", err)
+	}
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("error writing credential file: %v", err)
+	}
+	return nil
+}
+
+// ReadFile reads and decrypts the file at path. Files written before encryption was introduced
+// are returned as they are and re-written encrypted, so existing credentials migrate on first use.
+// The permissions of the file are tightened if they allow access to other users.
+func (s *CredentialStore) ReadFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if info, statErr := os.Stat(path); statErr == nil && info.Mode().Perm()&^credentialFileMode != 0 {
+		if err = os.Chmod(path, credentialFileMode); err != nil {
+			logrus.WithError(err).Warnf("Unable to restrict permissions of %s", path)
+		}
+	}
+
+	if !IsEncryptedCredential(data) {
+		if s != nil {
+			if err = s.WriteFile(path, data); err != nil {
+				logrus.WithError(err).Warnf("Unable to encrypt plaintext credential file %s", path)
+			} else {
+				logrus.Infof("Encrypted plaintext credential file %s", path)
+			}
+		}
+		return data, nil
+	}
+
+	if s == nil {
+		return nil, fmt.Errorf("%w: %s is encrypted but no credential store is configured", ErrCredentialDecrypt, path)
+	}
+	return s.decrypt(data)
+}
+
+// IsEncryptedCredential reports whether data was written by a CredentialStore.
+func IsEncryptedCredential(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(credentialMagic))
+}
+
+func (s *CredentialStore) encrypt(plaintext []byte) ([]byte, error) {
+	salt := make([]byte, credentialSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("error generating salt: %v", err)
+	}
+	aead, err := s.cipher(salt)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("error generating nonce: %v", err)
+	}
+
+	out := make([]byte, 0, len(credentialMagic)+len(salt)+len(nonce)+len(plaintext)+aead.Overhead())
+	out = append(out, credentialMagic...)
+	out = append(out, salt...)
+	out = append(out, nonce...)
+	return aead.Seal(out, nonce, plaintext, []byte(credentialMagic)), nil
+}
+
+func (s *CredentialStore) decrypt(data []byte) ([]byte, error) {
+	data = data[len(credentialMagic):]
+	if len(data) < credentialSaltSize {
+		return nil, ErrCredentialDecrypt
+	}
+	salt, data := data[:credentialSaltSize], data[credentialSaltSize:]
+	aead, err := s.cipher(salt)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < aead.NonceSize() {
+		return nil, ErrCredentialDecrypt
+	}
+	nonce, ciphertext := data[:aead.NonceSize()], data[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, []byte(credentialMagic))
+	if err != nil {
+		return nil, ErrCredentialDecrypt
+	}
+	return plaintext, nil
+}
+
+func (s *CredentialStore) cipher(salt []byte) (cipher.AEAD, error) {
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, s.secret, salt, []byte(credentialKDFInfo)), key); err != nil {
+		return nil, fmt.Errorf("error deriving credential key: %v", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
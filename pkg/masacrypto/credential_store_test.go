@@ -0,0 +1,167 @@
+package masacrypto
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+)
+
+func newTestCredentialStore(t *testing.T) *CredentialStore {
+	privKey, _, err := crypto.GenerateKeyPair(crypto.Secp256k1, 256)
+	if err != nil {
+		t.Fatal("[-] Failed to generate key:", err)
+	}
+	store, err := NewCredentialStoreFromKey(privKey)
+	if err != nil {
+		t.Fatal("[-] Failed to create credential store:", err)
+	}
+	return store
+}
+
+func TestCredentialStoreRoundTrip(t *testing.T) {
+	store := newTestCredentialStore(t)
+	path := filepath.Join(t.TempDir(), "alice_twitter_cookies.json")
+	secret := []byte(`[{"Name":"auth_token","Value":"secret"}]`)
+
+	if err := store.WriteFile(path, secret); err != nil {
+		t.Fatal("[-] Failed to write credential file:", err)
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal("[-] Failed to read credential file:", err)
+	}
+	if !IsEncryptedCredential(raw) || bytes.Contains(raw, []byte("secret")) {
+		t.Fatal("[-] Credential file is not encrypted")
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal("[-] Failed to stat credential file:", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Fatalf("[-] Expected mode 0600, got %v", info.Mode().Perm())
+	}
+
+	data, err := store.ReadFile(path)
+	if err != nil {
+		t.Fatal("[-] Failed to decrypt credential file:", err)
+	}
+	if !bytes.Equal(data, secret) {
+		t.Fatalf("[-] Expected %q, got %q", secret, data)
+	}
+}
+
+func TestCredentialStoreMigratesPlaintext(t *testing.T) {
+	store := newTestCredentialStore(t)
+	path := filepath.Join(t.TempDir(), "session.json")
+	secret := []byte(`{"Version":1}`)
+
+	if err := os.WriteFile(path, secret, 0644); err != nil {
+		t.Fatal("[-] Failed to write plaintext file:", err)
+	}
+
+	data, err := store.ReadFile(path)
+	if err != nil {
+		t.Fatal("[-] Failed to read plaintext file:", err)
+	}
+	if !bytes.Equal(data, secret) {
+		t.Fatalf("[-] Expected %q, got %q", secret, data)
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal("[-] Failed to read migrated file:", err)
+	}
+	if !IsEncryptedCredential(raw) {
+		t.Fatal("[-] Plaintext file was not migrated")
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal("[-] Failed to stat migrated file:", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Fatalf("[-] Expected mode 0600, got %v", info.Mode().Perm())
+	}
+}
+
+func TestCredentialStoreRejectsWrongKey(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "session.json")
+	saltPath := filepath.Join(dir, PassphraseSaltFile)
+
+	writer, err := NewCredentialStoreFromPassphrase("correct horse", saltPath)
+	if err != nil {
+		t.Fatal("[-] Failed to create credential store:", err)
+	}
+	if err = writer.WriteFile(path, []byte("secret")); err != nil {
+		t.Fatal("[-] Failed to write credential file:", err)
+	}
+
+	reader, err := NewCredentialStoreFromPassphrase("battery staple", saltPath)
+	if err != nil {
+		t.Fatal("[-] Failed to create credential store:", err)
+	}
+	if _, err = reader.ReadFile(path); !errors.Is(err, ErrCredentialDecrypt) {
+		t.Fatalf("[-] Expected ErrCredentialDecrypt, got %v", err)
+	}
+
+	var unconfigured *CredentialStore
+	if _, err = unconfigured.ReadFile(path); !errors.Is(err, ErrCredentialDecrypt) {
+		t.Fatalf("[-] Expected ErrCredentialDecrypt, got %v", err)
+	}
+}
+
+func TestCredentialStorePassphraseSaltPerNode(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "session.json")
+	saltPath := filepath.Join(dir, PassphraseSaltFile)
+
+	writer, err := NewCredentialStoreFromPassphrase("correct horse", saltPath)
+	if err != nil {
+		t.Fatal("[-] Failed to create credential store:", err)
+	}
+	if err = writer.WriteFile(path, []byte("secret")); err != nil {
+		t.Fatal("[-] Failed to write credential file:", err)
+	}
+
+	restarted, err := NewCredentialStoreFromPassphrase("correct horse", saltPath)
+	if err != nil {
+		t.Fatal("[-] Failed to create credential store:", err)
+	}
+	if data, err := restarted.ReadFile(path); err != nil || string(data) != "secret" {
+		t.Fatalf("[-] Expected the same node to decrypt the file, got %q, %v", data, err)
+	}
+
+	otherNode, err := NewCredentialStoreFromPassphrase("correct horse", filepath.Join(t.TempDir(), PassphraseSaltFile))
+	if err != nil {
+		t.Fatal("[-] Failed to create credential store:", err)
+	}
+	if _, err = otherNode.ReadFile(path); !errors.Is(err, ErrCredentialDecrypt) {
+		t.Fatalf("[-] Expected ErrCredentialDecrypt with another node's salt, got %v", err)
+	}
+}
+
+func TestCredentialStoreRestrictsExistingDirectory(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "session")
+	if err := os.Mkdir(dir, 0755); err != nil {
+		t.Fatal("[-] Failed to create directory:", err)
+	}
+
+	store := newTestCredentialStore(t)
+	if err := store.WriteFile(filepath.Join(dir, "session.json"), []byte("secret")); err != nil {
+		t.Fatal("[-] Failed to write credential file:", err)
+	}
+
+	info, err := os.Stat(dir)
+	if err != nil {
+		t.Fatal("[-] Failed to stat directory:", err)
+	}
+	if info.Mode().Perm() != 0700 {
+		t.Fatalf("[-] Expected mode 0700, got %v", info.Mode().Perm())
+	}
+}
@@ -20,14 +20,21 @@ import (
 // If an error occurs during the multihash computation or CID creation, it is returned.
 func ComputeSha256Cid(str string) (string, error) {
 	logrus.Infof("Computing CID for string: %s", str)
-	// Create a multihash from the string
-	mhHash, err := mh.Sum([]byte(str), mh.SHA2_256, -1)
+	cidKey, err := ComputeSha256CidFromBytes([]byte(str))
 	if err != nil {
 		logrus.Errorf("Error computing multihash for string: %s, error: %v", str, err)
 		return "", err
 	}
-	// Create a CID from the multihash
-	cidKey := cid.NewCidV1(cid.Raw, mhHash).String()
 	logrus.Infof("Computed CID: %s", cidKey)
 	return cidKey, nil
 }
+
+// ComputeSha256CidFromBytes calculates the same CID as ComputeSha256Cid for binary data,
+// without logging the content.
+func ComputeSha256CidFromBytes(data []byte) (string, error) {
+	mhHash, err := mh.Sum(data, mh.SHA2_256, -1)
+	if err != nil {
+		return "", err
+	}
+	return cid.NewCidV1(cid.Raw, mhHash).String(), nil
+}
@@ -60,6 +60,8 @@ type NodeData struct {
 	IsDiscordScraper     bool            `json:"isDiscordScraper"`
 	IsTelegramScraper    bool            `json:"isTelegramScraper"`
 	IsWebScraper         bool            `json:"isWebScraper"`
+	IsRedditScraper      bool            `json:"isRedditScraper"`
+	TwitterBudget        map[string]int  `json:"twitterBudget,omitempty"` // remaining Twitter requests per endpoint type
 	Records              any             `json:"records,omitempty"`
 	Version              string          `json:"version"`
 	WorkerTimeout        time.Time       `json:"workerTimeout,omitempty"`
@@ -110,11 +112,12 @@ const (
 	CategoryTelegram
 	CategoryTwitter
 	CategoryWeb
+	CategoryReddit
 )
 
 // String returns the string representation of the WorkerCategory
 func (wc WorkerCategory) String() string {
-	return [...]string{"Discord", "Telegram", "Twitter", "Web"}[wc]
+	return [...]string{"Discord", "Telegram", "Twitter", "Web", "Reddit"}[wc]
 }
 
 // CanDoWork checks if the node can perform work of the specified WorkerType.
@@ -132,6 +135,8 @@ func (n *NodeData) CanDoWork(workerType WorkerCategory) bool {
 		return n.IsTelegramScraper
 	case CategoryWeb:
 		return n.IsWebScraper
+	case CategoryReddit:
+		return n.IsRedditScraper
 	default:
 		return false
 	}
@@ -161,6 +166,12 @@ func (n *NodeData) WebScraper() bool {
 	return n.IsWebScraper
 }
 
+// RedditScraper checks if the current node is configured as a Reddit scraper.
+// It retrieves the configuration instance and returns the value of the RedditScraper field.
+func (n *NodeData) RedditScraper() bool {
+	return n.IsRedditScraper
+}
+
 // Joined updates the NodeData when the node joins the network.
 // It sets the join times, activity, active status, and logs based on stake status.
 func (n *NodeData) Joined(nodeVersion string) {
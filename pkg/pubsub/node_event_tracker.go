@@ -395,6 +395,8 @@ func (net *NodeEventTracker) AddOrUpdateNodeData(nodeData *NodeData, forceGossip
 		nd.IsTelegramScraper = nodeData.IsTelegramScraper
 		nd.IsTwitterScraper = nodeData.IsTwitterScraper
 		nd.IsWebScraper = nodeData.IsWebScraper
+		nd.IsRedditScraper = nodeData.IsRedditScraper
+		nd.TwitterBudget = nodeData.TwitterBudget
 		nd.Records = nodeData.Records
 		nd.Multiaddrs = nodeData.Multiaddrs
 		nd.EthAddress = nodeData.EthAddress
@@ -0,0 +1,234 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/Gzgod/masa-oracle/pkg/scrapers/normalized"
+)
+
+// discordEpoch is the first second of 2015, which Discord snowflakes count their timestamps from.
+const discordEpoch = 1420070400000
+
+// MaxMessagesPerPage is the largest page of messages the Discord API returns for one request.
+const MaxMessagesPerPage = 100
+
+// History walk directions.
+const (
+	DirectionBackward = "backward"
+	DirectionForward  = "forward"
+)
+
+// Reasons why a history walk stopped.
+const (
+	StopEnd   = "end"   // No more messages in the walk direction
+	StopDate  = "date"  // The Since or Until bound was reached
+	StopLimit = "limit" // The requested number of messages was collected
+	StopTime  = "time"  // The time budget ran out, the walk can be resumed from the cursor
+	StopError = "error" // A page could not be fetched, the walk can be resumed from the cursor
+)
+
+// HistoryOptions selects the part of a channel's history to export.
+type HistoryOptions struct {
+	ChannelID string
+	// Direction is DirectionBackward (newest to oldest, the default) or DirectionForward.
+	Direction string
+	// Before and After are the message IDs to start from, exclusive. Before is used when walking
+	// backward and After when walking forward.
+	Before string
+	After  string
+	// Since and Until bound the export by message date. Either may be zero.
+	Since time.Time
+	Until time.Time
+	// Limit is the maximum number of messages to collect. Zero means no limit.
+	Limit int
+	// ChunkSize is the number of messages requested per page, at most MaxMessagesPerPage.
+	ChunkSize int
+	// MaxDuration stops the walk after the given time, leaving a cursor to resume from. Zero means no limit.
+	MaxDuration time.Duration
+}
+
+// HistoryChunk is one page of a channel's history.
+type HistoryChunk struct {
+	Messages []ChannelMessage  `json:"messages,omitempty"`
+	Posts    []normalized.Post `json:"posts,omitempty"`
+	FirstID  string            `json:"firstId"`
+	LastID   string            `json:"lastId"`
+}
+
+// ChannelHistory is the result of a history walk.
+type ChannelHistory struct {
+	ChannelID    string         `json:"channelId"`
+	Direction    string         `json:"direction"`
+	Chunks       []HistoryChunk `json:"chunks"`
+	MessageCount int            `json:"messageCount"`
+	// Cursor is the ID of the last message collected, to be passed as Before or After to continue.
+	Cursor     string `json:"cursor,omitempty"`
+	Complete   bool   `json:"complete"`
+	StopReason string `json:"stopReason"`
+	Error      string `json:"error,omitempty"`
+}
+
+// PageFetcher fetches one page of channel messages with the given query parameters.
+type PageFetcher func(channelID string, query url.Values) ([]ChannelMessage, error)
+
+// GetChannelHistory walks a channel's history page by page from the Discord API until the
+// limit, a date bound, the end of the channel or the time budget is reached.
+func GetChannelHistory(opts HistoryOptions) (*ChannelHistory, error) {
+	return WalkChannelHistory(opts, fetchChannelMessagesPage)
+}
+
+// WalkChannelHistory walks a channel's history using fetch to retrieve each page.
+func WalkChannelHistory(opts HistoryOptions, fetch PageFetcher) (*ChannelHistory, error) {
+	if opts.ChannelID == "" {
+		return nil, fmt.Errorf("channel ID must be provided")
+	}
+	if opts.Direction == "" {
+		opts.Direction = DirectionBackward
+	}
+	if opts.Direction != DirectionBackward && opts.Direction != DirectionForward {
+		return nil, fmt.Errorf("unsupported direction %q, expected %q or %q", opts.Direction, DirectionBackward, DirectionForward)
+	}
+	if opts.ChunkSize <= 0 || opts.ChunkSize > MaxMessagesPerPage {
+		opts.ChunkSize = MaxMessagesPerPage
+	}
+	forward := opts.Direction == DirectionForward
+
+	cursor := opts.Before
+	if forward {
+		cursor = opts.After
+		if cursor == "" && !opts.Since.IsZero() {
+			// Cursors are exclusive, step back one millisecond to include messages sent at Since.
+			cursor = SnowflakeFromTime(opts.Since.Add(-time.Millisecond))
+		} else if cursor == "" {
+			cursor = "0"
+		}
+	} else if cursor == "" && !opts.Until.IsZero() {
+		cursor = SnowflakeFromTime(opts.Until.Add(time.Millisecond))
+	}
+
+	history := &ChannelHistory{ChannelID: opts.ChannelID, Direction: opts.Direction, Chunks: []HistoryChunk{}, Cursor: cursor}
+	var deadline time.Time
+	if opts.MaxDuration > 0 {
+		deadline = time.Now().Add(opts.MaxDuration)
+	}
+
+	for {
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			history.StopReason = StopTime
+			return history, nil
+		}
+
+		pageSize := opts.ChunkSize
+		if opts.Limit > 0 && opts.Limit-history.MessageCount < pageSize {
+			pageSize = opts.Limit - history.MessageCount
+		}
+		query := url.Values{}
+		query.Set("limit", strconv.Itoa(pageSize))
+		if forward {
+			query.Set("after", cursor)
+		} else if cursor != "" {
+			query.Set("before", cursor)
+		}
+
+		page, err := fetch(opts.ChannelID, query)
+		if err != nil {
+			return history, fmt.Errorf("error fetching history of channel %s after %d messages: %w", opts.ChannelID, history.MessageCount, err)
+		}
+		if len(page) == 0 {
+			history.StopReason = StopEnd
+			history.Complete = true
+			return history, nil
+		}
+
+		sortMessages(page, forward)
+		messages := page
+		reachedDate := false
+		for i, message := range page {
+			created := SnowflakeTime(message.ID)
+			if (forward && !opts.Until.IsZero() && created.After(opts.Until)) ||
+				(!forward && !opts.Since.IsZero() && created.Before(opts.Since)) {
+				messages = page[:i]
+				reachedDate = true
+				break
+			}
+		}
+
+		if len(messages) > 0 {
+			cursor = messages[len(messages)-1].ID
+			history.Cursor = cursor
+			history.MessageCount += len(messages)
+			history.Chunks = append(history.Chunks, HistoryChunk{
+				Messages: messages,
+				FirstID:  messages[0].ID,
+				LastID:   cursor,
+			})
+		}
+
+		switch {
+		case reachedDate:
+			history.StopReason = StopDate
+			history.Complete = true
+			return history, nil
+		case opts.Limit > 0 && history.MessageCount >= opts.Limit:
+			history.StopReason = StopLimit
+			return history, nil
+		case len(page) < pageSize:
+			history.StopReason = StopEnd
+			history.Complete = true
+			return history, nil
+		}
+	}
+}
+
+// NormalizeChunks replaces the messages of each chunk with their normalized posts.
+func (h *ChannelHistory) NormalizeChunks() {
+	for i := range h.Chunks {
+		h.Chunks[i].Posts = MessagesToPosts(h.Chunks[i].Messages)
+		h.Chunks[i].Messages = nil
+	}
+}
+
+// SnowflakeTime returns the creation time encoded in a Discord snowflake ID.
+func SnowflakeTime(id string) time.Time {
+	value, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.UnixMilli(int64(value>>22) + discordEpoch).UTC()
+}
+
+// SnowflakeFromTime returns the smallest snowflake ID created at t, which can be used as a
+// before or after cursor for a date. The zero time returns "0".
+func SnowflakeFromTime(t time.Time) string {
+	ms := t.UnixMilli() - discordEpoch
+	if t.IsZero() || ms < 0 {
+		return "0"
+	}
+	return strconv.FormatUint(uint64(ms)<<22, 10)
+}
+
+// sortMessages orders messages in the walk direction: oldest first when walking forward and
+// newest first when walking backward.
+func sortMessages(messages []ChannelMessage, forward bool) {
+	sort.SliceStable(messages, func(i, j int) bool {
+		a, _ := strconv.ParseUint(messages[i].ID, 10, 64)
+		b, _ := strconv.ParseUint(messages[j].ID, 10, 64)
+		if forward {
+			return a < b
+		}
+		return a > b
+	})
+}
+
+func fetchChannelMessagesPage(channelID string, query url.Values) ([]ChannelMessage, error) {
+	var messages []ChannelMessage
+	if err := DefaultClient().Get(context.Background(), fmt.Sprintf("/channels/%s/messages", channelID), query, &messages); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
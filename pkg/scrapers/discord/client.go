@@ -0,0 +1,426 @@
+package discord
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultMaxRetries is the number of times a request is retried after a rate limit or, for GET
+	// and HEAD requests, a server error or a network error.
+	DefaultMaxRetries = 3
+	// DefaultMaxRetryWait is the longest rate limit the client waits out. Longer limits are returned
+	// as a RateLimitError.
+	DefaultMaxRetryWait = 15 * time.Second
+	// DefaultRequestTimeout bounds a single attempt of a request.
+	DefaultRequestTimeout = 30 * time.Second
+)
+
+var (
+	// ErrMissingToken is returned when no bot token is configured.
+	ErrMissingToken = errors.New("DISCORD_BOT_TOKEN environment variable not set")
+	// ErrRateLimited matches every RateLimitError.
+	ErrRateLimited = errors.New("discord rate limit exceeded")
+	// ErrUnauthorized, ErrForbidden and ErrNotFound match an APIError with the same status code.
+	ErrUnauthorized = errors.New("discord request unauthorized")
+	ErrForbidden    = errors.New("discord request forbidden")
+	ErrNotFound     = errors.New("discord resource not found")
+)
+
+// APIError is a response from the Discord API with an unexpected status code.
+type APIError struct {
+	Method     string
+	Route      string
+	StatusCode int
+	Code       int    // Discord's JSON error code, if any
+	Message    string // Discord's error message, or the response body
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("discord API error on %s %s, status code: %d", e.Method, e.Route, e.StatusCode)
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	if e.Code != 0 {
+		msg += fmt.Sprintf(" (code %d)", e.Code)
+	}
+	return msg
+}
+
+// Is makes APIError match ErrUnauthorized, ErrForbidden and ErrNotFound.
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrForbidden:
+		return e.StatusCode == http.StatusForbidden
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	}
+	return false
+}
+
+// RateLimitError is returned when a route, or the bot as a whole, stays rate limited for longer
+// than the client is willing to wait, or after the retries are used up.
+type RateLimitError struct {
+	Route      string
+	RetryAfter time.Duration
+	Global     bool
+}
+
+func (e *RateLimitError) Error() string {
+	scope := "route " + e.Route
+	if e.Global {
+		scope = "global limit"
+	}
+	return fmt.Sprintf("discord rate limit exceeded (429 error) on %s, retry after %s", scope, e.RetryAfter)
+}
+
+// Is makes RateLimitError match ErrRateLimited.
+func (e *RateLimitError) Is(target error) bool {
+	return target == ErrRateLimited
+}
+
+// bucket is the rate limit state Discord reports for a route through the X-RateLimit headers.
+type bucket struct {
+	remaining int
+	resetAt   time.Time
+}
+
+// Client is a Discord REST client shared by the functions of this package. It tracks the rate
+// limit bucket of every route and the global limit, waits them out before sending requests and
+// retries rate limited requests, and failed and timed out GET and HEAD requests, with backoff.
+type Client struct {
+	BaseURL        string
+	Token          string // Bot token, read from DISCORD_BOT_TOKEN when empty
+	HTTPClient     *http.Client
+	MaxRetries     int
+	MaxRetryWait   time.Duration
+	RequestTimeout time.Duration
+
+	mu          sync.Mutex
+	buckets     map[string]*bucket // Keyed by bucket hash and major parameter
+	routes      map[string]string  // Route key to bucket key, once Discord has reported the hash
+	globalUntil time.Time
+}
+
+// NewClient returns a Client for the Discord API at baseURL using the given bot token.
+func NewClient(baseURL, token string) *Client {
+	return &Client{
+		BaseURL:        strings.TrimRight(baseURL, "/"),
+		Token:          token,
+		HTTPClient:     &http.Client{},
+		MaxRetries:     DefaultMaxRetries,
+		MaxRetryWait:   DefaultMaxRetryWait,
+		RequestTimeout: DefaultRequestTimeout,
+		buckets:        make(map[string]*bucket),
+		routes:         make(map[string]string),
+	}
+}
+
+var (
+	defaultClient   *Client
+	defaultClientMu sync.Mutex
+)
+
+// DefaultClient returns the client used by the functions of this package, which shares rate
+// limit state between all of them.
+func DefaultClient() *Client {
+	defaultClientMu.Lock()
+	defer defaultClientMu.Unlock()
+	if defaultClient == nil {
+		defaultClient = NewClient(apiEndpoint, "")
+	}
+	return defaultClient
+}
+
+// SetDefaultClient replaces the client used by the functions of this package.
+func SetDefaultClient(client *Client) {
+	defaultClientMu.Lock()
+	defer defaultClientMu.Unlock()
+	defaultClient = client
+}
+
+// request describes a call to the Discord API.
+type request struct {
+	method      string
+	path        string
+	query       url.Values
+	body        []byte
+	contentType string
+	noAuth      bool // OAuth token exchanges are not authorized with the bot token
+}
+
+// idempotent reports whether the request can be sent again after a server or network error,
+// which may have happened after Discord processed it.
+func (r request) idempotent() bool {
+	return r.method == http.MethodGet || r.method == http.MethodHead
+}
+
+// Get sends a GET request for path and decodes the JSON response into v.
+func (c *Client) Get(ctx context.Context, path string, query url.Values, v interface{}) error {
+	return c.do(ctx, request{method: http.MethodGet, path: path, query: query}, v)
+}
+
+func (c *Client) do(ctx context.Context, req request, v interface{}) error {
+	token := c.Token
+	if token == "" {
+		token = os.Getenv("DISCORD_BOT_TOKEN")
+	}
+	if token == "" && !req.noAuth {
+		return ErrMissingToken
+	}
+	route := routeKey(req.method, req.path)
+
+	retryBackoff := backoff.NewExponentialBackOff()
+	retryBackoff.InitialInterval = 500 * time.Millisecond
+	retryBackoff.MaxInterval = 5 * time.Second
+
+	var lastErr error
+	for attempt := 0; attempt <= c.MaxRetries; attempt++ {
+		if wait, global := c.waitTime(route); wait > 0 {
+			if wait > c.MaxRetryWait {
+				return &RateLimitError{Route: route, RetryAfter: wait, Global: global}
+			}
+			logrus.Debugf("[+] Waiting %s for the Discord rate limit on %s", wait, route)
+			if err := sleepContext(ctx, wait); err != nil {
+				return err
+			}
+		}
+
+		resp, body, err := c.send(ctx, req, token)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if !req.idempotent() {
+				return err
+			}
+			lastErr = err
+			logrus.Warnf("[-] Discord request %s failed: %v", route, err)
+			if err := sleepContext(ctx, retryBackoff.NextBackOff()); err != nil {
+				return err
+			}
+			continue
+		}
+		c.updateBucket(route, req.path, resp.Header)
+
+		switch {
+		case resp.StatusCode == http.StatusTooManyRequests:
+			limit := c.handleTooManyRequests(route, req.path, resp.Header, body)
+			lastErr = limit
+			if limit.RetryAfter > c.MaxRetryWait {
+				return limit
+			}
+			logrus.Warnf("[-] %v", limit)
+		case resp.StatusCode >= http.StatusInternalServerError:
+			lastErr = newAPIError(req.method, route, resp.StatusCode, body)
+			if !req.idempotent() {
+				return lastErr
+			}
+			logrus.Warnf("[-] %v", lastErr)
+			if err := sleepContext(ctx, retryBackoff.NextBackOff()); err != nil {
+				return err
+			}
+		case resp.StatusCode >= http.StatusBadRequest:
+			return newAPIError(req.method, route, resp.StatusCode, body)
+		default:
+			if v == nil || resp.StatusCode == http.StatusNoContent {
+				return nil
+			}
+			if err := json.Unmarshal(body, v); err != nil {
+				return fmt.Errorf("error decoding discord response for %s: %w", route, err)
+			}
+			return nil
+		}
+	}
+	return lastErr
+}
+
+// send performs a single attempt of a request and reads the response body.
+func (c *Client) send(ctx context.Context, req request, token string) (*http.Response, []byte, error) {
+	if c.RequestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.RequestTimeout)
+		defer cancel()
+	}
+	endpoint := c.BaseURL + req.path
+	if len(req.query) > 0 {
+		endpoint += "?" + req.query.Encode()
+	}
+	var body io.Reader
+	if req.body != nil {
+		body = bytes.NewReader(req.body)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, endpoint, body)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !req.noAuth {
+		httpReq.Header.Set("Authorization", fmt.Sprintf("Bot %s", token))
+	}
+	if req.contentType != "" {
+		httpReq.Header.Set("Content-Type", req.contentType)
+	}
+
+	resp, err := c.HTTPClient.Do(httpReq)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp, respBody, nil
+}
+
+// waitTime returns how long to wait before sending a request on route and whether the wait is
+// caused by the global limit.
+func (c *Client) waitTime(route string) (time.Duration, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	if now.Before(c.globalUntil) {
+		return c.globalUntil.Sub(now), true
+	}
+	b, ok := c.buckets[c.bucketKey(route)]
+	if !ok || b.remaining > 0 || !now.Before(b.resetAt) {
+		return 0, false
+	}
+	return b.resetAt.Sub(now), false
+}
+
+// updateBucket records the X-RateLimit headers of a response.
+func (c *Client) updateBucket(route, path string, header http.Header) {
+	remaining, err := strconv.Atoi(header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+	resetAfter, _ := strconv.ParseFloat(header.Get("X-RateLimit-Reset-After"), 64)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if hash := header.Get("X-RateLimit-Bucket"); hash != "" {
+		// Routes that share a bucket hash share their limit, per major parameter.
+		c.routes[route] = hash + ":" + majorParameter(path)
+	}
+	c.buckets[c.bucketKey(route)] = &bucket{
+		remaining: remaining,
+		resetAt:   time.Now().Add(time.Duration(resetAfter * float64(time.Second))),
+	}
+}
+
+// handleTooManyRequests records a 429 response and returns the rate limit it reports.
+func (c *Client) handleTooManyRequests(route, path string, header http.Header, body []byte) *RateLimitError {
+	var payload struct {
+		RetryAfter float64 `json:"retry_after"`
+		Global     bool    `json:"global"`
+	}
+	_ = json.Unmarshal(body, &payload)
+	retryAfter := payload.RetryAfter
+	if value, err := strconv.ParseFloat(header.Get("Retry-After"), 64); err == nil && value > retryAfter {
+		retryAfter = value
+	}
+	if retryAfter <= 0 {
+		retryAfter = 1
+	}
+	limit := &RateLimitError{
+		Route:      route,
+		RetryAfter: time.Duration(retryAfter * float64(time.Second)),
+		Global:     payload.Global || header.Get("X-RateLimit-Global") == "true" || header.Get("X-RateLimit-Scope") == "global",
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	resetAt := time.Now().Add(limit.RetryAfter)
+	if limit.Global {
+		c.globalUntil = resetAt
+	} else {
+		c.buckets[c.bucketKey(route)] = &bucket{remaining: 0, resetAt: resetAt}
+	}
+	return limit
+}
+
+// bucketKey returns the key of the bucket of route. It must be called with c.mu held.
+func (c *Client) bucketKey(route string) string {
+	if key, ok := c.routes[route]; ok {
+		return key
+	}
+	return route
+}
+
+func newAPIError(method, route string, status int, body []byte) *APIError {
+	apiErr := &APIError{Method: method, Route: route, StatusCode: status}
+	var payload struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(body, &payload); err == nil && payload.Message != "" {
+		apiErr.Code = payload.Code
+		apiErr.Message = payload.Message
+	} else {
+		apiErr.Message = strings.TrimSpace(string(body))
+	}
+	return apiErr
+}
+
+// routeKey identifies the rate limit route of a request: its method and path, with every ID
+// except the major parameter replaced by a placeholder.
+func routeKey(method, path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i, segment := range segments {
+		if !isSnowflake(segment) {
+			continue
+		}
+		if i > 0 && isMajorResource(segments[i-1]) {
+			continue
+		}
+		segments[i] = ":id"
+	}
+	return method + " /" + strings.Join(segments, "/")
+}
+
+// majorParameter returns the channel, guild or webhook ID of a path, which Discord keeps
+// separate limits for even when routes share a bucket.
+func majorParameter(path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(segments) > 1 && isMajorResource(segments[0]) {
+		return segments[1]
+	}
+	return ""
+}
+
+func isMajorResource(segment string) bool {
+	return segment == "channels" || segment == "guilds" || segment == "webhooks"
+}
+
+func isSnowflake(segment string) bool {
+	_, err := strconv.ParseUint(segment, 10, 64)
+	return err == nil
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
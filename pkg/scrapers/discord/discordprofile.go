@@ -1,11 +1,8 @@
 package discord
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"io"
-	"net/http"
-	"os"
 )
 
 // UserProfile holds the structure for a Discord user profile response
@@ -18,36 +15,8 @@ type UserProfile struct {
 
 // GetUserProfile fetches a user's profile from Discord API
 func GetUserProfile(userID string) (*UserProfile, error) {
-	botToken := os.Getenv("DISCORD_BOT_TOKEN") // Replace with your actual environment variable name
-	if botToken == "" {
-		return nil, fmt.Errorf("DISCORD_BOT_TOKEN environment variable not set")
-	}
-
-	url := fmt.Sprintf("https://discord.com/api/users/%s", userID)
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	req.Header.Set("Authorization", fmt.Sprintf("Bot %s", botToken))
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("error fetching user profile, status code: %d", resp.StatusCode)
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
 	var profile UserProfile
-	if err := json.Unmarshal(body, &profile); err != nil {
+	if err := DefaultClient().Get(context.Background(), fmt.Sprintf("/users/%s", userID), nil, &profile); err != nil {
 		return nil, err
 	}
 
@@ -2,11 +2,9 @@
 package discord
 
 import (
-	"encoding/json"
-	"fmt"
+	"context"
 	"net/http"
 	"net/url"
-	"strings"
 )
 
 const (
@@ -34,26 +32,15 @@ func ExchangeCode(code string) (*OAuthTokenResponse, error) {
 	data.Set("code", code)
 	data.Set("redirect_uri", redirectURI)
 
-	req, err := http.NewRequest("POST", fmt.Sprintf("%s/oauth2/token", apiEndpoint), strings.NewReader(data.Encode()))
-	if err != nil {
-		return nil, err
-	}
-
-	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("error exchanging code for token, status code: %d", resp.StatusCode)
-	}
-
 	var tokenResponse OAuthTokenResponse
-	if err := json.NewDecoder(resp.Body).Decode(&tokenResponse); err != nil {
+	err := DefaultClient().do(context.Background(), request{
+		method:      http.MethodPost,
+		path:        "/oauth2/token",
+		body:        []byte(data.Encode()),
+		contentType: "application/x-www-form-urlencoded",
+		noAuth:      true,
+	}, &tokenResponse)
+	if err != nil {
 		return nil, err
 	}
 
@@ -0,0 +1,384 @@
+package discord
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultGatewayURL is the Discord gateway a Gateway connects to when no URL is configured.
+const DefaultGatewayURL = "wss://gateway.discord.gg"
+
+// Gateway intents, see https://discord.com/developers/docs/topics/gateway#gateway-intents
+const (
+	IntentGuilds         = 1 << 0
+	IntentGuildMessages  = 1 << 9
+	IntentMessageContent = 1 << 15
+
+	// DefaultIntents receives the messages of the guilds the bot is a member of, with their content.
+	DefaultIntents = IntentGuilds | IntentGuildMessages | IntentMessageContent
+)
+
+// Gateway opcodes
+const (
+	opDispatch       = 0
+	opHeartbeat      = 1
+	opIdentify       = 2
+	opResume         = 6
+	opReconnect      = 7
+	opInvalidSession = 9
+	opHello          = 10
+	opHeartbeatACK   = 11
+)
+
+// closeResumable is the close code the client uses when it reconnects. Discord invalidates the
+// session when a client closes with 1000 or 1001, so those are only used when the gateway stops.
+const closeResumable = 4000
+
+// fatalCloseCodes are the close codes after which reconnecting cannot succeed.
+var fatalCloseCodes = map[int]string{
+	4004: "authentication failed",
+	4010: "invalid shard",
+	4011: "sharding required",
+	4012: "invalid API version",
+	4013: "invalid intents",
+	4014: "disallowed intents",
+}
+
+// ErrGatewayClosed is returned by Gateway.Run when Discord closes the connection with a code
+// that does not allow reconnecting, such as an invalid token.
+var ErrGatewayClosed = errors.New("discord gateway closed the connection")
+
+// GatewayConfig configures a Gateway connection.
+type GatewayConfig struct {
+	URL     string // Defaults to DefaultGatewayURL
+	Token   string // Bot token, read from DISCORD_BOT_TOKEN when empty
+	Intents int    // Defaults to DefaultIntents
+	// GuildIDs and ChannelIDs select the messages passed to the handler: a message is passed on if
+	// its guild or its channel is listed. If both are empty every message is passed on.
+	GuildIDs   []string
+	ChannelIDs []string
+}
+
+// MessageHandler is called for every message the gateway receives, in order.
+type MessageHandler func(message ChannelMessage)
+
+// Gateway receives messages in real time over a Discord gateway websocket connection. It
+// reconnects when the connection drops and resumes the session, so that no message is missed.
+type Gateway struct {
+	config   GatewayConfig
+	handler  MessageHandler
+	guilds   map[string]bool
+	channels map[string]bool
+
+	sessionID string
+	resumeURL string
+	sequence  int64
+}
+
+type gatewayPayload struct {
+	Op       int             `json:"op"`
+	Data     json.RawMessage `json:"d,omitempty"`
+	Sequence *int64          `json:"s,omitempty"`
+	Type     string          `json:"t,omitempty"`
+}
+
+// NewGateway returns a Gateway that passes the messages selected by config to handler.
+func NewGateway(config GatewayConfig, handler MessageHandler) *Gateway {
+	if config.URL == "" {
+		config.URL = DefaultGatewayURL
+	}
+	if config.Token == "" {
+		config.Token = os.Getenv("DISCORD_BOT_TOKEN")
+	}
+	if config.Intents == 0 {
+		config.Intents = DefaultIntents
+	}
+	g := &Gateway{config: config, handler: handler, guilds: make(map[string]bool), channels: make(map[string]bool)}
+	for _, id := range config.GuildIDs {
+		g.guilds[id] = true
+	}
+	for _, id := range config.ChannelIDs {
+		g.channels[id] = true
+	}
+	return g
+}
+
+// Run connects to the gateway and receives messages until ctx is cancelled or Discord closes
+// the connection for good. Dropped connections are resumed, with backoff between attempts.
+func (g *Gateway) Run(ctx context.Context) error {
+	if g.config.Token == "" {
+		return ErrMissingToken
+	}
+	reconnectBackoff := backoff.NewExponentialBackOff()
+	reconnectBackoff.InitialInterval = time.Second
+	reconnectBackoff.MaxInterval = time.Minute
+	reconnectBackoff.MaxElapsedTime = 0
+
+	for {
+		connected, err := g.runSession(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if errors.Is(err, ErrGatewayClosed) {
+			return err
+		}
+		if connected {
+			reconnectBackoff.Reset()
+		}
+		wait := reconnectBackoff.NextBackOff()
+		logrus.Warnf("[-] Discord gateway disconnected: %v, reconnecting in %s", err, wait)
+		if err := sleepContext(ctx, wait); err != nil {
+			return nil
+		}
+	}
+}
+
+// runSession runs a single gateway connection. It reports whether the session was established,
+// and why the connection ended.
+func (g *Gateway) runSession(ctx context.Context) (bool, error) {
+	resuming := g.sessionID != "" && g.resumeURL != ""
+	endpoint := g.config.URL
+	if resuming {
+		endpoint = g.resumeURL
+	}
+	endpoint, err := gatewayURL(endpoint)
+	if err != nil {
+		return false, err
+	}
+
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, endpoint, nil)
+	if err != nil {
+		return false, fmt.Errorf("error connecting to %s: %w", endpoint, err)
+	}
+	defer conn.Close()
+
+	var writeMu sync.Mutex
+	send := func(op int, data interface{}) error {
+		bytes, err := json.Marshal(data)
+		if err != nil {
+			return err
+		}
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		return conn.WriteJSON(gatewayPayload{Op: op, Data: bytes})
+	}
+
+	var hello struct {
+		HeartbeatInterval int64 `json:"heartbeat_interval"`
+	}
+	payload, err := readPayload(conn)
+	if err != nil {
+		return false, err
+	}
+	if payload.Op != opHello || json.Unmarshal(payload.Data, &hello) != nil || hello.HeartbeatInterval <= 0 {
+		return false, fmt.Errorf("expected hello from the gateway, got opcode %d", payload.Op)
+	}
+
+	if resuming {
+		err = send(opResume, map[string]interface{}{"token": g.config.Token, "session_id": g.sessionID, "seq": g.sequence})
+	} else {
+		err = send(opIdentify, map[string]interface{}{
+			"token":   g.config.Token,
+			"intents": g.config.Intents,
+			"properties": map[string]string{
+				"os":      "linux",
+				"browser": "masa-oracle",
+				"device":  "masa-oracle",
+			},
+		})
+	}
+	if err != nil {
+		return false, err
+	}
+
+	sessionCtx, cancel := context.WithCancel(ctx)
+	closed := make(chan struct{})
+	defer func() {
+		cancel()
+		<-closed
+	}()
+	var (
+		ackMu    sync.Mutex
+		acked    = true
+		sequence = g.sequence
+	)
+	go func() {
+		<-sessionCtx.Done()
+		// Unblocks the read loop when the context is cancelled, a heartbeat was not acknowledged or
+		// the gateway asked for a reconnect
+		code := closeResumable
+		if ctx.Err() != nil {
+			code = websocket.CloseNormalClosure
+		}
+		writeMu.Lock()
+		_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, ""), time.Now().Add(time.Second))
+		writeMu.Unlock()
+		conn.Close()
+		close(closed)
+	}()
+	go func() {
+		interval := time.Duration(hello.HeartbeatInterval) * time.Millisecond
+		timer := time.NewTimer(time.Duration(rand.Int63n(int64(interval))))
+		defer timer.Stop()
+		for {
+			select {
+			case <-sessionCtx.Done():
+				return
+			case <-timer.C:
+			}
+			ackMu.Lock()
+			if !acked {
+				ackMu.Unlock()
+				logrus.Warn("[-] Discord gateway did not acknowledge the last heartbeat")
+				cancel()
+				return
+			}
+			acked = false
+			seq := heartbeatSequence(sequence)
+			ackMu.Unlock()
+			if err := send(opHeartbeat, seq); err != nil {
+				cancel()
+				return
+			}
+			timer.Reset(interval)
+		}
+	}()
+
+	connected := false
+	for {
+		payload, err := readPayload(conn)
+		if err != nil {
+			var closeErr *websocket.CloseError
+			if errors.As(err, &closeErr) {
+				if reason, ok := fatalCloseCodes[closeErr.Code]; ok {
+					return connected, fmt.Errorf("%w: %s (%d)", ErrGatewayClosed, reason, closeErr.Code)
+				}
+				if closeErr.Code == 4007 || closeErr.Code == 4009 {
+					// Invalid sequence or session timed out, start a new session
+					g.sessionID = ""
+					g.sequence = 0
+				}
+			}
+			if sessionCtx.Err() != nil && ctx.Err() == nil {
+				return connected, fmt.Errorf("heartbeat not acknowledged")
+			}
+			return connected, err
+		}
+		if payload.Sequence != nil {
+			ackMu.Lock()
+			sequence = *payload.Sequence
+			ackMu.Unlock()
+			g.sequence = *payload.Sequence
+		}
+
+		switch payload.Op {
+		case opHeartbeat:
+			ackMu.Lock()
+			seq := heartbeatSequence(sequence)
+			ackMu.Unlock()
+			if err := send(opHeartbeat, seq); err != nil {
+				return connected, err
+			}
+		case opHeartbeatACK:
+			ackMu.Lock()
+			acked = true
+			ackMu.Unlock()
+		case opReconnect:
+			return connected, fmt.Errorf("gateway requested a reconnect")
+		case opInvalidSession:
+			var resumable bool
+			_ = json.Unmarshal(payload.Data, &resumable)
+			if !resumable {
+				g.sessionID = ""
+				g.sequence = 0
+			}
+			// Discord asks clients to wait between 1 and 5 seconds before identifying again
+			_ = sleepContext(ctx, time.Second+time.Duration(rand.Int63n(int64(4*time.Second))))
+			return connected, fmt.Errorf("gateway invalidated the session")
+		case opDispatch:
+			if g.dispatch(payload) {
+				connected = true
+			}
+		}
+	}
+}
+
+// dispatch handles a dispatched event. It reports whether the event established the session.
+func (g *Gateway) dispatch(payload *gatewayPayload) bool {
+	switch payload.Type {
+	case "READY":
+		var ready struct {
+			SessionID        string `json:"session_id"`
+			ResumeGatewayURL string `json:"resume_gateway_url"`
+		}
+		if err := json.Unmarshal(payload.Data, &ready); err != nil {
+			logrus.Errorf("[-] Unable to parse Discord gateway READY event: %v", err)
+			return false
+		}
+		g.sessionID = ready.SessionID
+		g.resumeURL = ready.ResumeGatewayURL
+		logrus.Infof("[+] Discord gateway session %s established", g.sessionID)
+		return true
+	case "RESUMED":
+		logrus.Infof("[+] Discord gateway session %s resumed", g.sessionID)
+		return true
+	case "MESSAGE_CREATE":
+		var message ChannelMessage
+		if err := json.Unmarshal(payload.Data, &message); err != nil {
+			logrus.Errorf("[-] Unable to parse Discord message: %v", err)
+			return false
+		}
+		if g.selected(message) && g.handler != nil {
+			g.handler(message)
+		}
+	}
+	return false
+}
+
+func (g *Gateway) selected(message ChannelMessage) bool {
+	if len(g.guilds) == 0 && len(g.channels) == 0 {
+		return true
+	}
+	return g.guilds[message.GuildID] || g.channels[message.ChannelID]
+}
+
+// heartbeatSequence returns the data of a heartbeat: the last sequence number received, or null
+// before the first one.
+func heartbeatSequence(sequence int64) interface{} {
+	if sequence == 0 {
+		return nil
+	}
+	return sequence
+}
+
+func readPayload(conn *websocket.Conn) (*gatewayPayload, error) {
+	var payload gatewayPayload
+	if err := conn.ReadJSON(&payload); err != nil {
+		return nil, err
+	}
+	return &payload, nil
+}
+
+// gatewayURL adds the API version and encoding the gateway must use to endpoint.
+func gatewayURL(endpoint string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid gateway URL %q: %w", endpoint, err)
+	}
+	q := u.Query()
+	q.Set("v", "10")
+	q.Set("encoding", "json")
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
@@ -2,11 +2,9 @@
 package discord
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"io"
-	"net/http"
-	"os"
+	"net/url"
 	"strconv"
 )
 
@@ -14,61 +12,43 @@ import (
 type ChannelMessage struct {
 	ID        string `json:"id"`
 	ChannelID string `json:"channel_id"`
+	GuildID   string `json:"guild_id,omitempty"`
 	Author    struct {
 		ID            string `json:"id"`
 		Username      string `json:"username"`
 		Discriminator string `json:"discriminator"`
 		Avatar        string `json:"avatar"`
 	} `json:"author"`
-	Content   string `json:"content"`
-	Timestamp string `json:"timestamp"`
+	Content   string     `json:"content"`
+	Timestamp string     `json:"timestamp"`
+	Pinned    bool       `json:"pinned,omitempty"`
+	Reactions []Reaction `json:"reactions,omitempty"`
+}
+
+// Reaction is the count of an emoji reaction on a message
+type Reaction struct {
+	Count int `json:"count"`
+	Emoji struct {
+		ID   string `json:"id,omitempty"` // Set for custom emojis
+		Name string `json:"name"`
+	} `json:"emoji"`
 }
 
 // GetChannelMessages fetches messages for a specific channel from the Discord API
 func GetChannelMessages(channelID string, limit string, before string) ([]ChannelMessage, error) {
-	botToken := os.Getenv("DISCORD_BOT_TOKEN") // Replace with your actual environment variable name
-	if botToken == "" {
-		return nil, fmt.Errorf("DISCORD_BOT_TOKEN environment variable not set")
-	}
-
-	url := fmt.Sprintf("https://discord.com/api/channels/%s/messages", channelID)
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, err
-	}
-
 	limitCheck, _ := strconv.Atoi(limit)
 
 	// Add query parameters if they are provided
-	q := req.URL.Query()
+	q := url.Values{}
 	if limitCheck > 0 && limitCheck <= 100 {
 		q.Add("limit", limit)
 	}
 	if before != "" {
 		q.Add("before", before)
 	}
-	req.URL.RawQuery = q.Encode()
-
-	req.Header.Set("Authorization", fmt.Sprintf("Bot %s", botToken))
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("error fetching channel messages, status code: %d", resp.StatusCode)
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
 
 	var messages []ChannelMessage
-	if err := json.Unmarshal(body, &messages); err != nil {
+	if err := DefaultClient().Get(context.Background(), fmt.Sprintf("/channels/%s/messages", channelID), q, &messages); err != nil {
 		return nil, err
 	}
 
@@ -0,0 +1,16 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+)
+
+// GetPinnedMessages fetches the pinned messages of a channel from the Discord API
+func GetPinnedMessages(channelID string) ([]ChannelMessage, error) {
+	var messages []ChannelMessage
+	if err := DefaultClient().Get(context.Background(), fmt.Sprintf("/channels/%s/pins", channelID), nil, &messages); err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
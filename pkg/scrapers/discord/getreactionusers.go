@@ -0,0 +1,29 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// GetReactionUsers fetches the users who reacted to a message with an emoji from the Discord API.
+// The emoji is either a unicode emoji or name:id for a custom emoji. Users are paged by user ID
+// with after.
+func GetReactionUsers(channelID string, messageID string, emoji string, after string, limit string) ([]UserProfile, error) {
+	q := url.Values{}
+	if limitCheck, _ := strconv.Atoi(limit); limitCheck > 0 && limitCheck <= 100 {
+		q.Add("limit", limit)
+	}
+	if after != "" {
+		q.Add("after", after)
+	}
+
+	var users []UserProfile
+	path := fmt.Sprintf("/channels/%s/messages/%s/reactions/%s", channelID, messageID, url.PathEscape(emoji))
+	if err := DefaultClient().Get(context.Background(), path, q, &users); err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
@@ -0,0 +1,87 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// Thread represents a Discord thread, including the posts of a forum channel
+type Thread struct {
+	ID             string   `json:"id"`
+	GuildID        string   `json:"guild_id"`
+	ParentID       string   `json:"parent_id"`
+	OwnerID        string   `json:"owner_id"`
+	Name           string   `json:"name"`
+	Type           int      `json:"type"`
+	LastMessageID  string   `json:"last_message_id,omitempty"`
+	MessageCount   int      `json:"message_count"`
+	MemberCount    int      `json:"member_count"`
+	AppliedTags    []string `json:"applied_tags,omitempty"` // Tags of a forum post
+	ThreadMetadata struct {
+		Archived         bool   `json:"archived"`
+		ArchiveTimestamp string `json:"archive_timestamp"`
+		Locked           bool   `json:"locked"`
+		CreateTimestamp  string `json:"create_timestamp,omitempty"`
+	} `json:"thread_metadata"`
+}
+
+// ThreadList is a page of threads
+type ThreadList struct {
+	Threads []Thread `json:"threads"`
+	HasMore bool     `json:"has_more"`
+}
+
+// GetActiveThreads fetches the active threads of a channel from the Discord API
+func GetActiveThreads(channelID string) ([]Thread, error) {
+	var channel GuildChannel
+	if err := DefaultClient().Get(context.Background(), fmt.Sprintf("/channels/%s", channelID), nil, &channel); err != nil {
+		return nil, err
+	}
+	if channel.GuildID == "" {
+		return nil, fmt.Errorf("channel %s is not a guild channel", channelID)
+	}
+
+	// Discord lists the active threads of the whole guild
+	var list ThreadList
+	if err := DefaultClient().Get(context.Background(), fmt.Sprintf("/guilds/%s/threads/active", channel.GuildID), nil, &list); err != nil {
+		return nil, err
+	}
+	threads := []Thread{}
+	for _, thread := range list.Threads {
+		if thread.ParentID == channelID {
+			threads = append(threads, thread)
+		}
+	}
+	return threads, nil
+}
+
+// GetArchivedThreads fetches a page of the archived threads of a channel from the Discord API,
+// starting before the given ISO 8601 timestamp. Private threads require the Manage Threads permission.
+func GetArchivedThreads(channelID string, private bool, before string, limit string) (*ThreadList, error) {
+	visibility := "public"
+	if private {
+		visibility = "private"
+	}
+
+	q := url.Values{}
+	if limitCheck, _ := strconv.Atoi(limit); limitCheck > 0 && limitCheck <= 100 {
+		q.Add("limit", limit)
+	}
+	if before != "" {
+		q.Add("before", before)
+	}
+
+	var list ThreadList
+	if err := DefaultClient().Get(context.Background(), fmt.Sprintf("/channels/%s/threads/archived/%s", channelID, visibility), q, &list); err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+// GetThreadMessages fetches messages of a thread or forum post from the Discord API
+func GetThreadMessages(threadID string, limit string, before string) ([]ChannelMessage, error) {
+	// Threads are channels, their messages are fetched the same way
+	return GetChannelMessages(threadID, limit, before)
+}
@@ -0,0 +1,74 @@
+package discord
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Gzgod/masa-oracle/pkg/scrapers/normalized"
+)
+
+// MessageToPost converts a Discord channel message to the normalized post schema.
+func MessageToPost(message ChannelMessage) normalized.Post {
+	post := normalized.Post{
+		ID:        message.ID,
+		Source:    normalized.SourceDiscord,
+		Text:      message.Content,
+		ChannelID: message.ChannelID,
+		Author: &normalized.Author{
+			ID:          message.Author.ID,
+			Source:      normalized.SourceDiscord,
+			Username:    message.Author.Username,
+			DisplayName: message.Author.Username,
+			AvatarURL:   avatarURL(message.Author.ID, message.Author.Avatar),
+		},
+		Extensions: normalized.Extension(normalized.SourceDiscord, message),
+	}
+	if createdAt, err := time.Parse(time.RFC3339, message.Timestamp); err == nil {
+		post.CreatedAt = &createdAt
+	}
+	if len(message.Reactions) > 0 {
+		reactions := 0
+		for _, reaction := range message.Reactions {
+			reactions += reaction.Count
+		}
+		post.Metrics = map[string]int{"reactions": reactions}
+	}
+	return post
+}
+
+// MessagesToPosts converts Discord channel messages to normalized posts.
+func MessagesToPosts(messages []ChannelMessage) []normalized.Post {
+	posts := make([]normalized.Post, 0, len(messages))
+	for _, message := range messages {
+		posts = append(posts, MessageToPost(message))
+	}
+	return posts
+}
+
+// UserProfilesToAuthors converts Discord user profiles to normalized authors.
+func UserProfilesToAuthors(profiles []UserProfile) []normalized.Author {
+	authors := make([]normalized.Author, 0, len(profiles))
+	for i := range profiles {
+		authors = append(authors, UserProfileToAuthor(&profiles[i]))
+	}
+	return authors
+}
+
+// UserProfileToAuthor converts a Discord user profile to the normalized author schema.
+func UserProfileToAuthor(profile *UserProfile) normalized.Author {
+	return normalized.Author{
+		ID:          profile.ID,
+		Source:      normalized.SourceDiscord,
+		Username:    profile.Username,
+		DisplayName: profile.Username,
+		AvatarURL:   avatarURL(profile.ID, profile.Avatar),
+		Extensions:  normalized.Extension(normalized.SourceDiscord, profile),
+	}
+}
+
+func avatarURL(userID, avatar string) string {
+	if userID == "" || avatar == "" {
+		return ""
+	}
+	return fmt.Sprintf("https://cdn.discordapp.com/avatars/%s/%s.png", userID, avatar)
+}
@@ -0,0 +1,104 @@
+// Package normalized defines a source independent representation of scraped content, so that
+// consumers can handle tweets, Discord and Telegram messages, Reddit posts and web pages the same way.
+package normalized
+
+import (
+	"fmt"
+	"time"
+)
+
+// Source identifies the platform a Post or Author was scraped from.
+type Source string
+
+const (
+	SourceTwitter  Source = "twitter"
+	SourceDiscord  Source = "discord"
+	SourceTelegram Source = "telegram"
+	SourceWeb      Source = "web"
+	SourceReddit   Source = "reddit"
+)
+
+// MediaType describes the kind of a Media attachment.
+type MediaType string
+
+const (
+	MediaImage    MediaType = "image"
+	MediaVideo    MediaType = "video"
+	MediaGIF      MediaType = "gif"
+	MediaAudio    MediaType = "audio"
+	MediaDocument MediaType = "document"
+)
+
+// Result formats accepted by the data endpoints through the "format" option.
+const (
+	FormatRaw        = "raw"
+	FormatNormalized = "normalized"
+)
+
+// Post is a single piece of content: a tweet, a chat message or a section of a web page.
+type Post struct {
+	ID         string         `json:"id"`
+	Source     Source         `json:"source"`
+	URL        string         `json:"url,omitempty"`
+	Title      string         `json:"title,omitempty"`
+	Text       string         `json:"text"`
+	Author     *Author        `json:"author,omitempty"`
+	CreatedAt  *time.Time     `json:"createdAt,omitempty"`
+	ChannelID  string         `json:"channelId,omitempty"` // Conversation, channel or page the post belongs to
+	ReplyToID  string         `json:"replyToId,omitempty"`
+	Media      []Media        `json:"media,omitempty"`
+	Links      []string       `json:"links,omitempty"`
+	Tags       []string       `json:"tags,omitempty"`
+	Metrics    map[string]int `json:"metrics,omitempty"` // Likes, replies, views and similar counters
+	Extensions map[string]any `json:"extensions,omitempty"`
+}
+
+// Author is the account that published a Post.
+type Author struct {
+	ID          string         `json:"id"`
+	Source      Source         `json:"source"`
+	Username    string         `json:"username,omitempty"`
+	DisplayName string         `json:"displayName,omitempty"`
+	Description string         `json:"description,omitempty"`
+	AvatarURL   string         `json:"avatarUrl,omitempty"`
+	URL         string         `json:"url,omitempty"`
+	Verified    bool           `json:"verified,omitempty"`
+	Metrics     map[string]int `json:"metrics,omitempty"` // Followers, following and similar counters
+	Extensions  map[string]any `json:"extensions,omitempty"`
+}
+
+// Media is a file attached to a Post.
+type Media struct {
+	Type       MediaType `json:"type"`
+	URL        string    `json:"url,omitempty"`
+	PreviewURL string    `json:"previewUrl,omitempty"`
+	MimeType   string    `json:"mimeType,omitempty"`
+	Size       int64     `json:"size,omitempty"`
+	Name       string    `json:"name,omitempty"`
+	CID        string    `json:"cid,omitempty"` // Set when a copy is kept in the node's blob store
+}
+
+// Extension returns the map stored under the source's key, which holds the fields of the
+// native object that have no place in the normalized schema.
+func Extension(source Source, raw any) map[string]any {
+	return map[string]any{string(source): raw}
+}
+
+// ValidateFormat returns an error if format is not one of the supported result formats.
+// An empty format selects the raw format.
+func ValidateFormat(format string) error {
+	switch format {
+	case "", FormatRaw, FormatNormalized:
+		return nil
+	default:
+		return fmt.Errorf("unsupported format %q, expected %q or %q", format, FormatRaw, FormatNormalized)
+	}
+}
+
+// TimePtr returns a pointer to t, or nil if t is the zero time.
+func TimePtr(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
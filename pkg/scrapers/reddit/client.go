@@ -0,0 +1,389 @@
+package reddit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	apiEndpoint   = "https://oauth.reddit.com"
+	tokenEndpoint = "https://www.reddit.com/api/v1/access_token"
+
+	// DefaultUserAgent identifies the node to Reddit when REDDIT_USER_AGENT is not set. Reddit
+	// throttles requests with generic user agents.
+	DefaultUserAgent = "go:masa-oracle:v1 (by /u/masa-oracle)"
+	// DefaultMaxRetries is the number of times a request is retried after a server or network error.
+	DefaultMaxRetries = 3
+	// DefaultMaxRetryWait is the longest rate limit the client waits out. Longer limits are returned
+	// as a RateLimitError.
+	DefaultMaxRetryWait = 15 * time.Second
+	// DefaultRequestTimeout bounds a single attempt of a request.
+	DefaultRequestTimeout = 30 * time.Second
+)
+
+var (
+	// ErrMissingCredentials is returned when the Reddit app credentials are not configured.
+	ErrMissingCredentials = errors.New("REDDIT_CLIENT_ID and REDDIT_CLIENT_SECRET must be set")
+	// ErrRateLimited matches every RateLimitError.
+	ErrRateLimited = errors.New("reddit rate limit exceeded")
+	// ErrForbidden and ErrNotFound match an APIError with the same status code, such as for a
+	// private or banned subreddit.
+	ErrForbidden = errors.New("reddit resource forbidden")
+	ErrNotFound  = errors.New("reddit resource not found")
+)
+
+// APIError is a response from the Reddit API with an unexpected status code.
+type APIError struct {
+	Method     string
+	Path       string
+	StatusCode int
+	Message    string // Reddit's error message, or the response body
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("reddit API error on %s %s, status code: %d", e.Method, e.Path, e.StatusCode)
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	return msg
+}
+
+// Is makes APIError match ErrForbidden and ErrNotFound.
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrForbidden:
+		return e.StatusCode == http.StatusForbidden
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	}
+	return false
+}
+
+// RateLimitError is returned when the app stays rate limited for longer than the client is
+// willing to wait.
+type RateLimitError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("reddit rate limit exceeded (429 error), retry after %s", e.RetryAfter)
+}
+
+// Is makes RateLimitError match ErrRateLimited.
+func (e *RateLimitError) Is(target error) bool {
+	return target == ErrRateLimited
+}
+
+// Client is a Reddit API client shared by the functions of this package. It signs in with the
+// OAuth credentials of a Reddit app, keeps track of the rate limit Reddit reports in the
+// X-Ratelimit headers and retries failed requests with backoff.
+//
+// Without a username the client uses the app-only grant, which can read everything public. With
+// the username and password of the account that owns a "script" app, it signs in as that account.
+type Client struct {
+	BaseURL        string
+	TokenURL       string
+	ClientID       string // Read from REDDIT_CLIENT_ID when empty
+	ClientSecret   string // Read from REDDIT_CLIENT_SECRET when empty
+	Username       string // Read from REDDIT_USERNAME when empty
+	Password       string // Read from REDDIT_PASSWORD when empty
+	UserAgent      string // Read from REDDIT_USER_AGENT when empty
+	HTTPClient     *http.Client
+	MaxRetries     int
+	MaxRetryWait   time.Duration
+	RequestTimeout time.Duration
+
+	mu          sync.Mutex
+	token       string
+	tokenExpiry time.Time
+	remaining   float64
+	resetAt     time.Time
+}
+
+// NewClient returns a Client for the Reddit API at baseURL that gets its access tokens from tokenURL.
+func NewClient(baseURL, tokenURL string) *Client {
+	return &Client{
+		BaseURL:        strings.TrimRight(baseURL, "/"),
+		TokenURL:       tokenURL,
+		HTTPClient:     &http.Client{},
+		MaxRetries:     DefaultMaxRetries,
+		MaxRetryWait:   DefaultMaxRetryWait,
+		RequestTimeout: DefaultRequestTimeout,
+		remaining:      -1,
+	}
+}
+
+var (
+	defaultClient   *Client
+	defaultClientMu sync.Mutex
+)
+
+// DefaultClient returns the client used by the functions of this package.
+func DefaultClient() *Client {
+	defaultClientMu.Lock()
+	defer defaultClientMu.Unlock()
+	if defaultClient == nil {
+		defaultClient = NewClient(apiEndpoint, tokenEndpoint)
+	}
+	return defaultClient
+}
+
+// SetDefaultClient replaces the client used by the functions of this package.
+func SetDefaultClient(client *Client) {
+	defaultClientMu.Lock()
+	defer defaultClientMu.Unlock()
+	defaultClient = client
+}
+
+// Get sends a GET request for path and decodes the JSON response into v. Reddit's HTML escaping
+// of text is turned off.
+func (c *Client) Get(ctx context.Context, path string, query url.Values, v interface{}) error {
+	if query == nil {
+		query = url.Values{}
+	}
+	query.Set("raw_json", "1")
+
+	retryBackoff := backoff.NewExponentialBackOff()
+	retryBackoff.InitialInterval = 500 * time.Millisecond
+	retryBackoff.MaxInterval = 5 * time.Second
+
+	var lastErr error
+	refreshed := false
+	for attempt := 0; attempt <= c.MaxRetries; attempt++ {
+		if wait := c.waitTime(); wait > 0 {
+			if wait > c.MaxRetryWait {
+				return &RateLimitError{RetryAfter: wait}
+			}
+			logrus.Debugf("[+] Waiting %s for the Reddit rate limit", wait)
+			if err := sleepContext(ctx, wait); err != nil {
+				return err
+			}
+		}
+		token, err := c.accessToken(ctx)
+		if err != nil {
+			return err
+		}
+
+		resp, body, err := c.send(ctx, path, query, token)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			lastErr = err
+			logrus.Warnf("[-] Reddit request %s failed: %v", path, err)
+			if err := sleepContext(ctx, retryBackoff.NextBackOff()); err != nil {
+				return err
+			}
+			continue
+		}
+		c.updateRateLimit(resp.Header)
+
+		switch {
+		case resp.StatusCode == http.StatusUnauthorized && !refreshed:
+			// The token expired early or was revoked, get a new one once.
+			refreshed = true
+			c.resetToken()
+			attempt--
+		case resp.StatusCode == http.StatusTooManyRequests:
+			limit := &RateLimitError{RetryAfter: c.waitTime()}
+			if limit.RetryAfter <= 0 {
+				limit.RetryAfter = time.Second
+			}
+			lastErr = limit
+			if limit.RetryAfter > c.MaxRetryWait {
+				return limit
+			}
+			logrus.Warnf("[-] %v", limit)
+			if err := sleepContext(ctx, limit.RetryAfter); err != nil {
+				return err
+			}
+		case resp.StatusCode >= http.StatusInternalServerError:
+			lastErr = newAPIError(http.MethodGet, path, resp.StatusCode, body)
+			logrus.Warnf("[-] %v", lastErr)
+			if err := sleepContext(ctx, retryBackoff.NextBackOff()); err != nil {
+				return err
+			}
+		case resp.StatusCode >= http.StatusBadRequest:
+			return newAPIError(http.MethodGet, path, resp.StatusCode, body)
+		default:
+			if err := json.Unmarshal(body, v); err != nil {
+				return fmt.Errorf("error decoding reddit response for %s: %w", path, err)
+			}
+			return nil
+		}
+	}
+	return lastErr
+}
+
+// send performs a single attempt of a request and reads the response body.
+func (c *Client) send(ctx context.Context, path string, query url.Values, token string) (*http.Response, []byte, error) {
+	if c.RequestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.RequestTimeout)
+		defer cancel()
+	}
+	endpoint := c.BaseURL + path + "?" + query.Encode()
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+token)
+	httpReq.Header.Set("User-Agent", c.userAgent())
+
+	resp, err := c.HTTPClient.Do(httpReq)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp, respBody, nil
+}
+
+// accessToken returns the current OAuth access token, requesting a new one if it has expired.
+func (c *Client) accessToken(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token != "" && time.Now().Before(c.tokenExpiry) {
+		return c.token, nil
+	}
+
+	clientID, clientSecret := envDefault(c.ClientID, "REDDIT_CLIENT_ID"), envDefault(c.ClientSecret, "REDDIT_CLIENT_SECRET")
+	if clientID == "" || clientSecret == "" {
+		return "", ErrMissingCredentials
+	}
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if username := envDefault(c.Username, "REDDIT_USERNAME"); username != "" {
+		form = url.Values{
+			"grant_type": {"password"},
+			"username":   {username},
+			"password":   {envDefault(c.Password, "REDDIT_PASSWORD")},
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.SetBasicAuth(clientID, clientSecret)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("User-Agent", c.userAgent())
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("error requesting reddit access token: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", newAPIError(http.MethodPost, "/api/v1/access_token", resp.StatusCode, body)
+	}
+
+	var payload struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+		Error       string `json:"error"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return "", fmt.Errorf("error decoding reddit access token: %w", err)
+	}
+	if payload.AccessToken == "" {
+		// Reddit answers bad credentials with a 200 and an error field.
+		return "", fmt.Errorf("reddit access token request failed: %s", payload.Error)
+	}
+	c.token = payload.AccessToken
+	// Renew the token a minute before Reddit expires it.
+	c.tokenExpiry = time.Now().Add(time.Duration(payload.ExpiresIn)*time.Second - time.Minute)
+	return c.token, nil
+}
+
+func (c *Client) resetToken() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.token = ""
+}
+
+func (c *Client) userAgent() string {
+	if agent := envDefault(c.UserAgent, "REDDIT_USER_AGENT"); agent != "" {
+		return agent
+	}
+	return DefaultUserAgent
+}
+
+// waitTime returns how long to wait before the next request because the rate limit is used up.
+func (c *Client) waitTime() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.remaining < 1 && c.remaining >= 0 && time.Now().Before(c.resetAt) {
+		return time.Until(c.resetAt)
+	}
+	return 0
+}
+
+// updateRateLimit records the X-Ratelimit headers of a response: the requests left in the
+// current window and the seconds until it resets.
+func (c *Client) updateRateLimit(header http.Header) {
+	remaining, err := strconv.ParseFloat(header.Get("X-Ratelimit-Remaining"), 64)
+	if err != nil {
+		return
+	}
+	reset, _ := strconv.ParseFloat(header.Get("X-Ratelimit-Reset"), 64)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.remaining = math.Floor(remaining)
+	c.resetAt = time.Now().Add(time.Duration(reset * float64(time.Second)))
+}
+
+func newAPIError(method, path string, status int, body []byte) *APIError {
+	apiErr := &APIError{Method: method, Path: path, StatusCode: status}
+	var payload struct {
+		Message string `json:"message"`
+		Reason  string `json:"reason"`
+	}
+	if err := json.Unmarshal(body, &payload); err == nil && payload.Message != "" {
+		apiErr.Message = payload.Message
+		if payload.Reason != "" {
+			apiErr.Message += " (" + payload.Reason + ")"
+		}
+	} else {
+		apiErr.Message = strings.TrimSpace(string(body))
+	}
+	return apiErr
+}
+
+func envDefault(value, key string) string {
+	if value != "" {
+		return value
+	}
+	return os.Getenv(key)
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
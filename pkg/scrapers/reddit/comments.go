@@ -0,0 +1,88 @@
+package reddit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// CommentOptions selects the comments of a post.
+type CommentOptions struct {
+	// Sort is confidence, top, new, controversial, old or qa. Reddit's default, confidence, if empty.
+	Sort string
+	// Depth is the number of reply levels to fetch, all levels Reddit returns if 0.
+	Depth int
+	// Limit is the number of comments to fetch, Reddit's default if 0. Comments that are left out
+	// are listed in the More fields of the tree.
+	Limit int
+}
+
+// GetComments fetches a post and its comment tree. postID is the ID of the post, with or without
+// the "t3_" prefix.
+func GetComments(ctx context.Context, postID string, opts CommentOptions) (*CommentTree, error) {
+	postID = strings.TrimPrefix(postID, "t3_")
+	if !namePattern.MatchString(postID) {
+		return nil, fmt.Errorf("%w: post %q", ErrInvalidName, postID)
+	}
+	sort, err := validSort(opts.Sort, "", "confidence", SortTop, SortNew, "controversial", "old", "qa")
+	if err != nil {
+		return nil, err
+	}
+	query := url.Values{}
+	if sort != "" {
+		query.Set("sort", sort)
+	}
+	if opts.Depth > 0 {
+		query.Set("depth", strconv.Itoa(opts.Depth))
+	}
+	if opts.Limit > 0 {
+		query.Set("limit", strconv.Itoa(opts.Limit))
+	}
+
+	path := "/comments/" + postID
+	var listings []json.RawMessage
+	if err := DefaultClient().Get(ctx, path, query, &listings); err != nil {
+		return nil, err
+	}
+	tree, err := parseCommentTree(listings)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding reddit comments %s: %w", path, err)
+	}
+	return tree, nil
+}
+
+// parseCommentTree decodes the response of the comments endpoint: a listing with the post,
+// followed by a listing with the comments.
+func parseCommentTree(listings []json.RawMessage) (*CommentTree, error) {
+	if len(listings) != 2 {
+		return nil, fmt.Errorf("expected 2 listings, got %d", len(listings))
+	}
+	posts, err := parseListing(listings[0])
+	if err != nil {
+		return nil, err
+	}
+	if len(posts.Posts) == 0 {
+		return nil, errors.New("the post is missing")
+	}
+	comments, more, err := parseComments(listings[1])
+	if err != nil {
+		return nil, err
+	}
+	if comments == nil {
+		comments = []*Comment{}
+	}
+	return &CommentTree{Post: posts.Posts[0], Comments: comments, More: more}, nil
+}
+
+// CountComments returns the number of comments in a tree, replies included.
+func CountComments(comments []*Comment) int {
+	count := len(comments)
+	for _, comment := range comments {
+		count += CountComments(comment.Replies)
+	}
+	return count
+}
@@ -0,0 +1,190 @@
+package reddit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+)
+
+const (
+	// DefaultLimit is the number of posts or comments fetched when no limit is given.
+	DefaultLimit = 25
+	// MaxPageSize is the most items Reddit returns for one listing request.
+	MaxPageSize = 100
+	// MaxLimit bounds the posts fetched by one request. Reddit does not page past 1000 items.
+	MaxLimit = 1000
+)
+
+// Sorts of subreddit listings and user submissions.
+const (
+	SortHot    = "hot"
+	SortNew    = "new"
+	SortTop    = "top"
+	SortRising = "rising"
+)
+
+// Sorts of search results, besides SortHot, SortNew and SortTop.
+const (
+	SortRelevance = "relevance"
+	SortComments  = "comments"
+)
+
+var (
+	// ErrInvalidName is returned for subreddit names, usernames and post IDs Reddit would not accept.
+	ErrInvalidName = errors.New("invalid reddit name")
+
+	namePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,32}$`)
+	timeWindows = map[string]bool{"": true, "hour": true, "day": true, "week": true, "month": true, "year": true, "all": true}
+)
+
+// ListingOptions selects a page of a subreddit or of the submissions of a user.
+type ListingOptions struct {
+	// Sort is hot, new, top or rising for subreddits and hot, new or top for users. Hot if empty.
+	Sort string
+	// Time is the window of the top sort: hour, day, week, month, year or all.
+	Time string
+	// After is the pagination token of the previous page, empty for the first page.
+	After string
+	// Limit is the number of posts to fetch, DefaultLimit if 0. Limits above MaxPageSize are
+	// fetched in several pages.
+	Limit int
+}
+
+// SearchOptions selects the posts matching a search query.
+type SearchOptions struct {
+	Query string
+	// Subreddit restricts the search to one subreddit if set.
+	Subreddit string
+	// Sort is relevance, hot, top, new or comments. Relevance if empty.
+	Sort  string
+	Time  string
+	After string
+	Limit int
+}
+
+// GetSubredditPosts fetches the posts of a subreddit.
+func GetSubredditPosts(ctx context.Context, subreddit string, opts ListingOptions) (*Listing, error) {
+	if !namePattern.MatchString(subreddit) {
+		return nil, fmt.Errorf("%w: subreddit %q", ErrInvalidName, subreddit)
+	}
+	sort, err := validSort(opts.Sort, SortHot, SortHot, SortNew, SortTop, SortRising)
+	if err != nil {
+		return nil, err
+	}
+	query, err := listingQuery(opts.Time)
+	if err != nil {
+		return nil, err
+	}
+	return fetchListing(ctx, fmt.Sprintf("/r/%s/%s", subreddit, sort), query, opts.After, opts.Limit)
+}
+
+// GetUserPosts fetches the submissions of a user.
+func GetUserPosts(ctx context.Context, username string, opts ListingOptions) (*Listing, error) {
+	if !namePattern.MatchString(username) {
+		return nil, fmt.Errorf("%w: user %q", ErrInvalidName, username)
+	}
+	sort, err := validSort(opts.Sort, SortHot, SortHot, SortNew, SortTop)
+	if err != nil {
+		return nil, err
+	}
+	query, err := listingQuery(opts.Time)
+	if err != nil {
+		return nil, err
+	}
+	query.Set("sort", sort)
+	return fetchListing(ctx, fmt.Sprintf("/user/%s/submitted", username), query, opts.After, opts.Limit)
+}
+
+// Search fetches the posts matching a query, in all of Reddit or in one subreddit.
+func Search(ctx context.Context, opts SearchOptions) (*Listing, error) {
+	if opts.Query == "" {
+		return nil, errors.New("a search query is required")
+	}
+	sort, err := validSort(opts.Sort, SortRelevance, SortRelevance, SortHot, SortTop, SortNew, SortComments)
+	if err != nil {
+		return nil, err
+	}
+	query, err := listingQuery(opts.Time)
+	if err != nil {
+		return nil, err
+	}
+	query.Set("q", opts.Query)
+	query.Set("sort", sort)
+	query.Set("type", "link")
+
+	path := "/search"
+	if opts.Subreddit != "" {
+		if !namePattern.MatchString(opts.Subreddit) {
+			return nil, fmt.Errorf("%w: subreddit %q", ErrInvalidName, opts.Subreddit)
+		}
+		path = fmt.Sprintf("/r/%s/search", opts.Subreddit)
+		query.Set("restrict_sr", "true")
+	}
+	return fetchListing(ctx, path, query, opts.After, opts.Limit)
+}
+
+// fetchListing pages through a listing from after until limit posts are fetched or the listing
+// ends. The returned After continues below the last post.
+func fetchListing(ctx context.Context, path string, query url.Values, after string, limit int) (*Listing, error) {
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	limit = min(limit, MaxLimit)
+
+	result := &Listing{Posts: []Post{}}
+	for len(result.Posts) < limit {
+		query.Set("limit", strconv.Itoa(min(MaxPageSize, limit-len(result.Posts))))
+		if after != "" {
+			query.Set("after", after)
+			query.Set("count", strconv.Itoa(len(result.Posts)))
+		}
+		var raw json.RawMessage
+		if err := DefaultClient().Get(ctx, path, query, &raw); err != nil {
+			if len(result.Posts) > 0 {
+				// Return the pages fetched so far, After resumes from there.
+				result.Error = err.Error()
+				return result, err
+			}
+			return nil, err
+		}
+		page, err := parseListing(raw)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding reddit listing %s: %w", path, err)
+		}
+		result.Posts = append(result.Posts, page.Posts...)
+		result.After = page.After
+		if page.After == "" || len(page.Posts) == 0 {
+			break
+		}
+		after = page.After
+	}
+	return result, nil
+}
+
+func listingQuery(window string) (url.Values, error) {
+	if !timeWindows[window] {
+		return nil, fmt.Errorf("invalid time window %q, expected hour, day, week, month, year or all", window)
+	}
+	query := url.Values{}
+	if window != "" {
+		query.Set("t", window)
+	}
+	return query, nil
+}
+
+// validSort returns sort, or def if it is empty, if it is one of the allowed sorts.
+func validSort(sort, def string, allowed ...string) (string, error) {
+	if sort == "" {
+		return def, nil
+	}
+	for _, s := range allowed {
+		if s == sort {
+			return sort, nil
+		}
+	}
+	return "", fmt.Errorf("invalid sort %q, expected one of %v", sort, allowed)
+}
@@ -0,0 +1,122 @@
+package reddit
+
+import (
+	"github.com/Gzgod/masa-oracle/pkg/scrapers/normalized"
+)
+
+const siteURL = "https://www.reddit.com"
+
+// PostToNormalized converts a Reddit post to the normalized post schema.
+func PostToNormalized(p Post) normalized.Post {
+	post := normalized.Post{
+		ID:         p.ID,
+		Source:     normalized.SourceReddit,
+		URL:        siteURL + p.Permalink,
+		Title:      p.Title,
+		Text:       p.SelfText,
+		Author:     author(p.Author, p.AuthorID),
+		CreatedAt:  normalized.TimePtr(p.Created()),
+		ChannelID:  p.Subreddit,
+		Metrics:    map[string]int{"score": p.Score, "comments": p.NumComments},
+		Extensions: normalized.Extension(normalized.SourceReddit, p),
+	}
+	if !p.IsSelf && p.URL != "" {
+		post.Links = []string{p.URL}
+		switch {
+		case p.PostHint == "image":
+			post.Media = []normalized.Media{{Type: normalized.MediaImage, URL: p.URL, PreviewURL: thumbnailURL(p.Thumbnail)}}
+		case p.IsVideo || p.PostHint == "hosted:video":
+			post.Media = []normalized.Media{{Type: normalized.MediaVideo, URL: p.URL, PreviewURL: thumbnailURL(p.Thumbnail)}}
+		}
+	}
+	if p.Flair != "" {
+		post.Tags = []string{p.Flair}
+	}
+	return post
+}
+
+// ListingToPosts converts the posts of a listing to normalized posts.
+func ListingToPosts(listing *Listing) []normalized.Post {
+	posts := make([]normalized.Post, 0, len(listing.Posts))
+	for _, p := range listing.Posts {
+		posts = append(posts, PostToNormalized(p))
+	}
+	return posts
+}
+
+// NormalizedListing is a page of posts in the normalized schema, with the pagination token of
+// the next page and the error that cut the listing short, if any.
+type NormalizedListing struct {
+	Posts []normalized.Post `json:"posts"`
+	After string            `json:"after,omitempty"`
+	Error string            `json:"error,omitempty"`
+}
+
+// NormalizeListing converts the posts of a listing to normalized posts and keeps its token and error.
+func NormalizeListing(listing *Listing) NormalizedListing {
+	return NormalizedListing{Posts: ListingToPosts(listing), After: listing.After, Error: listing.Error}
+}
+
+// CommentToNormalized converts a Reddit comment, without its replies, to the normalized post schema.
+func CommentToNormalized(c *Comment) normalized.Post {
+	raw := *c
+	raw.Replies = nil
+	return normalized.Post{
+		ID:         c.ID,
+		Source:     normalized.SourceReddit,
+		URL:        siteURL + c.Permalink,
+		Text:       c.Body,
+		Author:     author(c.Author, c.AuthorID),
+		CreatedAt:  normalized.TimePtr(c.Created()),
+		ChannelID:  c.Subreddit,
+		ReplyToID:  trimKind(c.ParentID),
+		Metrics:    map[string]int{"score": c.Score},
+		Extensions: normalized.Extension(normalized.SourceReddit, raw),
+	}
+}
+
+// CommentTreeToPosts converts a post and its comments to normalized posts, the post first and
+// the comments in the order of the tree. The tree structure is kept in ReplyToID.
+func CommentTreeToPosts(tree *CommentTree) []normalized.Post {
+	posts := []normalized.Post{PostToNormalized(tree.Post)}
+	var walk func(comments []*Comment)
+	walk = func(comments []*Comment) {
+		for _, c := range comments {
+			posts = append(posts, CommentToNormalized(c))
+			walk(c.Replies)
+		}
+	}
+	walk(tree.Comments)
+	return posts
+}
+
+// author returns the normalized author of a post or comment, nil for deleted accounts.
+func author(username, fullname string) *normalized.Author {
+	if username == "" || username == "[deleted]" {
+		return nil
+	}
+	return &normalized.Author{
+		ID:          trimKind(fullname),
+		Source:      normalized.SourceReddit,
+		Username:    username,
+		DisplayName: username,
+		URL:         siteURL + "/user/" + username,
+	}
+}
+
+// trimKind removes the kind prefix from a fullname, such as "t1_" in "t1_abc123".
+func trimKind(fullname string) string {
+	if len(fullname) > 3 && fullname[0] == 't' && fullname[2] == '_' {
+		return fullname[3:]
+	}
+	return fullname
+}
+
+// thumbnailURL returns the thumbnail if it is a URL. Reddit uses "self", "default", "nsfw" and
+// "spoiler" as placeholders.
+func thumbnailURL(thumbnail string) string {
+	if len(thumbnail) > 4 && thumbnail[:4] == "http" {
+		return thumbnail
+	}
+	return ""
+}
@@ -1,7 +1,207 @@
+// Package reddit reads subreddit listings, comment trees, user submissions and search results
+// from the Reddit API.
 package reddit
 
-import "github.com/sirupsen/logrus"
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
 
-func TestReddit() {
-	logrus.Info("Testing reddit")
+// Post is a Reddit submission, with the fields of Reddit's "t3" objects.
+type Post struct {
+	ID          string  `json:"id"`
+	Name        string  `json:"name"` // Fullname, such as "t3_abc123", used as pagination token
+	Subreddit   string  `json:"subreddit"`
+	Title       string  `json:"title"`
+	SelfText    string  `json:"selftext,omitempty"`
+	Author      string  `json:"author"`
+	AuthorID    string  `json:"author_fullname,omitempty"`
+	URL         string  `json:"url,omitempty"`
+	Domain      string  `json:"domain,omitempty"`
+	Permalink   string  `json:"permalink"`
+	CreatedUTC  float64 `json:"created_utc"`
+	Edited      Edited  `json:"edited,omitempty"`
+	Score       int     `json:"score"`
+	UpvoteRatio float64 `json:"upvote_ratio,omitempty"`
+	NumComments int     `json:"num_comments"`
+	Flair       string  `json:"link_flair_text,omitempty"`
+	Thumbnail   string  `json:"thumbnail,omitempty"`
+	PostHint    string  `json:"post_hint,omitempty"` // "image", "hosted:video", "link" or "self"
+	IsSelf      bool    `json:"is_self"`
+	IsVideo     bool    `json:"is_video,omitempty"`
+	Over18      bool    `json:"over_18,omitempty"`
+	Spoiler     bool    `json:"spoiler,omitempty"`
+	Stickied    bool    `json:"stickied,omitempty"`
+	Locked      bool    `json:"locked,omitempty"`
+}
+
+// Comment is a Reddit comment, with the fields of Reddit's "t1" objects and its replies.
+type Comment struct {
+	ID            string     `json:"id"`
+	Name          string     `json:"name"`
+	ParentID      string     `json:"parent_id"` // Fullname of the post or comment replied to
+	LinkID        string     `json:"link_id"`   // Fullname of the post
+	Subreddit     string     `json:"subreddit"`
+	Author        string     `json:"author"`
+	AuthorID      string     `json:"author_fullname,omitempty"`
+	Body          string     `json:"body"`
+	Permalink     string     `json:"permalink"`
+	CreatedUTC    float64    `json:"created_utc"`
+	Edited        Edited     `json:"edited,omitempty"`
+	Score         int        `json:"score"`
+	Depth         int        `json:"depth"`
+	Stickied      bool       `json:"stickied,omitempty"`
+	Distinguished string     `json:"distinguished,omitempty"`
+	Replies       []*Comment `json:"replies,omitempty"`
+	// More lists the replies Reddit left out of the tree, to be loaded separately.
+	More *More `json:"more,omitempty"`
+}
+
+// More is a placeholder for comments left out of a tree because it was too large.
+type More struct {
+	Count    int      `json:"count"`
+	ParentID string   `json:"parent_id"`
+	Children []string `json:"children"` // IDs of the missing comments
+}
+
+// Listing is a page of posts. After is the pagination token of the next page, empty on the last
+// page. Error is set when a page could not be fetched and the listing was cut short; After then
+// resumes after the last post.
+type Listing struct {
+	Posts []Post `json:"posts"`
+	After string `json:"after,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// CommentTree is a post with its comments.
+type CommentTree struct {
+	Post     Post       `json:"post"`
+	Comments []*Comment `json:"comments"`
+	More     *More      `json:"more,omitempty"`
+}
+
+// Edited is the time a post or comment was last edited, zero if it never was. Reddit sends
+// false for posts that were not edited and a Unix timestamp otherwise.
+type Edited float64
+
+func (e *Edited) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("false")) || bytes.Equal(data, []byte("true")) || bytes.Equal(data, []byte("null")) {
+		*e = 0
+		return nil
+	}
+	value, err := strconv.ParseFloat(string(data), 64)
+	if err != nil {
+		return fmt.Errorf("invalid edited value %s", data)
+	}
+	*e = Edited(value)
+	return nil
+}
+
+// Created returns the time the post was submitted.
+func (p Post) Created() time.Time {
+	return unixTime(p.CreatedUTC)
+}
+
+// Created returns the time the comment was posted.
+func (c Comment) Created() time.Time {
+	return unixTime(c.CreatedUTC)
+}
+
+func unixTime(seconds float64) time.Time {
+	if seconds == 0 {
+		return time.Time{}
+	}
+	return time.Unix(int64(seconds), 0).UTC()
+}
+
+// thing is the envelope Reddit wraps every object in, tagged with its kind.
+type thing struct {
+	Kind string          `json:"kind"`
+	Data json.RawMessage `json:"data"`
+}
+
+type listingData struct {
+	After    string  `json:"after"`
+	Children []thing `json:"children"`
+}
+
+// rawComment is a comment as sent by Reddit, whose replies are an empty string or a listing.
+type rawComment struct {
+	Comment
+	Replies json.RawMessage `json:"replies"`
+}
+
+// parseListing decodes a listing of posts.
+func parseListing(data []byte) (*Listing, error) {
+	var envelope thing
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return nil, err
+	}
+	if envelope.Kind != "Listing" {
+		return nil, fmt.Errorf("unexpected reddit object %q, expected a listing", envelope.Kind)
+	}
+	var listing listingData
+	if err := json.Unmarshal(envelope.Data, &listing); err != nil {
+		return nil, err
+	}
+	result := &Listing{Posts: []Post{}, After: listing.After}
+	for _, child := range listing.Children {
+		if child.Kind != "t3" {
+			continue
+		}
+		var post Post
+		if err := json.Unmarshal(child.Data, &post); err != nil {
+			return nil, err
+		}
+		result.Posts = append(result.Posts, post)
+	}
+	return result, nil
+}
+
+// parseComments decodes a listing of comments into a tree, with the placeholder of the comments
+// left out at its level.
+func parseComments(data json.RawMessage) ([]*Comment, *More, error) {
+	if len(data) == 0 || data[0] != '{' {
+		// Comments without replies have an empty string instead of a listing.
+		return nil, nil, nil
+	}
+	var envelope thing
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return nil, nil, err
+	}
+	var listing listingData
+	if err := json.Unmarshal(envelope.Data, &listing); err != nil {
+		return nil, nil, err
+	}
+
+	var comments []*Comment
+	var more *More
+	for _, child := range listing.Children {
+		switch child.Kind {
+		case "t1":
+			var raw rawComment
+			if err := json.Unmarshal(child.Data, &raw); err != nil {
+				return nil, nil, err
+			}
+			comment := raw.Comment
+			replies, repliesMore, err := parseComments(raw.Replies)
+			if err != nil {
+				return nil, nil, err
+			}
+			comment.Replies, comment.More = replies, repliesMore
+			comments = append(comments, &comment)
+		case "more":
+			var m More
+			if err := json.Unmarshal(child.Data, &m); err != nil {
+				return nil, nil, err
+			}
+			if len(m.Children) > 0 {
+				more = &m
+			}
+		}
+	}
+	return comments, more, nil
 }
@@ -2,66 +2,286 @@ package telegram
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/gotd/td/tg"
+	"github.com/gotd/td/tgerr"
+	"github.com/sirupsen/logrus"
 )
 
+const (
+	// DefaultHistoryLimit is the number of messages fetched when no limit is given.
+	DefaultHistoryLimit = 100
+	// MaxHistoryPageSize is the most messages Telegram returns for one history request.
+	MaxHistoryPageSize = 100
+	// DefaultMaxFloodWait is the longest flood wait that is waited out before giving up.
+	DefaultMaxFloodWait = 30 * time.Second
+)
+
+// HistoryOptions selects the messages of a channel's history. Messages are walked from the
+// newest to the oldest.
+type HistoryOptions struct {
+	// OffsetID starts the walk at the messages older than this message ID, 0 for the newest message.
+	OffsetID int
+	// Since stops the walk at the first message older than this date.
+	Since time.Time
+	// Until skips the messages newer than this date.
+	Until time.Time
+	// Limit is the total number of messages to fetch, DefaultHistoryLimit if 0.
+	Limit int
+	// PageSize is the number of messages fetched per request, at most MaxHistoryPageSize.
+	PageSize int
+	// MaxFloodWait is the longest flood wait to sleep through, DefaultMaxFloodWait if 0.
+	MaxFloodWait time.Duration
+	// MaxDuration stops the walk after this much time if not 0.
+	MaxDuration time.Duration
+}
+
+// HistoryFetcher requests one page of a peer's history, like the MessagesGetHistory method of the
+// Telegram API client.
+type HistoryFetcher func(ctx context.Context, request *tg.MessagesGetHistoryRequest) (tg.MessagesMessagesClass, error)
+
+// Target selects the chat whose messages are fetched.
+type Target struct {
+	// Username of a public channel or supergroup.
+	Username string
+	// ChatID of a basic group the account is a member of, used when Username is empty.
+	ChatID int64
+	// PostID selects the replies to this message instead of the whole chat, which for a channel
+	// post are the comments from its discussion group.
+	PostID int
+}
+
 // FetchChannelMessages Fetch messages from a group
-func FetchChannelMessages(ctx context.Context, username string) ([]*tg.Message, error) {
-	// Initialize the Telegram client (if not already initialized)
-	client, err := GetClient()
+func FetchChannelMessages(ctx context.Context, username string) ([]Message, error) {
+	return FetchChatHistory(ctx, Target{Username: username}, HistoryOptions{})
+}
+
+// FetchChatHistory fetches the messages of a channel or group selected by opts. The requests are
+// made by the next session in rotation; when Telegram asks a session to wait longer than
+// opts.MaxFloodWait, the walk continues with another session, except for basic groups. When the
+// walk fails part way, the messages fetched so far are returned with the error; the ID of the last
+// one is the offset to resume from.
+func FetchChatHistory(ctx context.Context, target Target, opts HistoryOptions) ([]Message, error) {
+	manager := GetSessionManager()
+	return RotateSessions(ctx, manager, target, opts, func(ctx context.Context, opts HistoryOptions) (*Session, []Message, error) {
+		session, client, err := manager.authorizedClient(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		messages, err := fetchChatHistory(ctx, client.API(), target, opts)
+		return session, messages, err
+	})
+}
+
+// SessionWalk walks a history with the next session in rotation and returns the session it used,
+// or no session and ErrNoSessionAvailable if every session is waiting.
+type SessionWalk func(ctx context.Context, opts HistoryOptions) (*Session, []Message, error)
+
+// RotateSessions runs walk with the sessions of manager, continuing below the last message with
+// another session when one hits a flood wait. Message IDs of a basic group differ between
+// accounts, and the next account may not be a member, so a walk of a basic group is not rotated:
+// the messages fetched so far are returned with the flood wait error.
+func RotateSessions(ctx context.Context, manager *SessionManager, target Target, opts HistoryOptions, walk SessionWalk) ([]Message, error) {
+	if opts.Limit <= 0 {
+		opts.Limit = DefaultHistoryLimit
+	}
+	var deadline time.Time
+	if opts.MaxDuration > 0 {
+		deadline = time.Now().Add(opts.MaxDuration)
+	}
+
+	var messages []Message
+	var floodErr error
+	for {
+		session, page, err := walk(ctx, opts)
+		if session == nil {
+			if errors.Is(err, ErrNoSessionAvailable) && floodErr != nil {
+				// Every session is waiting, report how long the last one has to.
+				return messages, floodErr
+			}
+			log.Printf("Failed to initialize Telegram client: %v", err)
+			return messages, err
+		}
+		messages = append(messages, page...)
+		wait, floodWait := tgerr.AsFloodWait(err)
+		if !floodWait {
+			if err != nil {
+				manager.RecordError(session)
+			}
+			return messages, err
+		}
+		manager.MarkFloodWait(session, wait)
+		if target.Username == "" {
+			logrus.Warnf("[-] Telegram session %s must wait %s, stopping the basic group walk", session.Name, wait)
+			return messages, err
+		}
+		logrus.Warnf("[-] Telegram session %s must wait %s, switching sessions", session.Name, wait)
+		floodErr = err
+
+		// Continue below the last message with the next session.
+		if len(page) > 0 {
+			opts.OffsetID = page[len(page)-1].ID
+		}
+		opts.Limit -= len(page)
+		if opts.Limit <= 0 {
+			return messages, nil
+		}
+		if !deadline.IsZero() {
+			opts.MaxDuration = time.Until(deadline)
+			if opts.MaxDuration <= 0 {
+				return messages, ErrTimeBudget
+			}
+		}
+	}
+}
+
+// fetchChatHistory walks the history of target with the API client of one session.
+func fetchChatHistory(ctx context.Context, api *tg.Client, target Target, opts HistoryOptions) ([]Message, error) {
+	inputPeer, err := resolvePeer(ctx, api, target)
 	if err != nil {
-		log.Printf("Failed to initialize Telegram client: %v", err)
-		return nil, err // Edit: Added nil as the first return value
+		return nil, err
+	}
+	if target.PostID == 0 {
+		return WalkHistory(ctx, inputPeer, opts, api.MessagesGetHistory)
 	}
 
-	var messagesSlice []*tg.Message // Define a slice to hold the messages
+	messages, err := WalkHistory(ctx, inputPeer, opts, func(ctx context.Context, request *tg.MessagesGetHistoryRequest) (tg.MessagesMessagesClass, error) {
+		return api.MessagesGetReplies(ctx, &tg.MessagesGetRepliesRequest{
+			Peer:       request.Peer,
+			MsgID:      target.PostID,
+			OffsetID:   request.OffsetID,
+			OffsetDate: request.OffsetDate,
+			Limit:      request.Limit,
+		})
+	})
+	for i := range messages {
+		messages[i].PostID = target.PostID
+	}
+	return messages, err
+}
 
-	err = client.Run(ctx, func(ctx context.Context) error {
-		resolved, err := client.API().ContactsResolveUsername(ctx, username)
-		if err != nil {
-			return err
+// resolvePeer finds the input peer of a channel or supergroup by its username, or of a basic
+// group by its ID.
+func resolvePeer(ctx context.Context, api *tg.Client, target Target) (tg.InputPeerClass, error) {
+	if target.Username == "" {
+		if target.ChatID == 0 {
+			return nil, errors.New("a username or chat ID is required")
 		}
+		return &tg.InputPeerChat{ChatID: target.ChatID}, nil
+	}
 
-		channel := &tg.InputChannel{
-			ChannelID:  resolved.Chats[0].GetID(),
-			AccessHash: resolved.Chats[0].(*tg.Channel).AccessHash,
+	resolved, err := api.ContactsResolveUsername(ctx, target.Username)
+	if err != nil {
+		return nil, err
+	}
+	peer, ok := resolved.Peer.(*tg.PeerChannel)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a channel or group", target.Username)
+	}
+	for _, chat := range resolved.Chats {
+		switch c := chat.(type) {
+		case *tg.Channel:
+			if c.ID == peer.ChannelID {
+				return &tg.InputPeerChannel{ChannelID: c.ID, AccessHash: c.AccessHash}, nil
+			}
+		case *tg.ChannelForbidden:
+			if c.ID == peer.ChannelID {
+				return nil, fmt.Errorf("access to %s is forbidden", target.Username)
+			}
 		}
+	}
+	return nil, fmt.Errorf("no channel found for %s", target.Username)
+}
+
+// WalkHistory pages through the history of peer with fetch until the options are satisfied or the
+// start of the history is reached. Flood waits up to opts.MaxFloodWait are slept through, longer
+// ones end the walk with the error and the messages fetched so far.
+func WalkHistory(ctx context.Context, peer tg.InputPeerClass, opts HistoryOptions, fetch HistoryFetcher) ([]Message, error) {
+	limit := historyLimit(opts)
+	pageSize := opts.PageSize
+	if pageSize <= 0 || pageSize > MaxHistoryPageSize {
+		pageSize = MaxHistoryPageSize
+	}
+	maxFloodWait := opts.MaxFloodWait
+	if maxFloodWait <= 0 {
+		maxFloodWait = DefaultMaxFloodWait
+	}
+	var deadline time.Time
+	if opts.MaxDuration > 0 {
+		deadline = time.Now().Add(opts.MaxDuration)
+	}
 
-		fmt.Printf("Channel ID: %d, Access Hash: %d\n", channel.ChannelID, channel.AccessHash)
+	request := &tg.MessagesGetHistoryRequest{Peer: peer, OffsetID: opts.OffsetID}
+	if opts.OffsetID == 0 && !opts.Until.IsZero() {
+		// Telegram returns the messages sent before the offset date, so round up to keep Until.
+		request.OffsetDate = int(opts.Until.Unix()) + 1
+	}
 
-		inputPeer := &tg.InputPeerChannel{ // Use InputPeerChannel instead of InputChannel
-			ChannelID:  channel.ChannelID,
-			AccessHash: channel.AccessHash,
+	var messages []Message
+	for len(messages) < limit {
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			return messages, ErrTimeBudget
+		}
+		request.Limit = min(pageSize, limit-len(messages))
+		result, err := fetch(ctx, request)
+		if wait, ok := tgerr.AsFloodWait(err); ok {
+			if wait > maxFloodWait || (!deadline.IsZero() && time.Now().Add(wait).After(deadline)) {
+				return messages, fmt.Errorf("telegram flood wait of %s: %w", wait, err)
+			}
+			logrus.Warnf("[-] Telegram flood wait, retrying in %s", wait)
+			if err := sleepContext(ctx, wait); err != nil {
+				return messages, err
+			}
+			continue
 		}
-		result, err := client.API().MessagesGetHistory(ctx, &tg.MessagesGetHistoryRequest{
-			Peer:  inputPeer, // Pass inputPeer here
-			Limit: 100,       // Adjust the number of messages to fetch
-		})
 		if err != nil {
-			return err
+			return messages, err
 		}
 
-		// Type assert the result to *tg.MessagesChannelMessages to access Messages field
-		messages, ok := result.(*tg.MessagesChannelMessages)
+		modified, ok := result.AsModified()
 		if !ok {
-			return fmt.Errorf("unexpected type %T", result)
+			return messages, fmt.Errorf("unexpected type %T", result)
 		}
-
-		// Process the messages
-		for _, m := range messages.Messages {
-			message, ok := m.(*tg.Message) // Type assert to *tg.Message
+		page := modified.GetMessages()
+		if len(page) == 0 {
+			return messages, nil
+		}
+		entities := newEntities(modified.GetUsers(), modified.GetChats())
+		for _, m := range page {
+			message, ok := entities.convertMessage(m)
 			if !ok {
-				// Handle the case where the message is not a regular message (e.g., service message)
 				continue
 			}
-			messagesSlice = append(messagesSlice, message) // Append the message to the slice
+			if !opts.Until.IsZero() && message.Date.Unix() > opts.Until.Unix() {
+				continue
+			}
+			if !opts.Since.IsZero() && message.Date.Unix() < opts.Since.Unix() {
+				return messages, nil
+			}
+			messages = append(messages, message)
+			if len(messages) == limit {
+				return messages, nil
+			}
 		}
+		// The next page starts below the oldest message of this one.
+		request.OffsetID = page[len(page)-1].GetID()
+		request.OffsetDate = 0
+	}
+	return messages, nil
+}
 
+// sleepContext sleeps for d or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
 		return nil
-	})
-
-	return messagesSlice, err // Return the slice of messages and any error
+	}
 }
@@ -0,0 +1,62 @@
+package telegram
+
+import (
+	"errors"
+
+	"github.com/Gzgod/masa-oracle/pkg/scrapers/normalized"
+)
+
+// Reasons why a history walk stopped.
+const (
+	StopEnd   = "end"   // The start of the history or the Since date was reached
+	StopLimit = "limit" // The requested number of messages was fetched
+	StopTime  = "time"  // The time budget ran out, the walk can be resumed from NextOffsetID
+	StopError = "error" // A page could not be fetched, the walk can be resumed from NextOffsetID
+)
+
+// ErrTimeBudget is returned with the messages fetched so far when the MaxDuration of a walk ran out.
+var ErrTimeBudget = errors.New("telegram history time budget ran out")
+
+// ChannelHistory is the result of a history walk.
+type ChannelHistory struct {
+	Messages     []Message         `json:"messages,omitempty"`
+	Posts        []normalized.Post `json:"posts,omitempty"`
+	MessageCount int               `json:"messageCount"`
+	// NextOffsetID is the offset ID to continue the walk from, 0 once the walk is complete.
+	NextOffsetID int    `json:"nextOffsetId,omitempty"`
+	Complete     bool   `json:"complete"`
+	StopReason   string `json:"stopReason"`
+	Error        string `json:"error,omitempty"`
+}
+
+// NewChannelHistory describes the messages and error a walk with opts returned: why it stopped
+// and, unless it is complete, the offset ID to resume from.
+func NewChannelHistory(messages []Message, opts HistoryOptions, err error) *ChannelHistory {
+	history := &ChannelHistory{Messages: messages, MessageCount: len(messages)}
+	switch {
+	case errors.Is(err, ErrTimeBudget):
+		history.StopReason = StopTime
+	case err != nil:
+		history.StopReason = StopError
+		history.Error = err.Error()
+	case len(messages) >= historyLimit(opts):
+		history.StopReason = StopLimit
+	default:
+		history.StopReason = StopEnd
+		history.Complete = true
+		return history
+	}
+	history.NextOffsetID = opts.OffsetID
+	if len(messages) > 0 {
+		history.NextOffsetID = int(messages[len(messages)-1].ID)
+	}
+	return history
+}
+
+// historyLimit returns the number of messages a walk with opts fetches at most.
+func historyLimit(opts HistoryOptions) int {
+	if opts.Limit <= 0 {
+		return DefaultHistoryLimit
+	}
+	return opts.Limit
+}
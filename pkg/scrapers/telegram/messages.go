@@ -0,0 +1,305 @@
+package telegram
+
+import (
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gotd/td/tg"
+)
+
+// PeerType is the kind of a Telegram user or chat.
+type PeerType string
+
+const (
+	PeerUser       PeerType = "user"
+	PeerGroup      PeerType = "group" // Basic group
+	PeerSupergroup PeerType = "supergroup"
+	PeerChannel    PeerType = "channel" // Broadcast channel
+)
+
+// MediaType is the kind of a message attachment.
+type MediaType string
+
+const (
+	MediaPhoto     MediaType = "photo"
+	MediaVideo     MediaType = "video"
+	MediaAnimation MediaType = "animation"
+	MediaAudio     MediaType = "audio"
+	MediaVoice     MediaType = "voice"
+	MediaSticker   MediaType = "sticker"
+	MediaDocument  MediaType = "document"
+	MediaWebPage   MediaType = "webpage"
+	MediaGeo       MediaType = "geo"
+	MediaContact   MediaType = "contact"
+	MediaPoll      MediaType = "poll"
+	MediaOther     MediaType = "other"
+)
+
+// Peer is a Telegram user or chat.
+type Peer struct {
+	ID       int64    `json:"id"`
+	Type     PeerType `json:"type"`
+	Username string   `json:"username,omitempty"`
+	Name     string   `json:"name,omitempty"` // Title of a chat, full name of a user
+}
+
+// Forward describes where a forwarded message was first posted.
+type Forward struct {
+	From          *Peer     `json:"from,omitempty"`
+	FromName      string    `json:"fromName,omitempty"` // Set instead of From for users who hide their account
+	Date          time.Time `json:"date"`
+	ChannelPostID int       `json:"channelPostId,omitempty"`
+	PostAuthor    string    `json:"postAuthor,omitempty"`
+}
+
+// Media is the metadata of a message attachment. The file itself is not downloaded.
+type Media struct {
+	Type      MediaType `json:"type"`
+	ID        int64     `json:"id,omitempty"`
+	MimeType  string    `json:"mimeType,omitempty"`
+	FileName  string    `json:"fileName,omitempty"`
+	Size      int64     `json:"size,omitempty"`
+	Width     int       `json:"width,omitempty"`
+	Height    int       `json:"height,omitempty"`
+	Duration  float64   `json:"duration,omitempty"` // Seconds
+	Title     string    `json:"title,omitempty"`
+	Performer string    `json:"performer,omitempty"`
+	URL       string    `json:"url,omitempty"` // Link of a web page preview
+}
+
+// Message is a message of a Telegram channel or group.
+type Message struct {
+	ID         int        `json:"id"`
+	Chat       *Peer      `json:"chat,omitempty"`
+	From       *Peer      `json:"from,omitempty"` // Not set for channel posts
+	PostAuthor string     `json:"postAuthor,omitempty"`
+	Date       time.Time  `json:"date"`
+	EditDate   *time.Time `json:"editDate,omitempty"`
+	Text       string     `json:"text,omitempty"`
+	ReplyToID  int        `json:"replyToId,omitempty"`
+	ThreadID   int        `json:"threadId,omitempty"` // First message of the thread or forum topic
+	PostID     int        `json:"postId,omitempty"`   // Channel post the message comments on
+	GroupedID  int64      `json:"groupedId,omitempty"`
+	Pinned     bool       `json:"pinned,omitempty"`
+	Forward    *Forward   `json:"forwardedFrom,omitempty"`
+	Media      *Media     `json:"media,omitempty"`
+	Action     string     `json:"action,omitempty"` // Set for service messages, such as "chatAddUser"
+	Views      int        `json:"views,omitempty"`
+	Forwards   int        `json:"forwards,omitempty"`
+	Replies    int        `json:"replies,omitempty"`
+}
+
+// entities indexes the users and chats sent along with a page of messages, so that the peers of
+// the messages can be described by name.
+type entities struct {
+	users map[int64]*tg.User
+	chats map[int64]tg.ChatClass
+}
+
+func newEntities(users []tg.UserClass, chats []tg.ChatClass) entities {
+	e := entities{users: map[int64]*tg.User{}, chats: map[int64]tg.ChatClass{}}
+	for _, u := range users {
+		if user, ok := u.(*tg.User); ok {
+			e.users[user.ID] = user
+		}
+	}
+	for _, chat := range chats {
+		e.chats[chat.GetID()] = chat
+	}
+	return e
+}
+
+func (e entities) peer(peer tg.PeerClass) *Peer {
+	switch p := peer.(type) {
+	case *tg.PeerUser:
+		result := &Peer{ID: p.UserID, Type: PeerUser}
+		if user, ok := e.users[p.UserID]; ok {
+			result.Username = user.Username
+			result.Name = strings.TrimSpace(user.FirstName + " " + user.LastName)
+		}
+		return result
+	case *tg.PeerChat:
+		result := &Peer{ID: p.ChatID, Type: PeerGroup}
+		if chat, ok := e.chats[p.ChatID].(*tg.Chat); ok {
+			result.Name = chat.Title
+		}
+		return result
+	case *tg.PeerChannel:
+		result := &Peer{ID: p.ChannelID, Type: PeerChannel}
+		if channel, ok := e.chats[p.ChannelID].(*tg.Channel); ok {
+			result.Type = channelType(channel)
+			result.Username = channel.Username
+			result.Name = channel.Title
+		}
+		return result
+	}
+	return nil
+}
+
+func channelType(channel *tg.Channel) PeerType {
+	if channel.Megagroup || channel.Gigagroup {
+		return PeerSupergroup
+	}
+	return PeerChannel
+}
+
+// convertMessage converts a message to the typed result. Empty messages are skipped.
+func (e entities) convertMessage(m tg.MessageClass) (Message, bool) {
+	switch message := m.(type) {
+	case *tg.Message:
+		result := Message{
+			ID:         message.ID,
+			Chat:       e.peer(message.PeerID),
+			PostAuthor: message.PostAuthor,
+			Date:       unixTime(message.Date),
+			Text:       message.Message,
+			GroupedID:  message.GroupedID,
+			Pinned:     message.Pinned,
+			Views:      message.Views,
+			Forwards:   message.Forwards,
+			Media:      mediaMetadata(message.Media),
+		}
+		result.From = e.peer(message.FromID)
+		if message.EditDate != 0 {
+			date := unixTime(message.EditDate)
+			result.EditDate = &date
+		}
+		result.Replies = message.Replies.Replies
+		result.ReplyToID, result.ThreadID = replyTo(message.ReplyTo)
+		if fwd, ok := message.GetFwdFrom(); ok {
+			result.Forward = &Forward{
+				From:          e.peer(fwd.FromID),
+				FromName:      fwd.FromName,
+				Date:          unixTime(fwd.Date),
+				ChannelPostID: fwd.ChannelPost,
+				PostAuthor:    fwd.PostAuthor,
+			}
+		}
+		return result, true
+	case *tg.MessageService:
+		result := Message{
+			ID:     message.ID,
+			Chat:   e.peer(message.PeerID),
+			Date:   unixTime(message.Date),
+			From:   e.peer(message.FromID),
+			Action: actionName(message.Action),
+		}
+		result.ReplyToID, result.ThreadID = replyTo(message.ReplyTo)
+		return result, true
+	}
+	return Message{}, false
+}
+
+func replyTo(header tg.MessageReplyHeaderClass) (replyToID, threadID int) {
+	if h, ok := header.(*tg.MessageReplyHeader); ok {
+		return h.ReplyToMsgID, h.ReplyToTopID
+	}
+	return 0, 0
+}
+
+// actionName turns the TL name of a service message action, such as "messageActionChatAddUser",
+// into "chatAddUser".
+func actionName(action tg.MessageActionClass) string {
+	if action == nil {
+		return ""
+	}
+	name := []rune(strings.TrimPrefix(action.TypeName(), "messageAction"))
+	if len(name) == 0 {
+		return ""
+	}
+	name[0] = unicode.ToLower(name[0])
+	return string(name)
+}
+
+func mediaMetadata(media tg.MessageMediaClass) *Media {
+	switch m := media.(type) {
+	case nil, *tg.MessageMediaEmpty:
+		return nil
+	case *tg.MessageMediaPhoto:
+		result := &Media{Type: MediaPhoto}
+		if photo, ok := m.Photo.(*tg.Photo); ok {
+			result.ID = photo.ID
+			for _, size := range photo.Sizes {
+				switch s := size.(type) {
+				case *tg.PhotoSize:
+					if s.W*s.H > result.Width*result.Height {
+						result.Width, result.Height, result.Size = s.W, s.H, int64(s.Size)
+					}
+				case *tg.PhotoSizeProgressive:
+					if s.W*s.H > result.Width*result.Height && len(s.Sizes) > 0 {
+						result.Width, result.Height, result.Size = s.W, s.H, int64(s.Sizes[len(s.Sizes)-1])
+					}
+				}
+			}
+		}
+		return result
+	case *tg.MessageMediaDocument:
+		doc, ok := m.Document.(*tg.Document)
+		if !ok {
+			return &Media{Type: MediaDocument}
+		}
+		return documentMetadata(doc)
+	case *tg.MessageMediaWebPage:
+		result := &Media{Type: MediaWebPage}
+		if page, ok := m.Webpage.(*tg.WebPage); ok {
+			result.ID = page.ID
+			result.URL = page.URL
+			result.Title = page.Title
+		}
+		return result
+	case *tg.MessageMediaGeo, *tg.MessageMediaGeoLive, *tg.MessageMediaVenue:
+		return &Media{Type: MediaGeo}
+	case *tg.MessageMediaContact:
+		return &Media{Type: MediaContact, Title: strings.TrimSpace(m.FirstName + " " + m.LastName)}
+	case *tg.MessageMediaPoll:
+		return &Media{Type: MediaPoll, ID: m.Poll.ID, Title: m.Poll.Question.Text}
+	}
+	return &Media{Type: MediaOther}
+}
+
+func documentMetadata(doc *tg.Document) *Media {
+	result := &Media{
+		Type:     MediaDocument,
+		ID:       doc.ID,
+		MimeType: doc.MimeType,
+		Size:     doc.Size,
+	}
+	if strings.HasPrefix(doc.MimeType, "image/") {
+		result.Type = MediaPhoto
+	}
+	animated, sticker := false, false
+	for _, attr := range doc.Attributes {
+		switch a := attr.(type) {
+		case *tg.DocumentAttributeFilename:
+			result.FileName = a.FileName
+		case *tg.DocumentAttributeImageSize:
+			result.Width, result.Height = a.W, a.H
+		case *tg.DocumentAttributeVideo:
+			result.Type = MediaVideo
+			result.Width, result.Height, result.Duration = a.W, a.H, a.Duration
+		case *tg.DocumentAttributeAudio:
+			result.Type = MediaAudio
+			if a.Voice {
+				result.Type = MediaVoice
+			}
+			result.Duration = float64(a.Duration)
+			result.Title, result.Performer = a.Title, a.Performer
+		case *tg.DocumentAttributeAnimated:
+			animated = true
+		case *tg.DocumentAttributeSticker:
+			sticker = true
+		}
+	}
+	// Stickers and animations are images or videos with an extra attribute.
+	if sticker {
+		result.Type = MediaSticker
+	} else if animated {
+		result.Type = MediaAnimation
+	}
+	return result
+}
+
+func unixTime(date int) time.Time {
+	return time.Unix(int64(date), 0).UTC()
+}
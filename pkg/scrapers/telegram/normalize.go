@@ -0,0 +1,143 @@
+package telegram
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Gzgod/masa-oracle/pkg/scrapers/normalized"
+)
+
+// MessageToPost converts a message of the chat with the given username to the normalized post
+// schema. Messages of basic groups, which have no username, have no URL.
+func MessageToPost(chat string, message Message) normalized.Post {
+	post := normalized.Post{
+		ID:         strconv.Itoa(message.ID),
+		Source:     normalized.SourceTelegram,
+		Text:       message.Text,
+		ChannelID:  chat,
+		Extensions: normalized.Extension(normalized.SourceTelegram, message),
+	}
+	if isUsername(chat) {
+		post.URL = fmt.Sprintf("https://t.me/%s/%d", chat, message.ID)
+		if message.PostID != 0 {
+			post.URL = fmt.Sprintf("https://t.me/%s/%d?comment=%d", chat, message.PostID, message.ID)
+		}
+	}
+	if !message.Date.IsZero() {
+		createdAt := message.Date
+		post.CreatedAt = &createdAt
+	}
+
+	switch {
+	case message.From != nil:
+		post.Author = authorOf(message.From)
+	case message.Chat != nil:
+		post.Author = authorOf(message.Chat)
+		if post.Author.Username == "" && isUsername(chat) {
+			post.Author.Username = chat
+			post.Author.URL = fmt.Sprintf("https://t.me/%s", chat)
+		}
+	}
+	if post.Author != nil && message.PostAuthor != "" {
+		post.Author.DisplayName = message.PostAuthor
+	}
+
+	if message.ReplyToID != 0 {
+		post.ReplyToID = strconv.Itoa(message.ReplyToID)
+	}
+
+	metrics := map[string]int{}
+	if message.Views > 0 {
+		metrics["views"] = message.Views
+	}
+	if message.Forwards > 0 {
+		metrics["forwards"] = message.Forwards
+	}
+	if message.Replies > 0 {
+		metrics["replies"] = message.Replies
+	}
+	if len(metrics) > 0 {
+		post.Metrics = metrics
+	}
+
+	if m, ok := mediaOf(message.Media); ok {
+		post.Media = append(post.Media, m)
+	}
+	return post
+}
+
+// MessagesToPosts converts messages of the chat with the given username to normalized posts.
+// Service messages, such as members joining, are left out.
+func MessagesToPosts(chat string, messages []Message) []normalized.Post {
+	posts := make([]normalized.Post, 0, len(messages))
+	for _, message := range messages {
+		if message.Action != "" {
+			continue
+		}
+		posts = append(posts, MessageToPost(chat, message))
+	}
+	return posts
+}
+
+func authorOf(peer *Peer) *normalized.Author {
+	author := &normalized.Author{
+		Source:      normalized.SourceTelegram,
+		ID:          strconv.FormatInt(peer.ID, 10),
+		Username:    peer.Username,
+		DisplayName: peer.Name,
+	}
+	if peer.Username != "" {
+		author.URL = fmt.Sprintf("https://t.me/%s", peer.Username)
+	}
+	return author
+}
+
+// isUsername reports whether chat is a username rather than the ID of a basic group.
+func isUsername(chat string) bool {
+	if chat == "" {
+		return false
+	}
+	_, err := strconv.ParseInt(chat, 10, 64)
+	return err != nil
+}
+
+// mediaOf describes the attachment of a message. Telegram media cannot be fetched by URL, so
+// only the metadata is kept.
+func mediaOf(media *Media) (normalized.Media, bool) {
+	if media == nil {
+		return normalized.Media{}, false
+	}
+	result := normalized.Media{MimeType: media.MimeType, Size: media.Size, Name: media.FileName}
+	switch media.Type {
+	case MediaPhoto, MediaSticker:
+		result.Type = normalized.MediaImage
+	case MediaVideo:
+		result.Type = normalized.MediaVideo
+	case MediaAnimation:
+		result.Type = normalized.MediaGIF
+	case MediaAudio, MediaVoice:
+		result.Type = normalized.MediaAudio
+	case MediaDocument:
+		result.Type = documentMediaType(media.MimeType)
+	default:
+		// Web page previews, locations, contacts and polls are not files.
+		return normalized.Media{}, false
+	}
+	return result, true
+}
+
+func documentMediaType(mimeType string) normalized.MediaType {
+	switch {
+	case mimeType == "image/gif":
+		return normalized.MediaGIF
+	case strings.HasPrefix(mimeType, "image/"):
+		return normalized.MediaImage
+	case strings.HasPrefix(mimeType, "video/"):
+		return normalized.MediaVideo
+	case strings.HasPrefix(mimeType, "audio/"):
+		return normalized.MediaAudio
+	default:
+		return normalized.MediaDocument
+	}
+}
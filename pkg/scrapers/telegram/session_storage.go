@@ -0,0 +1,38 @@
+package telegram
+
+import (
+	"context"
+	"os"
+	"sync"
+
+	"github.com/gotd/td/session"
+
+	"github.com/Gzgod/masa-oracle/pkg/masacrypto"
+)
+
+// encryptedSessionStorage implements session.Storage on top of the node's credential store, so
+// that Telegram sessions are not kept in plain text on disk.
+type encryptedSessionStorage struct {
+	path  string
+	mutex sync.Mutex
+}
+
+// LoadSession loads and decrypts the session from disk.
+func (s *encryptedSessionStorage) LoadSession(_ context.Context) ([]byte, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	data, err := masacrypto.DefaultCredentialStore().ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil, session.ErrNotFound
+	}
+	return data, err
+}
+
+// StoreSession encrypts and stores the session on disk.
+func (s *encryptedSessionStorage) StoreSession(_ context.Context, data []byte) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return masacrypto.DefaultCredentialStore().WriteFile(s.path, data)
+}
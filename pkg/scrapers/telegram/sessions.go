@@ -0,0 +1,401 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gotd/td/telegram"
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultSessionName is the session used when no session is named. It is stored in the
+// session.json file that nodes used before several sessions were supported.
+const DefaultSessionName = "default"
+
+const (
+	sessionFileSuffix = ".session.json"
+	sessionStateFile  = "sessions-state.json"
+)
+
+var (
+	// ErrSessionNotFound is returned when an operation targets a session that is not part of the pool.
+	ErrSessionNotFound = errors.New("telegram session not found")
+	// ErrNoSessionAvailable is returned when every session is disabled, flood-waited or not authorized.
+	ErrNoSessionAvailable = errors.New("no telegram session is available, all are disabled, flood-waited or not authorized")
+	// ErrInvalidSessionName is returned for session names that cannot be used as a file name.
+	ErrInvalidSessionName = errors.New("telegram session names may only contain letters, digits, '-' and '_'")
+)
+
+var sessionNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// SessionStatus describes the current state of a Telegram session in the pool.
+type SessionStatus string
+
+const (
+	SessionStatusActive       SessionStatus = "active"
+	SessionStatusFloodWait    SessionStatus = "flood-wait"
+	SessionStatusUnauthorized SessionStatus = "unauthorized"
+	SessionStatusDisabled     SessionStatus = "disabled"
+)
+
+// SessionUsage holds the running usage counters of a single session.
+type SessionUsage struct {
+	Requests   int64     `json:"requests"`
+	Errors     int64     `json:"errors"`
+	FloodWaits int64     `json:"floodWaits"`
+	LastUsed   time.Time `json:"lastUsed,omitempty"`
+}
+
+// Session is a Telegram account signed in on the node. Each session has its own connection and
+// its own flood-wait limits.
+type Session struct {
+	Name           string
+	Disabled       bool
+	Unauthorized   bool // Set when the session was found not signed in, cleared by a new authentication
+	FloodWaitUntil time.Time
+	Usage          SessionUsage
+
+	conn *connection
+}
+
+// SessionInfo is a read-only snapshot of a session, safe to return from the API.
+type SessionInfo struct {
+	Name           string        `json:"name"`
+	Status         SessionStatus `json:"status"`
+	FloodWaitUntil *time.Time    `json:"floodWaitUntil,omitempty"`
+	Usage          SessionUsage  `json:"usage"`
+}
+
+// sessionState is the part of a Session that is persisted across restarts.
+type sessionState struct {
+	Disabled       bool         `json:"disabled"`
+	FloodWaitUntil time.Time    `json:"floodWaitUntil,omitempty"`
+	Usage          SessionUsage `json:"usage"`
+}
+
+// SessionManager rotates requests across the Telegram sessions of the node, skipping the ones
+// that are waiting out a flood wait.
+type SessionManager struct {
+	dir       string
+	sessions  []*Session
+	index     int
+	mutex     sync.Mutex
+	statePath string
+}
+
+var (
+	sessionManager *SessionManager
+	sessionOnce    sync.Once
+)
+
+// NewSessionManager creates a pool of the named sessions, stored in dir.
+func NewSessionManager(dir string, names []string) *SessionManager {
+	manager := &SessionManager{dir: dir}
+	for _, name := range names {
+		if _, err := manager.AddSession(name); err != nil {
+			logrus.Warnf("[-] Skipping Telegram session %q: %v", name, err)
+		}
+	}
+	return manager
+}
+
+// GetSessionManager returns the node's Telegram session pool, initializing it on first use. The
+// pool holds the sessions named in TELEGRAM_SESSIONS and every session stored in the session
+// directory, or the default session if there are none.
+func GetSessionManager() *SessionManager {
+	sessionOnce.Do(func() {
+		names := splitSessionNames(os.Getenv("TELEGRAM_SESSIONS"))
+		names = append(names, storedSessionNames(sessionDir)...)
+		if len(names) == 0 {
+			names = []string{DefaultSessionName}
+		}
+		sessionManager = NewSessionManager(sessionDir, names)
+		if err := sessionManager.LoadState(filepath.Join(sessionDir, sessionStateFile)); err != nil {
+			logrus.Errorf("[-] Failed to load Telegram session state: %v", err)
+		}
+	})
+	return sessionManager
+}
+
+func splitSessionNames(value string) []string {
+	var names []string
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// storedSessionNames lists the sessions that have a session file in dir.
+func storedSessionNames(dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, entry := range entries {
+		switch name := entry.Name(); {
+		case name == "session.json":
+			names = append(names, DefaultSessionName)
+		case strings.HasSuffix(name, sessionFileSuffix):
+			names = append(names, strings.TrimSuffix(name, sessionFileSuffix))
+		}
+	}
+	return names
+}
+
+// sessionPath returns the file the session with the given name is stored in.
+func sessionPath(dir, name string) string {
+	if name == DefaultSessionName {
+		return filepath.Join(dir, "session.json")
+	}
+	return filepath.Join(dir, name+sessionFileSuffix)
+}
+
+// AddSession adds a session to the pool and returns it. If a session with the name is already
+// part of the pool, that session is returned.
+func (manager *SessionManager) AddSession(name string) (*Session, error) {
+	if !sessionNamePattern.MatchString(name) {
+		return nil, ErrInvalidSessionName
+	}
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+	if session := manager.findSession(name); session != nil {
+		return session, nil
+	}
+	session := &Session{Name: name, conn: &connection{path: sessionPath(manager.dir, name)}}
+	manager.sessions = append(manager.sessions, session)
+	return session, nil
+}
+
+// GetSession returns the session with the given name, or nil if it is not in the pool.
+func (manager *SessionManager) GetSession(name string) *Session {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+	return manager.findSession(name)
+}
+
+// DefaultSession returns the default session if it is part of the pool, else the first session.
+func (manager *SessionManager) DefaultSession() *Session {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+	if session := manager.findSession(DefaultSessionName); session != nil {
+		return session
+	}
+	if len(manager.sessions) == 0 {
+		return nil
+	}
+	return manager.sessions[0]
+}
+
+// GetNextSession returns the next session in rotation that is enabled, authorized and not
+// waiting out a flood wait, and records a request for it. It returns nil if there is none.
+func (manager *SessionManager) GetNextSession() *Session {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+	now := time.Now()
+	for i := 0; i < len(manager.sessions); i++ {
+		idx := (manager.index + i) % len(manager.sessions)
+		session := manager.sessions[idx]
+		if session.Disabled || session.Unauthorized || now.Before(session.FloodWaitUntil) {
+			continue
+		}
+		manager.index = (idx + 1) % len(manager.sessions)
+		session.Usage.Requests++
+		session.Usage.LastUsed = now
+		manager.saveState()
+		return session
+	}
+	return nil
+}
+
+// MarkFloodWait records that Telegram asked the session to wait before its next request. The
+// session is skipped by GetNextSession until then.
+func (manager *SessionManager) MarkFloodWait(session *Session, wait time.Duration) {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+	if until := time.Now().Add(wait); until.After(session.FloodWaitUntil) {
+		session.FloodWaitUntil = until
+	}
+	session.Usage.FloodWaits++
+	manager.saveState()
+}
+
+// MarkUnauthorized records that the session is not signed in. It is skipped by GetNextSession
+// until it is authenticated.
+func (manager *SessionManager) MarkUnauthorized(session *Session) {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+	session.Unauthorized = true
+}
+
+// MarkAuthenticated returns a session that was not signed in to the rotation.
+func (manager *SessionManager) MarkAuthenticated(session *Session) {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+	session.Unauthorized = false
+}
+
+// RecordError increments the error counter of the session.
+func (manager *SessionManager) RecordError(session *Session) {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+	session.Usage.Errors++
+	manager.saveState()
+}
+
+// SetSessionDisabled enables or disables the session with the given name. Disabled sessions are
+// skipped by GetNextSession.
+func (manager *SessionManager) SetSessionDisabled(name string, disabled bool) error {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+	session := manager.findSession(name)
+	if session == nil {
+		return ErrSessionNotFound
+	}
+	session.Disabled = disabled
+	manager.saveState()
+	return nil
+}
+
+// GetSessions returns a snapshot of every session in the pool along with its status.
+func (manager *SessionManager) GetSessions() []SessionInfo {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+	now := time.Now()
+	infos := make([]SessionInfo, 0, len(manager.sessions))
+	for _, session := range manager.sessions {
+		info := SessionInfo{Name: session.Name, Status: SessionStatusActive, Usage: session.Usage}
+		if now.Before(session.FloodWaitUntil) {
+			until := session.FloodWaitUntil
+			info.FloodWaitUntil = &until
+		}
+		switch {
+		case session.Disabled:
+			info.Status = SessionStatusDisabled
+		case info.FloodWaitUntil != nil:
+			info.Status = SessionStatusFloodWait
+		case session.Unauthorized:
+			info.Status = SessionStatusUnauthorized
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// authorizedClient returns the next session in rotation with its connected client. Sessions that
+// turn out not to be signed in are marked and skipped.
+func (manager *SessionManager) authorizedClient(ctx context.Context) (*Session, *telegram.Client, error) {
+	for {
+		session := manager.GetNextSession()
+		if session == nil {
+			return nil, nil, ErrNoSessionAvailable
+		}
+		client, _, err := session.conn.get(ctx)
+		if err != nil {
+			manager.RecordError(session)
+			return nil, nil, err
+		}
+		status, err := client.Auth().Status(ctx)
+		if err != nil {
+			manager.RecordError(session)
+			return nil, nil, err
+		}
+		if !status.Authorized {
+			logrus.Warnf("[-] Telegram session %s is not authorized, skipping it", session.Name)
+			manager.MarkUnauthorized(session)
+			continue
+		}
+		return session, client, nil
+	}
+}
+
+// Stop closes the connections of every session.
+func (manager *SessionManager) Stop() error {
+	manager.mutex.Lock()
+	sessions := append([]*Session(nil), manager.sessions...)
+	manager.mutex.Unlock()
+
+	var errs []error
+	for _, session := range sessions {
+		if err := session.conn.stop(); err != nil {
+			errs = append(errs, fmt.Errorf("session %s: %w", session.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// LoadState restores the persisted session state from path and makes the manager write every
+// subsequent change back to it. A missing file is not an error.
+func (manager *SessionManager) LoadState(path string) error {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+	manager.statePath = path
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("error reading session state: %v", err)
+	}
+	var states map[string]sessionState
+	if err = json.Unmarshal(data, &states); err != nil {
+		return fmt.Errorf("error unmarshaling session state: %v", err)
+	}
+	for _, session := range manager.sessions {
+		state, ok := states[session.Name]
+		if !ok {
+			continue
+		}
+		session.Disabled = state.Disabled
+		session.FloodWaitUntil = state.FloodWaitUntil
+		session.Usage = state.Usage
+	}
+	return nil
+}
+
+// saveState writes the session state to the state file. The caller must hold the mutex.
+func (manager *SessionManager) saveState() {
+	if manager.statePath == "" {
+		return
+	}
+	states := make(map[string]sessionState, len(manager.sessions))
+	for _, session := range manager.sessions {
+		states[session.Name] = sessionState{
+			Disabled:       session.Disabled,
+			FloodWaitUntil: session.FloodWaitUntil,
+			Usage:          session.Usage,
+		}
+	}
+	data, err := json.Marshal(states)
+	if err != nil {
+		logrus.Errorf("error marshaling session state: %v", err)
+		return
+	}
+	if err = os.MkdirAll(filepath.Dir(manager.statePath), 0700); err != nil {
+		logrus.Errorf("error saving session state: %v", err)
+		return
+	}
+	if err = os.WriteFile(manager.statePath, data, 0600); err != nil {
+		logrus.Errorf("error saving session state: %v", err)
+	}
+}
+
+func (manager *SessionManager) findSession(name string) *Session {
+	for _, session := range manager.sessions {
+		if session.Name == name {
+			return session
+		}
+	}
+	return nil
+}
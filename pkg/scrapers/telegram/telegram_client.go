@@ -2,167 +2,256 @@ package telegram
 
 import (
 	"context"
-	"crypto/rand"
 	"errors"
+	"fmt"
 	"log"
 	"os"
 	"path/filepath"
 	"strconv"
-	"strings"
+	"sync"
 
-	"github.com/gotd/contrib/bg"
-	"github.com/gotd/td/session"
 	"github.com/gotd/td/telegram"
 	"github.com/gotd/td/telegram/auth"
 	"github.com/gotd/td/tg"
 	"github.com/sirupsen/logrus"
+
+	"github.com/Gzgod/masa-oracle/pkg/masacrypto"
 )
 
 var (
-	client     *telegram.Client
-	appID      int    // Your actual app ID
-	appHash    string // Your actual app hash
-	sessionDir = filepath.Join(os.Getenv("HOME"), ".telegram-sessions")
+	// ErrMissingCredentials is returned when the Telegram app credentials are not configured.
+	ErrMissingCredentials = errors.New("TELEGRAM_APP_ID and TELEGRAM_APP_HASH must be set")
+	// ErrNotAuthorized is returned when the session has not been authenticated yet.
+	ErrNotAuthorized = errors.New("telegram session is not authorized, authenticate the node first")
 )
 
-func GetClient() (*telegram.Client, error) {
-	var err error
+var sessionDir = filepath.Join(os.Getenv("HOME"), ".telegram-sessions")
 
-	appID, err = strconv.Atoi(os.Getenv("TELEGRAM_APP_ID"))
-	if err != nil {
-		logrus.Fatalf("Invalid TELEGRAM_APP_ID: %v", err)
+// connection is the long-lived Telegram client of a session. It is connected on first use, kept
+// open in the background and reconnected if it has stopped.
+type connection struct {
+	path string // Session file
+
+	mu     sync.Mutex
+	client *telegram.Client
+	cancel context.CancelFunc
+	done   chan struct{}
+	err    error
+
+	updatesMu sync.RWMutex
+	updates   telegram.UpdateHandler
+}
+
+// GetClient returns the Telegram client of the named session, connecting it if it is not
+// connected yet. The default session is used if name is empty. The client stays connected until
+// Stop is called.
+func GetClient(ctx context.Context, name string) (*telegram.Client, error) {
+	if name == "" {
+		name = DefaultSessionName
 	}
-	appHash = os.Getenv("TELEGRAM_APP_HASH")
-	if appHash == "" {
-		logrus.Fatal("TELEGRAM_APP_HASH must be set")
+	session := GetSessionManager().GetSession(name)
+	if session == nil {
+		return nil, ErrSessionNotFound
 	}
+	client, _, err := session.conn.get(ctx)
+	return client, err
+}
 
-	// Ensure the session directory exists
-	if err = os.MkdirAll(sessionDir, 0700); err != nil {
-		logrus.Error(err)
-		return nil, err // Added return statement to handle the error
+// Stop closes the connections of the node's Telegram sessions. The sessions are kept, so the
+// next request connects again without a new authentication.
+func Stop() error {
+	return GetSessionManager().Stop()
+}
+
+// get returns the connected client and a channel that is closed when its connection stops.
+func (c *connection) get(ctx context.Context) (*telegram.Client, <-chan struct{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
+	if c.client != nil {
+		select {
+		case <-c.done:
+			logrus.Warnf("[-] Telegram connection stopped, reconnecting: %v", c.err)
+			c.client = nil
+		default:
+			return c.client, c.done, nil
+		}
 	}
 
-	// Create a session storage
-	storage := &session.FileStorage{
-		Path: filepath.Join(sessionDir, "session.json"),
+	client, err := newClient(c.path, c)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	// Create a random seed for the client
-	seed := make([]byte, 32)
-	if _, err = rand.Read(seed); err != nil {
-		logrus.Error(err)
-		return nil, err // Added return statement to handle the error
+	runCtx, cancel := context.WithCancel(context.Background())
+	ready := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		err := client.Run(runCtx, func(ctx context.Context) error {
+			close(ready)
+			<-ctx.Done()
+			return ctx.Err()
+		})
+		if errors.Is(err, context.Canceled) {
+			err = nil
+		}
+		c.err = err
+	}()
 
+	select {
+	case <-ready:
+	case <-done:
+		cancel()
+		return nil, nil, fmt.Errorf("unable to connect to Telegram: %w", c.err)
+	case <-ctx.Done():
+		cancel()
+		<-done
+		return nil, nil, ctx.Err()
 	}
+	c.client, c.cancel, c.done = client, cancel, done
+	return client, done, nil
+}
 
-	// Initialize the Telegram client
-	client = telegram.NewClient(appID, appHash, telegram.Options{
-		SessionStorage: storage,
-	})
+func (c *connection) stop() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	return client, err
+	if c.client == nil {
+		return nil
+	}
+	c.cancel()
+	<-c.done
+	c.client = nil
+	return c.err
+}
+
+// Handle passes the updates received by the client to the subscribed handler, if any.
+func (c *connection) Handle(ctx context.Context, u tg.UpdatesClass) error {
+	c.updatesMu.RLock()
+	handler := c.updates
+	c.updatesMu.RUnlock()
+	if handler == nil {
+		return nil
+	}
+	return handler.Handle(ctx, u)
 }
 
-// StartAuthentication sends the phone number to Telegram and requests a code.
-func StartAuthentication(ctx context.Context, phoneNumber string) (string, error) {
-	// Initialize the Telegram client (if not already initialized)
-	client, err := GetClient()
+// subscribe sets the handler of the updates received by the client. Only one handler can be
+// subscribed at a time.
+func (c *connection) subscribe(handler telegram.UpdateHandler) error {
+	c.updatesMu.Lock()
+	defer c.updatesMu.Unlock()
+	if c.updates != nil && handler != nil && c.updates != handler {
+		return errors.New("telegram updates are already subscribed")
+	}
+	c.updates = handler
+	return nil
+}
+
+// newClient creates a Telegram client from the app credentials in the environment, with the
+// session stored encrypted at path. Updates are passed to updates.
+func newClient(path string, updates telegram.UpdateHandler) (*telegram.Client, error) {
+	appHash := os.Getenv("TELEGRAM_APP_HASH")
+	if os.Getenv("TELEGRAM_APP_ID") == "" || appHash == "" {
+		return nil, ErrMissingCredentials
+	}
+	appID, err := strconv.Atoi(os.Getenv("TELEGRAM_APP_ID"))
 	if err != nil {
-		logrus.Errorf("Failed to initialize Telegram client: %v", err)
-		return "", err
+		return nil, fmt.Errorf("invalid TELEGRAM_APP_ID: %w", err)
 	}
 
-	var phoneCodeHash string
+	// Ensure the session directory exists
+	if err = masacrypto.MkdirPrivate(filepath.Dir(path)); err != nil {
+		return nil, err
+	}
 
-	// Use client.Run to start the client and execute the SendCode method
-	err = client.Run(ctx, func(ctx context.Context) error {
-		// Call the SendCode method of the client to send the code to the user's Telegram app
-		sentCode, err := client.Auth().SendCode(ctx, phoneNumber, auth.SendCodeOptions{
-			AllowFlashCall: true,
-			CurrentNumber:  true,
-		})
-		if err != nil {
-			log.Printf("Error sending code: %v", err)
-			return err
-		}
+	// Create a session storage
+	storage := &encryptedSessionStorage{
+		path: path,
+	}
 
-		log.Printf("Code sent successfully to: %s", phoneNumber)
+	return telegram.NewClient(appID, appHash, telegram.Options{
+		SessionStorage: storage,
+		UpdateHandler:  updates,
+	}), nil
+}
 
-		// Extract the phoneCodeHash from the sentCode object
-		switch code := sentCode.(type) {
-		case *tg.AuthSentCode:
-			phoneCodeHash = code.PhoneCodeHash
-		default:
-			return errors.New("unexpected type of AuthSentCode")
-		}
+// StartAuthentication sends the phone number to Telegram and requests a code for the named
+// session, which is added to the pool if it is new. The default session is used if name is empty.
+func StartAuthentication(ctx context.Context, name, phoneNumber string) (string, error) {
+	if name == "" {
+		name = DefaultSessionName
+	}
+	session, err := GetSessionManager().AddSession(name)
+	if err != nil {
+		return "", err
+	}
+	client, _, err := session.conn.get(ctx)
+	if err != nil {
+		logrus.Errorf("Failed to initialize Telegram client: %v", err)
+		return "", err
+	}
 
-		return nil
+	// Call the SendCode method of the client to send the code to the user's Telegram app
+	sentCode, err := client.Auth().SendCode(ctx, phoneNumber, auth.SendCodeOptions{
+		AllowFlashCall: true,
+		CurrentNumber:  true,
 	})
-
 	if err != nil {
-		log.Printf("Failed to run client or send code: %v", err)
+		log.Printf("Error sending code: %v", err)
 		return "", err
 	}
 
+	log.Printf("Code sent successfully to: %s", phoneNumber)
+
+	// Extract the phoneCodeHash from the sentCode object
+	code, ok := sentCode.(*tg.AuthSentCode)
+	if !ok {
+		return "", errors.New("unexpected type of AuthSentCode")
+	}
+
 	// Return the phoneCodeHash to be used in the next step
 	log.Printf("Authentication process started successfully for: %s", phoneNumber)
-	return phoneCodeHash, nil
+	return code.PhoneCodeHash, nil
 }
 
-// CompleteAuthentication uses the provided code to authenticate with Telegram.
-// CompleteAuthentication uses the provided code to authenticate with Telegram.
-func CompleteAuthentication(ctx context.Context, phoneNumber, code, phoneCodeHash, password string) (*tg.AuthAuthorization, error) {
-	// Initialize the Telegram client (if not already initialized)
-	client, err := GetClient()
+// CompleteAuthentication uses the provided code to authenticate the named session, on which
+// StartAuthentication was called. The session is stored, so the node stays signed in across
+// restarts. If the account has a password and none is given, auth.ErrPasswordAuthNeeded is
+// returned.
+func CompleteAuthentication(ctx context.Context, name, phoneNumber, code, phoneCodeHash, password string) (*tg.AuthAuthorization, error) {
+	if name == "" {
+		name = DefaultSessionName
+	}
+	manager := GetSessionManager()
+	session := manager.GetSession(name)
+	if session == nil {
+		return nil, ErrSessionNotFound
+	}
+	client, _, err := session.conn.get(ctx)
 	if err != nil {
 		logrus.Printf("Failed to initialize Telegram client: %v", err)
 		return nil, err
 	}
 
-	// Define a variable to hold the authentication result
-	var authResult *tg.AuthAuthorization
-	// Use client.Run to start the client and execute the SignIn method
-	err = client.Run(ctx, func(ctx context.Context) error {
-		// Use the provided code and phoneCodeHash to authenticate
-		auth, err := client.Auth().SignIn(ctx, phoneNumber, code, phoneCodeHash)
-
-		if err != nil {
-			if strings.Contains(err.Error(), "2FA required") {
-				auth, err = client.Auth().Password(ctx, password)
-				if err != nil {
-					log.Printf("Error during 2FA SignIn: %v", err)
-					return err
-				}
-			} else {
-				log.Printf("Error during SignIn: %v", err)
-				return err
-			}
+	// Use the provided code and phoneCodeHash to authenticate
+	authResult, err := client.Auth().SignIn(ctx, phoneNumber, code, phoneCodeHash)
+	if errors.Is(err, auth.ErrPasswordAuthNeeded) {
+		if password == "" {
+			return nil, err
 		}
-
-		// At this point, authentication was successful, and you have the user's Telegram auth data.
-		authResult = auth
-		stop, err := bg.Connect(client)
+		authResult, err = client.Auth().Password(ctx, password)
 		if err != nil {
-			return err
-		}
-		defer func() { _ = stop() }()
-
-		// Now you can use client.
-		if _, err := client.Auth().Status(ctx); err != nil {
-			return err
+			log.Printf("Error during 2FA SignIn: %v", err)
+			return nil, err
 		}
-
-		return nil
-	})
-
-	if err != nil {
-		log.Printf("Failed to run client or sign in: %v", err)
+	} else if err != nil {
+		log.Printf("Error during SignIn: %v", err)
 		return nil, err
 	}
 
-	// You can now create a session for the user or perform other post-authentication tasks.
-	log.Printf("Authentication successful for: %s", phoneNumber)
+	manager.MarkAuthenticated(session)
+	log.Printf("Authentication successful for: %s (session %s)", phoneNumber, name)
 	return authResult, nil
 }
@@ -0,0 +1,172 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/gotd/td/telegram/updates"
+)
+
+// UpdateStateFile keeps the update state of the accounts and the access hashes of their channels
+// in a JSON file. With the state kept across restarts, the updates engine fetches the messages
+// that were missed while the node was offline.
+type UpdateStateFile struct {
+	path string
+	mu   sync.Mutex
+	data updateStateData
+}
+
+type updateStateData struct {
+	States       map[int64]updates.State   `json:"states"`
+	Channels     map[int64]map[int64]int   `json:"channels"`     // Account to channel to pts
+	AccessHashes map[int64]map[int64]int64 `json:"accessHashes"` // Account to channel to access hash
+}
+
+var (
+	_ updates.StateStorage        = (*UpdateStateFile)(nil)
+	_ updates.ChannelAccessHasher = (*UpdateStateFile)(nil)
+)
+
+// NewUpdateStateFile loads the update state from path. The file is created on the first change
+// if it does not exist.
+func NewUpdateStateFile(path string) (*UpdateStateFile, error) {
+	s := &UpdateStateFile{path: path}
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &s.data); err != nil {
+			return nil, err
+		}
+	}
+	if s.data.States == nil {
+		s.data.States = map[int64]updates.State{}
+	}
+	if s.data.Channels == nil {
+		s.data.Channels = map[int64]map[int64]int{}
+	}
+	if s.data.AccessHashes == nil {
+		s.data.AccessHashes = map[int64]map[int64]int64{}
+	}
+	return s, nil
+}
+
+// save writes the state to the file. The caller holds the lock.
+func (s *UpdateStateFile) save() error {
+	data, err := json.Marshal(s.data)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+	// Write to a temporary file first so that a crash cannot leave a truncated state behind.
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// update applies f to the state of userID and saves it.
+func (s *UpdateStateFile) update(userID int64, f func(state *updates.State)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state := s.data.States[userID]
+	f(&state)
+	s.data.States[userID] = state
+	return s.save()
+}
+
+func (s *UpdateStateFile) GetState(_ context.Context, userID int64) (updates.State, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, found := s.data.States[userID]
+	return state, found, nil
+}
+
+func (s *UpdateStateFile) SetState(_ context.Context, userID int64, state updates.State) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.States[userID] = state
+	s.data.Channels[userID] = map[int64]int{}
+	return s.save()
+}
+
+func (s *UpdateStateFile) SetPts(_ context.Context, userID int64, pts int) error {
+	return s.update(userID, func(state *updates.State) { state.Pts = pts })
+}
+
+func (s *UpdateStateFile) SetQts(_ context.Context, userID int64, qts int) error {
+	return s.update(userID, func(state *updates.State) { state.Qts = qts })
+}
+
+func (s *UpdateStateFile) SetDate(_ context.Context, userID int64, date int) error {
+	return s.update(userID, func(state *updates.State) { state.Date = date })
+}
+
+func (s *UpdateStateFile) SetSeq(_ context.Context, userID int64, seq int) error {
+	return s.update(userID, func(state *updates.State) { state.Seq = seq })
+}
+
+func (s *UpdateStateFile) SetDateSeq(_ context.Context, userID int64, date, seq int) error {
+	return s.update(userID, func(state *updates.State) { state.Date, state.Seq = date, seq })
+}
+
+func (s *UpdateStateFile) GetChannelPts(_ context.Context, userID, channelID int64) (int, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pts, found := s.data.Channels[userID][channelID]
+	return pts, found, nil
+}
+
+func (s *UpdateStateFile) SetChannelPts(_ context.Context, userID, channelID int64, pts int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.data.Channels[userID] == nil {
+		s.data.Channels[userID] = map[int64]int{}
+	}
+	s.data.Channels[userID][channelID] = pts
+	return s.save()
+}
+
+func (s *UpdateStateFile) ForEachChannels(ctx context.Context, userID int64, f func(ctx context.Context, channelID int64, pts int) error) error {
+	s.mu.Lock()
+	channels := make(map[int64]int, len(s.data.Channels[userID]))
+	for channelID, pts := range s.data.Channels[userID] {
+		channels[channelID] = pts
+	}
+	s.mu.Unlock()
+
+	for channelID, pts := range channels {
+		if err := f(ctx, channelID, pts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *UpdateStateFile) SetChannelAccessHash(_ context.Context, userID, channelID, accessHash int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.data.AccessHashes[userID] == nil {
+		s.data.AccessHashes[userID] = map[int64]int64{}
+	}
+	if s.data.AccessHashes[userID][channelID] == accessHash {
+		return nil
+	}
+	s.data.AccessHashes[userID][channelID] = accessHash
+	return s.save()
+}
+
+func (s *UpdateStateFile) GetChannelAccessHash(_ context.Context, userID, channelID int64) (int64, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	accessHash, found := s.data.AccessHashes[userID][channelID]
+	return accessHash, found, nil
+}
@@ -0,0 +1,239 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/gotd/td/telegram/updates"
+	"github.com/gotd/td/tg"
+	"github.com/sirupsen/logrus"
+)
+
+// seenMessagesSize is the number of recent messages remembered to drop the ones delivered twice,
+// for example when the updates of a gap are fetched again after a reconnect.
+const seenMessagesSize = 10000
+
+// UpdatesConfig selects the chats whose new messages are received. When no chat is selected, the
+// messages of every channel and group the account has joined are received. Private chats are
+// never received.
+type UpdatesConfig struct {
+	// Channels are the usernames of channels and supergroups.
+	Channels []string
+	// Groups are the IDs of basic groups.
+	Groups []int64
+	// Session is the name of the session that receives the updates, the default session if empty.
+	Session string
+	// StateFile keeps the update state across restarts, so that messages sent while the node
+	// was offline are received when it starts. The state is kept in memory if empty.
+	StateFile string
+}
+
+// MessageHandler receives the new messages of an update subscription.
+type MessageHandler func(Message)
+
+// Updates receives new messages as they are posted, through the updates engine of the node's
+// Telegram client. The engine detects gaps in the update sequence, such as after a disconnect,
+// and fetches the missed messages.
+type Updates struct {
+	cfg     UpdatesConfig
+	handler MessageHandler
+	manager *updates.Manager
+
+	mu       sync.Mutex
+	channels map[int64]bool
+	groups   map[int64]bool
+	seen     map[messageKey]bool
+	order    []messageKey
+}
+
+type messageKey struct {
+	chatID int64
+	id     int
+}
+
+// NewUpdates creates an update subscription that passes the selected messages to handler.
+func NewUpdates(cfg UpdatesConfig, handler MessageHandler) (*Updates, error) {
+	u := &Updates{
+		cfg:      cfg,
+		handler:  handler,
+		channels: map[int64]bool{},
+		groups:   map[int64]bool{},
+		seen:     map[messageKey]bool{},
+	}
+	for _, id := range cfg.Groups {
+		u.groups[id] = true
+	}
+
+	dispatcher := tg.NewUpdateDispatcher()
+	dispatcher.OnNewMessage(func(ctx context.Context, e tg.Entities, update *tg.UpdateNewMessage) error {
+		u.receive(e, update.Message)
+		return nil
+	})
+	dispatcher.OnNewChannelMessage(func(ctx context.Context, e tg.Entities, update *tg.UpdateNewChannelMessage) error {
+		u.receive(e, update.Message)
+		return nil
+	})
+
+	managerConfig := updates.Config{Handler: dispatcher}
+	if cfg.StateFile != "" {
+		state, err := NewUpdateStateFile(cfg.StateFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load telegram update state: %w", err)
+		}
+		managerConfig.Storage = state
+		managerConfig.AccessHasher = state
+	}
+	managerConfig.OnChannelTooLong = func(channelID int64) {
+		logrus.Warnf("[-] Too many missed Telegram messages in channel %d, some are skipped", channelID)
+	}
+	u.manager = updates.New(managerConfig)
+	return u, nil
+}
+
+// Handle passes updates to the updates engine.
+func (u *Updates) Handle(ctx context.Context, update tg.UpdatesClass) error {
+	return u.manager.Handle(ctx, update)
+}
+
+// Run receives updates until ctx is done. The client must be authenticated. When the connection
+// stops, Run reconnects and the engine catches up with the updates missed in the meantime.
+func (u *Updates) Run(ctx context.Context) error {
+	session := GetSessionManager().DefaultSession()
+	if u.cfg.Session != "" {
+		session = GetSessionManager().GetSession(u.cfg.Session)
+	}
+	if session == nil {
+		return ErrSessionNotFound
+	}
+	if err := session.conn.subscribe(u); err != nil {
+		return err
+	}
+	defer func() { _ = session.conn.subscribe(nil) }()
+
+	retry := backoff.NewExponentialBackOff()
+	retry.MaxElapsedTime = 0
+	retry.MaxInterval = 2 * time.Minute
+	for {
+		err := u.runOnce(ctx, session.conn, retry)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if errors.Is(err, ErrNotAuthorized) || errors.Is(err, ErrMissingCredentials) {
+			return err
+		}
+		wait := retry.NextBackOff()
+		logrus.Warnf("[-] Telegram updates stopped: %v, reconnecting in %s", err, wait)
+		if err := sleepContext(ctx, wait); err != nil {
+			return nil
+		}
+	}
+}
+
+// runOnce runs the updates engine until the connection stops.
+func (u *Updates) runOnce(ctx context.Context, conn *connection, retry backoff.BackOff) error {
+	client, done, err := conn.get(ctx)
+	if err != nil {
+		return err
+	}
+	self, err := client.Self(ctx)
+	if err != nil {
+		if status, statusErr := client.Auth().Status(ctx); statusErr == nil && !status.Authorized {
+			return ErrNotAuthorized
+		}
+		return err
+	}
+	if err := u.resolveChannels(ctx, client.API()); err != nil {
+		return err
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-done:
+			cancel()
+		case <-runCtx.Done():
+		}
+	}()
+	defer u.manager.Reset()
+
+	return u.manager.Run(runCtx, client.API(), self.ID, updates.AuthOptions{
+		IsBot: self.Bot,
+		OnStart: func(ctx context.Context) {
+			retry.Reset()
+			logrus.Info("[+] Receiving Telegram updates")
+		},
+	})
+}
+
+// resolveChannels looks up the IDs of the configured channels.
+func (u *Updates) resolveChannels(ctx context.Context, api *tg.Client) error {
+	for _, username := range u.cfg.Channels {
+		peer, err := resolvePeer(ctx, api, Target{Username: username})
+		if err != nil {
+			return fmt.Errorf("unable to resolve %s: %w", username, err)
+		}
+		u.mu.Lock()
+		u.channels[peer.(*tg.InputPeerChannel).ChannelID] = true
+		u.mu.Unlock()
+	}
+	return nil
+}
+
+// receive passes a new message to the handler if its chat is selected and it was not seen before.
+func (u *Updates) receive(e tg.Entities, m tg.MessageClass) {
+	chats := make([]tg.ChatClass, 0, len(e.Chats)+len(e.Channels))
+	for _, chat := range e.Chats {
+		chats = append(chats, chat)
+	}
+	for _, channel := range e.Channels {
+		chats = append(chats, channel)
+	}
+	users := make([]tg.UserClass, 0, len(e.Users))
+	for _, user := range e.Users {
+		users = append(users, user)
+	}
+	message, ok := newEntities(users, chats).convertMessage(m)
+	if !ok || message.Chat == nil || !u.selected(message.Chat) {
+		return
+	}
+	if u.markSeen(messageKey{chatID: message.Chat.ID, id: message.ID}) {
+		return
+	}
+	u.handler(message)
+}
+
+func (u *Updates) selected(chat *Peer) bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if chat.Type == PeerUser {
+		return false
+	}
+	if len(u.cfg.Channels) == 0 && len(u.cfg.Groups) == 0 {
+		return true
+	}
+	if chat.Type == PeerGroup {
+		return u.groups[chat.ID]
+	}
+	return u.channels[chat.ID]
+}
+
+// markSeen remembers a message and reports whether it was seen before.
+func (u *Updates) markSeen(key messageKey) bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.seen[key] {
+		return true
+	}
+	u.seen[key] = true
+	u.order = append(u.order, key)
+	if len(u.order) > seenMessagesSize {
+		delete(u.seen, u.order[0])
+		u.order = u.order[1:]
+	}
+	return false
+}
@@ -1,21 +1,85 @@
 package twitter
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
 	"sync"
 	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// stateSaveDelay is how long usage counter updates are batched before the state file is written.
+// Rate limits, authentication results and disabled accounts are written immediately.
+const stateSaveDelay = 10 * time.Second
+
+// ErrAccountNotFound is returned when an operation targets a username that is not part of the account pool.
+var ErrAccountNotFound = errors.New("twitter account not found")
+
+// AccountStatus describes the current state of a Twitter account in the pool.
+type AccountStatus string
+
+const (
+	AccountStatusActive      AccountStatus = "active"
+	AccountStatusRateLimited AccountStatus = "rate-limited"
+	AccountStatusAuthFailing AccountStatus = "auth-failing"
+	AccountStatusDisabled    AccountStatus = "disabled"
 )
 
+// AccountUsage holds the running usage counters of a single account.
+type AccountUsage struct {
+	Requests     int64     `json:"requests"`
+	Errors       int64     `json:"errors"`
+	RateLimits   int64     `json:"rateLimits"`
+	AuthFailures int64     `json:"authFailures"`
+	LastUsed     time.Time `json:"lastUsed,omitempty"`
+}
+
 type TwitterAccount struct {
-	Username         string
-	Password         string
-	TwoFACode        string
-	RateLimitedUntil time.Time
+	Username      string
+	Password      string
+	TwoFACode     string
+	Disabled      bool
+	LastAuthError string
+	Usage         AccountUsage
+
+	buckets map[EndpointType]*tokenBucket
+}
+
+// EndpointBudget is the request budget of an account for a single endpoint type.
+type EndpointBudget struct {
+	Remaining    int        `json:"remaining"`
+	Capacity     int        `json:"capacity"`
+	LimitedUntil *time.Time `json:"limitedUntil,omitempty"`
+}
+
+// AccountInfo is a read-only snapshot of an account, safe to return from the API.
+type AccountInfo struct {
+	Username         string                          `json:"username"`
+	Status           AccountStatus                   `json:"status"`
+	RateLimitedUntil *time.Time                      `json:"rateLimitedUntil,omitempty"`
+	LastAuthError    string                          `json:"lastAuthError,omitempty"`
+	Usage            AccountUsage                    `json:"usage"`
+	Budget           map[EndpointType]EndpointBudget `json:"budget"`
+}
+
+// accountState is the part of a TwitterAccount that is persisted across restarts.
+type accountState struct {
+	Disabled      bool                          `json:"disabled"`
+	LastAuthError string                        `json:"lastAuthError,omitempty"`
+	Usage         AccountUsage                  `json:"usage"`
+	Buckets       map[EndpointType]*tokenBucket `json:"buckets,omitempty"`
 }
 
 type TwitterAccountManager struct {
-	accounts []*TwitterAccount
-	index    int
-	mutex    sync.Mutex
+	accounts  []*TwitterAccount
+	index     int
+	mutex     sync.Mutex
+	statePath string
+	saveTimer *time.Timer // Pending write of the usage counters, nil when there is none
 }
 
 func NewTwitterAccountManager(accounts []*TwitterAccount) *TwitterAccountManager {
@@ -25,21 +89,276 @@ func NewTwitterAccountManager(accounts []*TwitterAccount) *TwitterAccountManager
 	}
 }
 
-func (manager *TwitterAccountManager) GetNextAccount() *TwitterAccount {
+// GetNextAccount returns the enabled account with the most budget left for the given endpoint
+// type and consumes one request from it, so that load is spread across the pool before any
+// account runs into Twitter's limit. It returns nil if every account is out of budget.
+func (manager *TwitterAccountManager) GetNextAccount(endpoint EndpointType) *TwitterAccount {
 	manager.mutex.Lock()
 	defer manager.mutex.Unlock()
+	now := time.Now()
+	var best *TwitterAccount
+	bestIndex, bestTokens := 0, 0.0
+	// Start at the rotation index so that accounts with equal budgets take turns.
 	for i := 0; i < len(manager.accounts); i++ {
-		account := manager.accounts[manager.index]
-		manager.index = (manager.index + 1) % len(manager.accounts)
-		if time.Now().After(account.RateLimitedUntil) {
-			return account
+		idx := (manager.index + i) % len(manager.accounts)
+		account := manager.accounts[idx]
+		if account.Disabled {
+			continue
+		}
+		tokens := manager.bucket(account, endpoint, now).tokensAt(now)
+		if tokens >= 1 && tokens > bestTokens {
+			best, bestIndex, bestTokens = account, idx, tokens
+		}
+	}
+	if best == nil {
+		return nil
+	}
+	manager.index = (bestIndex + 1) % len(manager.accounts)
+	manager.bucket(best, endpoint, now).take(now)
+	manager.scheduleSave()
+	return best
+}
+
+// MarkAccountRateLimited records a 429 for the account on the given endpoint type. resetAt is the
+// time Twitter said the limit resets, or the zero time if it gave no hint.
+func (manager *TwitterAccountManager) MarkAccountRateLimited(account *TwitterAccount, endpoint EndpointType, resetAt time.Time) {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+	manager.bucket(account, endpoint, time.Now()).rateLimited(time.Now(), resetAt)
+	account.Usage.RateLimits++
+	manager.saveState()
+}
+
+// MarkAccountSucceeded records a successful request so the account's budget for the endpoint
+// type can grow back towards its configured limit.
+func (manager *TwitterAccountManager) MarkAccountSucceeded(account *TwitterAccount, endpoint EndpointType) {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+	manager.bucket(account, endpoint, time.Now()).succeeded(time.Now())
+	manager.scheduleSave()
+}
+
+// RemainingBudget returns the number of requests the enabled accounts of the pool can still make
+// per endpoint type without hitting a rate limit.
+func (manager *TwitterAccountManager) RemainingBudget() map[EndpointType]int {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+	now := time.Now()
+	budget := make(map[EndpointType]int, len(DefaultBucketConfigs))
+	for endpoint := range DefaultBucketConfigs {
+		budget[endpoint] = 0
+		for _, account := range manager.accounts {
+			if !account.Disabled {
+				budget[endpoint] += manager.bucket(account, endpoint, now).remaining(now)
+			}
 		}
 	}
+	return budget
+}
+
+// MarkAccountAuthFailed records a failed login attempt for the account.
+func (manager *TwitterAccountManager) MarkAccountAuthFailed(account *TwitterAccount, err error) {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+	account.LastAuthError = err.Error()
+	account.Usage.AuthFailures++
+	manager.saveState()
+}
+
+// MarkAccountAuthenticated clears any previous authentication error for the account.
+func (manager *TwitterAccountManager) MarkAccountAuthenticated(account *TwitterAccount) {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+	if account.LastAuthError == "" {
+		return
+	}
+	account.LastAuthError = ""
+	manager.saveState()
+}
+
+// RecordRequest increments the request counter of the account and updates its last used time.
+func (manager *TwitterAccountManager) RecordRequest(account *TwitterAccount) {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+	account.Usage.Requests++
+	account.Usage.LastUsed = time.Now()
+	manager.scheduleSave()
+}
+
+// RecordError increments the error counter of the account.
+func (manager *TwitterAccountManager) RecordError(account *TwitterAccount) {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+	account.Usage.Errors++
+	manager.scheduleSave()
+}
+
+// GetAccount returns the account with the given username, or nil if it is not in the pool.
+func (manager *TwitterAccountManager) GetAccount(username string) *TwitterAccount {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+	return manager.findAccount(username)
+}
+
+// SetAccountDisabled enables or disables the account with the given username.
+// Disabled accounts are skipped by GetNextAccount.
+func (manager *TwitterAccountManager) SetAccountDisabled(username string, disabled bool) error {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+	account := manager.findAccount(username)
+	if account == nil {
+		return ErrAccountNotFound
+	}
+	account.Disabled = disabled
+	manager.saveState()
 	return nil
 }
 
-func (manager *TwitterAccountManager) MarkAccountRateLimited(account *TwitterAccount) {
+// GetAccounts returns a snapshot of every account in the pool along with its status.
+func (manager *TwitterAccountManager) GetAccounts() []AccountInfo {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+	now := time.Now()
+	infos := make([]AccountInfo, 0, len(manager.accounts))
+	for _, account := range manager.accounts {
+		info := AccountInfo{
+			Username:      account.Username,
+			Status:        AccountStatusActive,
+			LastAuthError: account.LastAuthError,
+			Usage:         account.Usage,
+			Budget:        make(map[EndpointType]EndpointBudget, len(DefaultBucketConfigs)),
+		}
+		for endpoint := range DefaultBucketConfigs {
+			bucket := manager.bucket(account, endpoint, now)
+			budget := EndpointBudget{Remaining: bucket.remaining(now), Capacity: int(bucket.Capacity)}
+			if now.Before(bucket.BlockedUntil) {
+				until := bucket.BlockedUntil
+				budget.LimitedUntil = &until
+				if info.RateLimitedUntil == nil || until.After(*info.RateLimitedUntil) {
+					info.RateLimitedUntil = &until
+				}
+			}
+			info.Budget[endpoint] = budget
+		}
+		switch {
+		case account.Disabled:
+			info.Status = AccountStatusDisabled
+		case info.RateLimitedUntil != nil:
+			info.Status = AccountStatusRateLimited
+		case account.LastAuthError != "":
+			info.Status = AccountStatusAuthFailing
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// LoadState restores the persisted account state from path and makes the manager
+// write every subsequent change back to it. A missing file is not an error.
+func (manager *TwitterAccountManager) LoadState(path string) error {
 	manager.mutex.Lock()
 	defer manager.mutex.Unlock()
-	account.RateLimitedUntil = time.Now().Add(GetRateLimitDuration())
+	manager.statePath = path
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("error reading account state: %v", err)
+	}
+	var states map[string]accountState
+	if err = json.Unmarshal(data, &states); err != nil {
+		return fmt.Errorf("error unmarshaling account state: %v", err)
+	}
+	for _, account := range manager.accounts {
+		state, ok := states[account.Username]
+		if !ok {
+			continue
+		}
+		account.Disabled = state.Disabled
+		account.LastAuthError = state.LastAuthError
+		account.Usage = state.Usage
+		for endpoint, saved := range state.Buckets {
+			if saved == nil {
+				continue
+			}
+			bucket := manager.bucket(account, endpoint, time.Now())
+			bucket.Capacity = math.Min(math.Max(saved.Capacity, minCapacity), bucket.maxCapacity)
+			bucket.Tokens = saved.Tokens
+			bucket.Backoff = saved.Backoff
+			bucket.BlockedUntil = saved.BlockedUntil
+			bucket.UpdatedAt = saved.UpdatedAt
+		}
+	}
+	return nil
+}
+
+// scheduleSave writes the account state to the state file after stateSaveDelay, so that the
+// usage counters of busy accounts do not cost a write per request. The caller must hold the mutex.
+func (manager *TwitterAccountManager) scheduleSave() {
+	if manager.statePath == "" || manager.saveTimer != nil {
+		return
+	}
+	manager.saveTimer = time.AfterFunc(stateSaveDelay, func() {
+		manager.mutex.Lock()
+		defer manager.mutex.Unlock()
+		manager.saveState()
+	})
+}
+
+// saveState writes the account state to the state file, including any pending usage counter
+// updates. The caller must hold the mutex.
+func (manager *TwitterAccountManager) saveState() {
+	if manager.statePath == "" {
+		return
+	}
+	if manager.saveTimer != nil {
+		manager.saveTimer.Stop()
+		manager.saveTimer = nil
+	}
+	states := make(map[string]accountState, len(manager.accounts))
+	for _, account := range manager.accounts {
+		states[account.Username] = accountState{
+			Disabled:      account.Disabled,
+			LastAuthError: account.LastAuthError,
+			Usage:         account.Usage,
+			Buckets:       account.buckets,
+		}
+	}
+	data, err := json.Marshal(states)
+	if err != nil {
+		logrus.Errorf("error marshaling account state: %v", err)
+		return
+	}
+	if err = os.WriteFile(manager.statePath, data, 0600); err != nil {
+		logrus.Errorf("error saving account state: %v", err)
+	}
+}
+
+// bucket returns the token bucket of the account for the endpoint type, creating it from the
+// default configuration on first use. The caller must hold the mutex.
+func (manager *TwitterAccountManager) bucket(account *TwitterAccount, endpoint EndpointType, now time.Time) *tokenBucket {
+	if account.buckets == nil {
+		account.buckets = make(map[EndpointType]*tokenBucket)
+	}
+	bucket, ok := account.buckets[endpoint]
+	if !ok {
+		config, known := DefaultBucketConfigs[endpoint]
+		if !known {
+			config = BucketConfig{Capacity: minCapacity, Window: RateLimitDuration}
+		}
+		bucket = newTokenBucket(config, now)
+		account.buckets[endpoint] = bucket
+	}
+	return bucket
+}
+
+func (manager *TwitterAccountManager) findAccount(username string) *TwitterAccount {
+	for _, account := range manager.accounts {
+		if account.Username == username {
+			return account
+		}
+	}
+	return nil
 }
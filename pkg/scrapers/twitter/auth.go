@@ -7,21 +7,54 @@ import (
 )
 
 func NewScraper(account *TwitterAccount, cookieDir string) *Scraper {
+	scraper, err := newScraper(account, cookieDir)
+	if err != nil {
+		return nil
+	}
+	return scraper
+}
+
+func newScraper(account *TwitterAccount, cookieDir string) (*Scraper, error) {
 	scraper := &Scraper{Scraper: newTwitterScraper()}
 
 	if err := LoadCookies(scraper.Scraper, account, cookieDir); err == nil {
 		logrus.Debugf("Cookies loaded for user %s.", account.Username)
 		if scraper.IsLoggedIn() {
 			logrus.Debugf("Already logged in as %s.", account.Username)
-			return scraper
+			return scraper, nil
 		}
 	}
 
+	if err := loginAndSaveCookies(scraper, account, cookieDir); err != nil {
+		return nil, err
+	}
+	return scraper, nil
+}
+
+// ReloginAccount discards the stored session of the given account and logs in again,
+// regardless of whether the saved cookies are still valid.
+func ReloginAccount(baseDir string, username string) error {
+	manager := GetAccountManager(baseDir)
+	account := manager.GetAccount(username)
+	if account == nil {
+		return ErrAccountNotFound
+	}
+
+	scraper := &Scraper{Scraper: newTwitterScraper()}
+	if err := loginAndSaveCookies(scraper, account, baseDir); err != nil {
+		manager.MarkAccountAuthFailed(account, err)
+		return err
+	}
+	manager.MarkAccountAuthenticated(account)
+	return nil
+}
+
+func loginAndSaveCookies(scraper *Scraper, account *TwitterAccount, cookieDir string) error {
 	RandomSleep()
 
 	if err := scraper.Login(account.Username, account.Password, account.TwoFACode); err != nil {
 		logrus.WithError(err).Warnf("Login failed for %s", account.Username)
-		return nil
+		return err
 	}
 
 	RandomSleep()
@@ -31,7 +64,7 @@ func NewScraper(account *TwitterAccount, cookieDir string) *Scraper {
 	}
 
 	logrus.Debugf("Login successful for %s", account.Username)
-	return scraper
+	return nil
 }
 
 func (scraper *Scraper) Login(username, password string, twoFACode ...string) error {
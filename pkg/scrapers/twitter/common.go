@@ -3,8 +3,10 @@ package twitter
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/sirupsen/logrus"
@@ -15,9 +17,21 @@ var (
 	once           sync.Once
 )
 
-func initializeAccountManager() {
+const accountStateFile = "twitter_accounts_state.json"
+
+func initializeAccountManager(baseDir string) {
 	accounts := loadAccountsFromConfig()
 	accountManager = NewTwitterAccountManager(accounts)
+	if err := accountManager.LoadState(filepath.Join(baseDir, accountStateFile)); err != nil {
+		logrus.WithError(err).Warn("Unable to restore Twitter account state")
+	}
+}
+
+// GetAccountManager returns the node's Twitter account pool, initializing it on first use.
+// The account state is persisted in baseDir so that rate limits survive restarts.
+func GetAccountManager(baseDir string) *TwitterAccountManager {
+	once.Do(func() { initializeAccountManager(baseDir) })
+	return accountManager
 }
 
 func loadAccountsFromConfig() []*TwitterAccount {
@@ -48,25 +62,29 @@ func parseAccounts(accountPairs []string) []*TwitterAccount {
 	})
 }
 
-func getAuthenticatedScraper(baseDir string) (*Scraper, *TwitterAccount, error) {
-	once.Do(initializeAccountManager)
+func getAuthenticatedScraper(baseDir string, endpoint EndpointType) (*Scraper, *TwitterAccount, error) {
+	manager := GetAccountManager(baseDir)
 
-	account := accountManager.GetNextAccount()
+	account := manager.GetNextAccount(endpoint)
 	if account == nil {
 		return nil, nil, fmt.Errorf("all accounts are rate-limited")
 	}
-	scraper := NewScraper(account, baseDir)
-	if scraper == nil {
+	manager.RecordRequest(account)
+	scraper, err := newScraper(account, baseDir)
+	if err != nil {
 		logrus.Errorf("Authentication failed for %s", account.Username)
+		manager.MarkAccountAuthFailed(account, err)
 		return nil, account, fmt.Errorf("Twitter authentication failed for %s", account.Username)
 	}
+	manager.MarkAccountAuthenticated(account)
 	return scraper, account, nil
 }
 
-func handleRateLimit(err error, account *TwitterAccount) bool {
-	if strings.Contains(err.Error(), "Rate limit exceeded") {
-		accountManager.MarkAccountRateLimited(account)
-		logrus.Warnf("rate limited: %s", account.Username)
+func handleRateLimit(err error, account *TwitterAccount, endpoint EndpointType) bool {
+	accountManager.RecordError(account)
+	if isRateLimitError(err) {
+		accountManager.MarkAccountRateLimited(account, endpoint, parseResetHint(err, time.Now()))
+		logrus.Warnf("rate limited on %s: %s", endpoint, account.Username)
 		return true
 	}
 	return false
@@ -4,10 +4,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"os"
 	"path/filepath"
 
 	twitterscraper "github.com/masa-finance/masa-twitter-scraper"
+
+	"github.com/Gzgod/masa-oracle/pkg/masacrypto"
 )
 
 func SaveCookies(scraper *twitterscraper.Scraper, account *TwitterAccount, baseDir string) error {
@@ -17,7 +18,7 @@ func SaveCookies(scraper *twitterscraper.Scraper, account *TwitterAccount, baseD
 	if err != nil {
 		return fmt.Errorf("error marshaling cookies: %v", err)
 	}
-	if err = os.WriteFile(cookieFile, data, 0644); err != nil {
+	if err = masacrypto.DefaultCredentialStore().WriteFile(cookieFile, data); err != nil {
 		return fmt.Errorf("error saving cookies: %v", err)
 	}
 	return nil
@@ -25,7 +26,7 @@ func SaveCookies(scraper *twitterscraper.Scraper, account *TwitterAccount, baseD
 
 func LoadCookies(scraper *twitterscraper.Scraper, account *TwitterAccount, baseDir string) error {
 	cookieFile := filepath.Join(baseDir, fmt.Sprintf("%s_twitter_cookies.json", account.Username))
-	data, err := os.ReadFile(cookieFile)
+	data, err := masacrypto.DefaultCredentialStore().ReadFile(cookieFile)
 	if err != nil {
 		return fmt.Errorf("error reading cookies: %v", err)
 	}
@@ -8,7 +8,7 @@ import (
 )
 
 func ScrapeFollowersForProfile(baseDir string, username string, count int) ([]twitterscraper.Legacy, error) {
-	scraper, account, err := getAuthenticatedScraper(baseDir)
+	scraper, account, err := getAuthenticatedScraper(baseDir, EndpointFollowers)
 	if err != nil {
 		return nil, err
 	}
@@ -16,7 +16,7 @@ func ScrapeFollowersForProfile(baseDir string, username string, count int) ([]tw
 	followingResponse, errString, _ := scraper.FetchFollowers(username, count, "")
 	if errString != "" {
 		err := fmt.Errorf("rate limited: %s", errString)
-		if handleRateLimit(err, account) {
+		if handleRateLimit(err, account, EndpointFollowers) {
 			return nil, err
 		}
 
@@ -24,5 +24,6 @@ func ScrapeFollowersForProfile(baseDir string, username string, count int) ([]tw
 		return nil, fmt.Errorf("error fetching followers: %s", errString)
 	}
 
+	accountManager.MarkAccountSucceeded(account, EndpointFollowers)
 	return followingResponse, nil
 }
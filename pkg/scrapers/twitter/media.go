@@ -0,0 +1,130 @@
+package twitter
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	twitterscraper "github.com/masa-finance/masa-twitter-scraper"
+	"github.com/sirupsen/logrus"
+
+	"github.com/Gzgod/masa-oracle/pkg/blobstore"
+	"github.com/Gzgod/masa-oracle/pkg/masacrypto"
+)
+
+const (
+	// MaxMediaSize is the largest media file a worker downloads. Larger files keep only their URL.
+	MaxMediaSize = 20 * 1024 * 1024
+	// MaxMediaPerRequest caps the total size of the media downloaded for one request.
+	MaxMediaPerRequest = 100 * 1024 * 1024
+
+	mediaDownloadTimeout = 60 * time.Second
+)
+
+// StoredMedia is a media attachment of a tweet that the worker downloaded into its blob store.
+type StoredMedia struct {
+	URL      string `json:"url"`
+	CID      string `json:"cid,omitempty"`
+	MimeType string `json:"mimeType,omitempty"`
+	Size     int64  `json:"size,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+var mediaClient = &http.Client{Timeout: mediaDownloadTimeout}
+
+// DownloadTweetMedia downloads the photos, videos and GIFs attached to the tweets into store and
+// records their CIDs on each result. It returns the downloaded content keyed by CID, so that it can
+// be passed on to the node that requested the work.
+func DownloadTweetMedia(store *blobstore.Store, results []*TweetResult) map[string][]byte {
+	blobs := make(map[string][]byte)
+	var total int64
+	for _, result := range results {
+		if result == nil || result.Tweet == nil {
+			continue
+		}
+		for _, url := range mediaURLs(result.Tweet) {
+			media := StoredMedia{URL: url}
+			if total >= MaxMediaPerRequest {
+				media.Error = "media limit for the request reached"
+				result.Media = append(result.Media, media)
+				continue
+			}
+			data, err := downloadMedia(url)
+			if err != nil {
+				logrus.WithError(err).Warnf("Unable to download media %s", url)
+				media.Error = err.Error()
+				result.Media = append(result.Media, media)
+				continue
+			}
+			cidKey, err := masacrypto.ComputeSha256CidFromBytes(data)
+			if err != nil {
+				media.Error = err.Error()
+				result.Media = append(result.Media, media)
+				continue
+			}
+			// Media already returned for this request does not count against the limit again.
+			if _, ok := blobs[cidKey]; !ok && total+int64(len(data)) > MaxMediaPerRequest {
+				media.Error = "media limit for the request reached"
+				result.Media = append(result.Media, media)
+				continue
+			}
+			info, err := store.Put(data, url)
+			if err != nil {
+				logrus.WithError(err).Errorf("Unable to store media %s", url)
+				media.Error = err.Error()
+				result.Media = append(result.Media, media)
+				continue
+			}
+			media.CID = info.CID
+			media.MimeType = info.MimeType
+			media.Size = info.Size
+			result.Media = append(result.Media, media)
+			if _, ok := blobs[info.CID]; !ok {
+				blobs[info.CID] = data
+				total += info.Size
+			}
+		}
+	}
+	return blobs
+}
+
+func mediaURLs(tweet *twitterscraper.Tweet) []string {
+	var urls []string
+	for _, photo := range tweet.Photos {
+		urls = append(urls, photo.URL)
+	}
+	for _, video := range tweet.Videos {
+		urls = append(urls, video.URL)
+	}
+	for _, gif := range tweet.GIFs {
+		urls = append(urls, gif.URL)
+	}
+	return urls
+}
+
+func downloadMedia(url string) ([]byte, error) {
+	if url == "" {
+		return nil, fmt.Errorf("media has no URL")
+	}
+	resp, err := mediaClient.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error downloading media, status code: %d", resp.StatusCode)
+	}
+	if resp.ContentLength > MaxMediaSize {
+		return nil, fmt.Errorf("media is larger than %d bytes", MaxMediaSize)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, MaxMediaSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > MaxMediaSize {
+		return nil, fmt.Errorf("media is larger than %d bytes", MaxMediaSize)
+	}
+	return data, nil
+}
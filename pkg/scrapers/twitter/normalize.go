@@ -0,0 +1,121 @@
+package twitter
+
+import (
+	"fmt"
+
+	twitterscraper "github.com/masa-finance/masa-twitter-scraper"
+
+	"github.com/Gzgod/masa-oracle/pkg/scrapers/normalized"
+)
+
+// TweetToPost converts a scraped tweet to the normalized post schema.
+func TweetToPost(tweet *twitterscraper.Tweet) normalized.Post {
+	post := normalized.Post{
+		ID:        tweet.ID,
+		Source:    normalized.SourceTwitter,
+		URL:       tweet.PermanentURL,
+		Text:      tweet.Text,
+		CreatedAt: normalized.TimePtr(tweet.TimeParsed),
+		ChannelID: tweet.ConversationID,
+		ReplyToID: tweet.InReplyToStatusID,
+		Links:     tweet.URLs,
+		Tags:      tweet.Hashtags,
+		Author: &normalized.Author{
+			ID:          tweet.UserID,
+			Source:      normalized.SourceTwitter,
+			Username:    tweet.Username,
+			DisplayName: tweet.Name,
+			URL:         profileURL(tweet.Username),
+		},
+		Metrics: map[string]int{
+			"likes":    tweet.Likes,
+			"replies":  tweet.Replies,
+			"retweets": tweet.Retweets,
+			"views":    tweet.Views,
+		},
+		Extensions: normalized.Extension(normalized.SourceTwitter, tweet),
+	}
+	for _, photo := range tweet.Photos {
+		post.Media = append(post.Media, normalized.Media{Type: normalized.MediaImage, URL: photo.URL})
+	}
+	for _, video := range tweet.Videos {
+		post.Media = append(post.Media, normalized.Media{Type: normalized.MediaVideo, URL: video.URL, PreviewURL: video.Preview})
+	}
+	for _, gif := range tweet.GIFs {
+		post.Media = append(post.Media, normalized.Media{Type: normalized.MediaGIF, URL: gif.URL, PreviewURL: gif.Preview})
+	}
+	return post
+}
+
+// TweetsToPosts converts the results of a tweet search to normalized posts, skipping failed results.
+func TweetsToPosts(results []*TweetResult) []normalized.Post {
+	posts := make([]normalized.Post, 0, len(results))
+	for _, result := range results {
+		if result == nil || result.Tweet == nil {
+			continue
+		}
+		post := TweetToPost(result.Tweet)
+		for i := range post.Media {
+			for _, stored := range result.Media {
+				if stored.URL == post.Media[i].URL && stored.CID != "" {
+					post.Media[i].CID = stored.CID
+					post.Media[i].MimeType = stored.MimeType
+					post.Media[i].Size = stored.Size
+				}
+			}
+		}
+		posts = append(posts, post)
+	}
+	return posts
+}
+
+// ProfileToAuthor converts a scraped Twitter profile to the normalized author schema.
+func ProfileToAuthor(profile twitterscraper.Profile) normalized.Author {
+	return normalized.Author{
+		ID:          profile.UserID,
+		Source:      normalized.SourceTwitter,
+		Username:    profile.Username,
+		DisplayName: profile.Name,
+		Description: profile.Biography,
+		AvatarURL:   profile.Avatar,
+		URL:         profileURL(profile.Username),
+		Verified:    profile.IsVerified,
+		Metrics: map[string]int{
+			"followers": profile.FollowersCount,
+			"following": profile.FollowingCount,
+			"tweets":    profile.TweetsCount,
+			"likes":     profile.LikesCount,
+		},
+		Extensions: normalized.Extension(normalized.SourceTwitter, profile),
+	}
+}
+
+// FollowersToAuthors converts the followers of a profile to the normalized author schema.
+func FollowersToAuthors(followers []twitterscraper.Legacy) []normalized.Author {
+	authors := make([]normalized.Author, 0, len(followers))
+	for _, follower := range followers {
+		authors = append(authors, normalized.Author{
+			Source:      normalized.SourceTwitter,
+			Username:    follower.ScreenName,
+			DisplayName: follower.Name,
+			Description: follower.Description,
+			AvatarURL:   follower.ProfileImageUrlHttps,
+			URL:         profileURL(follower.ScreenName),
+			Verified:    follower.Verified,
+			Metrics: map[string]int{
+				"followers": follower.FollowersCount,
+				"following": follower.FriendsCount,
+				"tweets":    follower.StatusesCount,
+			},
+			Extensions: normalized.Extension(normalized.SourceTwitter, follower),
+		})
+	}
+	return authors
+}
+
+func profileURL(username string) string {
+	if username == "" {
+		return ""
+	}
+	return fmt.Sprintf("https://twitter.com/%s", username)
+}
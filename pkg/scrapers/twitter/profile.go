@@ -5,17 +5,18 @@ import (
 )
 
 func ScrapeTweetsProfile(baseDir string, username string) (twitterscraper.Profile, error) {
-	scraper, account, err := getAuthenticatedScraper(baseDir)
+	scraper, account, err := getAuthenticatedScraper(baseDir, EndpointProfile)
 	if err != nil {
 		return twitterscraper.Profile{}, err
 	}
 
 	profile, err := scraper.GetProfile(username)
 	if err != nil {
-		if handleRateLimit(err, account) {
+		if handleRateLimit(err, account, EndpointProfile) {
 			return twitterscraper.Profile{}, err
 		}
 		return twitterscraper.Profile{}, err
 	}
+	accountManager.MarkAccountSucceeded(account, EndpointProfile)
 	return profile, nil
 }
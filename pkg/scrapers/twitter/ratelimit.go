@@ -0,0 +1,150 @@
+package twitter
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// EndpointType groups the Twitter requests that share a rate limit.
+type EndpointType string
+
+const (
+	EndpointSearch    EndpointType = "search"
+	EndpointProfile   EndpointType = "profile"
+	EndpointFollowers EndpointType = "followers"
+)
+
+// BucketConfig is the starting budget of a single account for one endpoint type.
+type BucketConfig struct {
+	Capacity int           // Requests allowed per window
+	Window   time.Duration // Time it takes to refill an empty bucket
+}
+
+// DefaultBucketConfigs mirrors the published per-user limits of the endpoints used by the scraper.
+var DefaultBucketConfigs = map[EndpointType]BucketConfig{
+	EndpointSearch:    {Capacity: 50, Window: RateLimitDuration},
+	EndpointProfile:   {Capacity: 95, Window: RateLimitDuration},
+	EndpointFollowers: {Capacity: 50, Window: RateLimitDuration},
+}
+
+const (
+	minBackoff  = 1 * time.Minute
+	minCapacity = 1
+)
+
+var (
+	// rateLimitPattern matches the errors of the scraper for a 429 response, "response status 429
+	// Too Many Requests: ...", and the "Rate limit exceeded" message of the API
+	rateLimitPattern      = regexp.MustCompile(`(?i)\bstatus(?: code)?:? 429\b|\brate limit exceeded\b`)
+	resetHintPattern      = regexp.MustCompile(`(?i)x-rate-limit-reset["':= ]+(\d{10})`)
+	retryAfterHintPattern = regexp.MustCompile(`(?i)retry-after["':= ]+(\d+)`)
+)
+
+// tokenBucket is a per-account, per-endpoint token bucket. Its capacity starts at the configured
+// limit, is halved every time Twitter answers with a 429 and slowly grows back on every success,
+// so that the bucket converges on the limit Twitter actually enforces for the account.
+type tokenBucket struct {
+	Capacity     float64       `json:"capacity"`
+	Tokens       float64       `json:"tokens"`
+	Backoff      time.Duration `json:"backoff"`
+	BlockedUntil time.Time     `json:"blockedUntil"`
+	UpdatedAt    time.Time     `json:"updatedAt"`
+
+	maxCapacity float64
+	window      time.Duration
+}
+
+func newTokenBucket(config BucketConfig, now time.Time) *tokenBucket {
+	return &tokenBucket{
+		Capacity:    float64(config.Capacity),
+		Tokens:      float64(config.Capacity),
+		UpdatedAt:   now,
+		maxCapacity: float64(config.Capacity),
+		window:      config.Window,
+	}
+}
+
+// tokensAt returns the number of tokens available at the given time without modifying the bucket.
+func (b *tokenBucket) tokensAt(now time.Time) float64 {
+	if now.Before(b.BlockedUntil) {
+		return 0
+	}
+	elapsed := now.Sub(b.UpdatedAt)
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	refilled := b.Tokens + elapsed.Seconds()*b.Capacity/b.window.Seconds()
+	return math.Min(refilled, b.Capacity)
+}
+
+// take consumes a token if one is available.
+func (b *tokenBucket) take(now time.Time) bool {
+	tokens := b.tokensAt(now)
+	if tokens < 1 {
+		return false
+	}
+	b.Tokens = tokens - 1
+	b.UpdatedAt = now
+	return true
+}
+
+// remaining returns the number of whole requests left in the bucket.
+func (b *tokenBucket) remaining(now time.Time) int {
+	return int(b.tokensAt(now))
+}
+
+// rateLimited adapts the bucket to an observed 429. If Twitter told us when the limit resets the
+// bucket is blocked until then, otherwise it backs off exponentially up to a full window.
+func (b *tokenBucket) rateLimited(now time.Time, resetAt time.Time) {
+	b.Capacity = math.Max(minCapacity, b.Capacity/2)
+	b.Tokens = 0
+	b.UpdatedAt = now
+	if resetAt.After(now) {
+		b.Backoff = resetAt.Sub(now)
+		b.BlockedUntil = resetAt
+		return
+	}
+	if b.Backoff < minBackoff {
+		b.Backoff = minBackoff
+	} else {
+		b.Backoff = time.Duration(math.Min(float64(b.Backoff*2), float64(b.window)))
+	}
+	b.BlockedUntil = now.Add(b.Backoff)
+}
+
+// succeeded grows the capacity back towards the configured limit and relaxes the backoff.
+func (b *tokenBucket) succeeded(now time.Time) {
+	tokens := b.tokensAt(now)
+	b.Capacity = math.Min(b.maxCapacity, b.Capacity+1)
+	b.Tokens = tokens
+	b.UpdatedAt = now
+	b.Backoff /= 2
+	if b.Backoff < minBackoff {
+		b.Backoff = 0
+	}
+}
+
+// isRateLimitError reports whether err is Twitter telling us to slow down. Only the status of the
+// response counts, a 429 elsewhere in the error, such as in a tweet ID, does not.
+func isRateLimitError(err error) bool {
+	return rateLimitPattern.MatchString(err.Error())
+}
+
+// parseResetHint extracts the time at which the rate limit resets from the error returned by the
+// scraper, if it carries an x-rate-limit-reset or Retry-After hint. It returns the zero time otherwise.
+func parseResetHint(err error, now time.Time) time.Time {
+	msg := err.Error()
+	if m := resetHintPattern.FindStringSubmatch(msg); m != nil {
+		if epoch, convErr := strconv.ParseInt(m[1], 10, 64); convErr == nil {
+			return time.Unix(epoch, 0)
+		}
+	}
+	if m := retryAfterHintPattern.FindStringSubmatch(msg); m != nil {
+		if seconds, convErr := strconv.Atoi(m[1]); convErr == nil {
+			return now.Add(time.Duration(seconds) * time.Second)
+		}
+	}
+	return time.Time{}
+}
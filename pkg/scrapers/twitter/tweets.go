@@ -9,10 +9,11 @@ import (
 type TweetResult struct {
 	Tweet *twitterscraper.Tweet
 	Error error
+	Media []StoredMedia `json:",omitempty"` // Set when the media of the tweet was downloaded
 }
 
 func ScrapeTweetsByQuery(baseDir string, query string, count int) ([]*TweetResult, error) {
-	scraper, account, err := getAuthenticatedScraper(baseDir)
+	scraper, account, err := getAuthenticatedScraper(baseDir, EndpointSearch)
 	if err != nil {
 		return nil, err
 	}
@@ -22,12 +23,13 @@ func ScrapeTweetsByQuery(baseDir string, query string, count int) ([]*TweetResul
 	scraper.SetSearchMode(twitterscraper.SearchLatest)
 	for tweet := range scraper.SearchTweets(ctx, query, count) {
 		if tweet.Error != nil {
-			if handleRateLimit(tweet.Error, account) {
+			if handleRateLimit(tweet.Error, account, EndpointSearch) {
 				return nil, tweet.Error
 			}
 			return nil, tweet.Error
 		}
 		tweets = append(tweets, &TweetResult{Tweet: &tweet.Tweet})
 	}
+	accountManager.MarkAccountSucceeded(account, EndpointSearch)
 	return tweets, nil
 }
@@ -0,0 +1,211 @@
+package web
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// Formats of the main content of a page.
+const (
+	ContentMarkdown = "markdown"
+	ContentText     = "text"
+)
+
+// Document is the main content of a scraped page, without navigation, footers and other boilerplate.
+type Document struct {
+	URL          string `json:"url"`
+	CanonicalURL string `json:"canonicalUrl,omitempty"`
+	Title        string `json:"title,omitempty"`
+	Description  string `json:"description,omitempty"`
+	Language     string `json:"language,omitempty"`
+	// Format is ContentMarkdown or ContentText.
+	Format  string `json:"format"`
+	Content string `json:"content"`
+}
+
+// validContentFormat returns an error if format is not empty, ContentMarkdown or ContentText.
+func validContentFormat(format string) error {
+	switch format {
+	case "", ContentMarkdown, ContentText:
+		return nil
+	default:
+		return fmt.Errorf("invalid content format %q, expected %s or %s", format, ContentMarkdown, ContentText)
+	}
+}
+
+// ExtractContent parses an HTML page and returns its main content in the given format.
+// pageURL is the address of the page, relative links and images are resolved against it.
+func ExtractContent(r io.Reader, pageURL string, format string) (Document, error) {
+	if err := validContentFormat(format); err != nil {
+		return Document{}, err
+	}
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return Document{}, err
+	}
+	doc, err := goquery.NewDocumentFromReader(r)
+	if err != nil {
+		return Document{}, err
+	}
+	return extractDocument(doc.Selection, base, nil, format), nil
+}
+
+// extractDocument returns the main content and metadata of a parsed page. header holds the
+// response headers, if any.
+func extractDocument(page *goquery.Selection, base *url.URL, header http.Header, format string) Document {
+	if format == "" {
+		format = ContentMarkdown
+	}
+	doc := Document{
+		URL:         base.String(),
+		Title:       cleanText(page.Find("title").First().Text()),
+		Description: cleanText(metaContent(page, "description")),
+		Language:    pageLanguage(page, header),
+		Format:      format,
+	}
+	if href, ok := page.Find(`link[rel~="canonical"]`).First().Attr("href"); ok {
+		if canonical, err := base.Parse(strings.TrimSpace(href)); err == nil {
+			doc.CanonicalURL = canonical.String()
+		}
+	}
+
+	body := page.Find("body").First()
+	if body.Length() == 0 {
+		body = page
+	}
+	content := body.Clone()
+	stripBoilerplate(content)
+	root := mainContent(content)
+	if doc.Title == "" {
+		doc.Title = cleanText(content.Find("h1").First().Text())
+	}
+
+	r := &renderer{markdown: format == ContentMarkdown, base: base}
+	doc.Content = strings.Join(r.blocks(root.Nodes[0]), "\n\n")
+	return doc
+}
+
+// htmlText returns the plain text of an HTML fragment, such as the content of a feed entry. Text
+// without markup is returned as it is, with its whitespace collapsed.
+func htmlText(fragment string) string {
+	if !strings.Contains(fragment, "<") {
+		return cleanText(html.UnescapeString(fragment))
+	}
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(fragment))
+	if err != nil {
+		return cleanText(fragment)
+	}
+	r := &renderer{base: &url.URL{}}
+	return strings.Join(r.blocks(doc.Find("body").Nodes[0]), "\n\n")
+}
+
+func metaContent(page *goquery.Selection, name string) string {
+	content, _ := page.Find(`meta[name="` + name + `"]`).First().Attr("content")
+	return content
+}
+
+// pageLanguage returns the language of the html element, the Content-Language meta tag or the
+// Content-Language header, in that order.
+func pageLanguage(page *goquery.Selection, header http.Header) string {
+	root := page.Filter("html")
+	if root.Length() == 0 {
+		root = page.Find("html").First()
+	}
+	if lang, ok := root.Attr("lang"); ok && strings.TrimSpace(lang) != "" {
+		return strings.TrimSpace(lang)
+	}
+	if lang, ok := page.Find(`meta[http-equiv="content-language" i]`).First().Attr("content"); ok && strings.TrimSpace(lang) != "" {
+		return strings.TrimSpace(lang)
+	}
+	if header != nil {
+		// The header may list several languages, the first is the main one
+		return strings.TrimSpace(strings.Split(header.Get("Content-Language"), ",")[0])
+	}
+	return ""
+}
+
+// boilerplateElements are removed before looking for the main content.
+const boilerplateElements = "script, style, noscript, template, iframe, svg, canvas, form, button, input, select, textarea, " +
+	"nav, header, footer, aside, dialog, [hidden], [aria-hidden=true], " +
+	"[role=navigation], [role=banner], [role=contentinfo], [role=complementary], [role=search], [role=dialog]"
+
+// boilerplatePattern matches the class names and IDs of navigation, sharing and similar blocks.
+var boilerplatePattern = regexp.MustCompile(`(?i)(^|[-_\s])(nav|navbar|menu|breadcrumbs?|footer|header|masthead|sidebar|` +
+	`cookies?|consent|banner|share|sharing|social|related|comments?|ads?|advert|advertisement|promo|sponsored|` +
+	`subscribe|newsletter|popup|modal|skip-link)($|[-_\s])`)
+
+// stripBoilerplate removes navigation, footers, scripts and similar elements. Elements that hold
+// the main content are kept even if their class name looks like boilerplate, and so are the
+// headers of articles, which often hold the title.
+func stripBoilerplate(content *goquery.Selection) {
+	content.Find(boilerplateElements).Not("main, article").FilterFunction(func(_ int, s *goquery.Selection) bool {
+		return !s.Is("header") || s.Closest("article, main, [role=main]").Length() == 0
+	}).Remove()
+	textLen := len(cleanText(content.Text()))
+	content.Find("[class], [id]").Each(func(_ int, s *goquery.Selection) {
+		if s.Is("main, article, [role=main], body") || s.Find("main, article, [role=main]").Length() > 0 {
+			return
+		}
+		// A wrapper of most of the page, such as a layout with a sidebar
+		if len(cleanText(s.Text()))*2 > textLen {
+			return
+		}
+		class, _ := s.Attr("class")
+		id, _ := s.Attr("id")
+		if boilerplatePattern.MatchString(class) || boilerplatePattern.MatchString(id) {
+			s.Remove()
+		}
+	})
+}
+
+// mainContent returns the element that holds the main content: the main element, the only
+// article, or else the container with the most paragraph text that is not link text.
+func mainContent(content *goquery.Selection) *goquery.Selection {
+	if main := content.Find("main, [role=main]").First(); main.Length() > 0 && cleanText(main.Text()) != "" {
+		return main
+	}
+	if articles := content.Find("article"); articles.Length() == 1 {
+		return articles
+	}
+
+	scores := map[*html.Node]int{}
+	var candidates []*goquery.Selection
+	content.Find("p, pre, li, td, blockquote, dd").Each(func(_ int, s *goquery.Selection) {
+		score := len(cleanText(s.Text())) - len(cleanText(s.Find("a").Text()))
+		if score < 25 {
+			return
+		}
+		// The parent gets the full score and the grandparent half of it, as in Readability
+		for i, ancestor := range []*goquery.Selection{s.Parent(), s.Parent().Parent()} {
+			if ancestor.Length() == 0 {
+				continue
+			}
+			if _, ok := scores[ancestor.Nodes[0]]; !ok {
+				candidates = append(candidates, ancestor)
+			}
+			scores[ancestor.Nodes[0]] += score >> i
+		}
+	})
+
+	best, bestScore := content, 0
+	for _, candidate := range candidates {
+		if score := scores[candidate.Nodes[0]]; score > bestScore {
+			best, bestScore = candidate, score
+		}
+	}
+	// A list item or a table cell is part of a larger structure, use its container as a whole
+	if best.Is("ul, ol, tr, tbody, table") {
+		if container := best.Closest("article, section, div"); container.Length() > 0 {
+			return container
+		}
+		return content
+	}
+	return best
+}
@@ -0,0 +1,258 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Scopes limit the links a crawl follows.
+const (
+	ScopeAny        = "any"         // Any link the policy allows, the default
+	ScopeSameDomain = "same-domain" // Links to the host of a start URL or its subdomains
+	ScopeSamePrefix = "same-prefix" // Links that start with a start URL
+)
+
+// Reasons why a crawl stopped.
+const (
+	StopEnd      = "end"       // Every page within the depth and scope was scraped
+	StopMaxPages = "max-pages" // MaxPages pages were requested
+	StopMaxBytes = "max-bytes" // MaxBytes bytes were downloaded
+	StopTime     = "time"      // MaxDuration passed
+)
+
+// Default budgets of crawls requested through the web work type.
+const (
+	DefaultMaxPages = 100
+	DefaultMaxBytes = 20 << 20
+)
+
+// CrawlOptions bound the pages a crawl visits. Zero budgets mean no limit.
+type CrawlOptions struct {
+	// Depth is the number of link levels to follow, 1 scrapes the start URLs only.
+	Depth int
+	// MaxPages is the number of pages to request, the start URLs included.
+	MaxPages int
+	// MaxBytes is the number of response bytes to download.
+	MaxBytes int
+	// MaxDuration stops the crawl after the given time, the requests in flight are cancelled.
+	MaxDuration time.Duration
+	// Scope is ScopeAny, ScopeSameDomain or ScopeSamePrefix.
+	Scope string
+	// Include, if not empty, limits the followed links to URLs that match one of the regular
+	// expressions. Links that match one of Exclude are not followed. The start URLs are always scraped.
+	Include []string
+	Exclude []string
+	// Content, if ContentMarkdown or ContentText, adds the main content of every page to the
+	// Documents of the result.
+	Content string
+	// Metadata adds the structured data of every page to the Metadata of the result.
+	Metadata bool
+	// Extract, if not empty, adds the values of the fields on every page to the Extracted of the result.
+	Extract []Field
+	// Discover is DiscoverLinks, DiscoverSitemap or DiscoverFeed. With DiscoverSitemap and
+	// DiscoverFeed the pages listed in the sitemaps or feeds are scraped, the most recently changed
+	// first, and links are not followed. The pages are still subject to the scope and patterns.
+	Discover string
+	// Since, if set, skips the sitemap and feed entries that did not change after it. Entries
+	// without a date are scraped.
+	Since time.Time
+}
+
+// crawl tracks the budgets and scope of a running crawl.
+type crawl struct {
+	opts     CrawlOptions
+	extract  []compiledField
+	include  []*regexp.Regexp
+	exclude  []*regexp.Regexp
+	starts   []*url.URL
+	ctx      context.Context
+	cancel   context.CancelFunc
+	timer    *time.Timer
+	mutex    sync.Mutex
+	pages    int
+	bytes    int
+	stopping string
+	// requested holds the URLs counted against the page budget, each is counted once.
+	requested map[string]bool
+}
+
+func newCrawl(starts []*url.URL, opts CrawlOptions) (*crawl, error) {
+	switch opts.Scope {
+	case "", ScopeAny, ScopeSameDomain, ScopeSamePrefix:
+	default:
+		return nil, fmt.Errorf("invalid scope %q, expected %s, %s or %s", opts.Scope, ScopeAny, ScopeSameDomain, ScopeSamePrefix)
+	}
+	if err := validContentFormat(opts.Content); err != nil {
+		return nil, err
+	}
+	if err := validDiscover(opts.Discover); err != nil {
+		return nil, err
+	}
+	extract, err := compileFields(opts.Extract)
+	if err != nil {
+		return nil, err
+	}
+	include, err := compilePatterns("include", opts.Include)
+	if err != nil {
+		return nil, err
+	}
+	exclude, err := compilePatterns("exclude", opts.Exclude)
+	if err != nil {
+		return nil, err
+	}
+
+	cr := &crawl{opts: opts, extract: extract, include: include, exclude: exclude, starts: starts, requested: map[string]bool{}}
+	cr.ctx, cr.cancel = context.WithCancel(context.Background())
+	if opts.MaxDuration > 0 {
+		cr.timer = time.AfterFunc(opts.MaxDuration, func() { cr.stop(StopTime) })
+	}
+	return cr, nil
+}
+
+func compilePatterns(name string, patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s pattern %q: %w", name, pattern, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// stop ends the crawl for the given reason and cancels the requests in flight. The first reason is kept.
+func (cr *crawl) stop(reason string) {
+	cr.mutex.Lock()
+	defer cr.mutex.Unlock()
+	if cr.stopping == "" {
+		cr.stopping = reason
+	}
+	cr.cancel()
+}
+
+// stopped reports whether a budget ended the crawl.
+func (cr *crawl) stopped() bool {
+	cr.mutex.Lock()
+	defer cr.mutex.Unlock()
+	return cr.stopping != ""
+}
+
+// startPage counts a request for u against the budget, once per URL. It returns false if the
+// request must be aborted. The pages already requested are still scraped when the page budget runs out.
+func (cr *crawl) startPage(u *url.URL) bool {
+	cr.mutex.Lock()
+	defer cr.mutex.Unlock()
+	if cr.stopping != "" {
+		return false
+	}
+	page := withoutFragment(u)
+	if cr.requested[page] {
+		return true
+	}
+	if cr.opts.MaxPages > 0 && cr.pages >= cr.opts.MaxPages {
+		cr.stopping = StopMaxPages
+		return false
+	}
+	cr.requested[page] = true
+	cr.pages++
+	return true
+}
+
+// addBytes counts downloaded bytes against the budget.
+func (cr *crawl) addBytes(n int) {
+	cr.mutex.Lock()
+	cr.bytes += n
+	exhausted := cr.opts.MaxBytes > 0 && cr.bytes >= cr.opts.MaxBytes
+	cr.mutex.Unlock()
+	if exhausted {
+		cr.stop(StopMaxBytes)
+	}
+}
+
+// finish releases the timer and returns why the crawl stopped.
+func (cr *crawl) finish() string {
+	if cr.timer != nil {
+		cr.timer.Stop()
+	}
+	cr.cancel()
+	cr.mutex.Lock()
+	defer cr.mutex.Unlock()
+	if cr.stopping == "" {
+		return StopEnd
+	}
+	return cr.stopping
+}
+
+// follows reports whether a link is within the scope and patterns of the crawl.
+func (cr *crawl) follows(u *url.URL) bool {
+	if !cr.inScope(u) {
+		return false
+	}
+	link := u.String()
+	for _, re := range cr.exclude {
+		if re.MatchString(link) {
+			return false
+		}
+	}
+	if len(cr.include) == 0 {
+		return true
+	}
+	for _, re := range cr.include {
+		if re.MatchString(link) {
+			return true
+		}
+	}
+	return false
+}
+
+func (cr *crawl) inScope(u *url.URL) bool {
+	switch cr.opts.Scope {
+	case ScopeSameDomain:
+		host := strings.ToLower(u.Hostname())
+		for _, start := range cr.starts {
+			domain := strings.TrimPrefix(strings.ToLower(start.Hostname()), "www.")
+			if host == domain || strings.HasSuffix(host, "."+domain) {
+				return true
+			}
+		}
+		return false
+	case ScopeSamePrefix:
+		link := withoutFragment(u)
+		for _, start := range cr.starts {
+			prefix := *start
+			prefix.RawQuery, prefix.Fragment = "", ""
+			if strings.HasPrefix(link, prefix.String()) {
+				return true
+			}
+		}
+		return false
+	default:
+		return true
+	}
+}
+
+func withoutFragment(u *url.URL) string {
+	link := *u
+	link.Fragment = ""
+	return link.String()
+}
+
+// transport cancels the requests of the crawl when it stops.
+func (cr *crawl) transport(base http.RoundTripper) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return base.RoundTrip(req.WithContext(cr.ctx))
+	})
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
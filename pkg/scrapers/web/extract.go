@@ -0,0 +1,212 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/cascadia"
+	"github.com/antchfx/htmlquery"
+	"github.com/antchfx/xpath"
+	"golang.org/x/net/html"
+)
+
+// Field is a named value to extract from every page of a crawl. Its elements are selected with
+// either a CSS selector or an XPath expression, within the page or within the element of the
+// enclosing group. CSS selectors only match descendants, use an XPath expression such as
+// "@data-id" to read the element of the group itself. XPath expressions that return a string or
+// a number, such as count(), and attribute or text nodes give their value.
+type Field struct {
+	Name  string `json:"name"`
+	CSS   string `json:"css,omitempty"`
+	XPath string `json:"xpath,omitempty"`
+	// Attribute is the attribute to read, the text of the element is read if empty. The href and
+	// src attributes are resolved to absolute URLs.
+	Attribute string `json:"attribute,omitempty"`
+	// Multiple returns the values of all the matched elements as a list, instead of the value of
+	// the first one.
+	Multiple bool `json:"multiple,omitempty"`
+	// Fields makes the field a group: every matched element gives an object with these fields.
+	Fields []Field `json:"fields,omitempty"`
+}
+
+// Extraction is the result of the fields of a crawl on a page.
+type Extraction struct {
+	URL  string         `json:"url"`
+	Data map[string]any `json:"data"`
+}
+
+// maxFields bounds the number of fields of a request, groups and nested fields included.
+const maxFields = 100
+
+// ErrInvalidField is wrapped by the errors of ValidateFields.
+var ErrInvalidField = errors.New("invalid extraction field")
+
+// ValidateFields returns an error if a field has no name, a duplicate name, an invalid selector
+// or not exactly one of a CSS selector and an XPath expression.
+func ValidateFields(fields []Field) error {
+	_, err := compileFields(fields)
+	return err
+}
+
+// ExtractFields parses an HTML page and returns the values of the fields. pageURL is the address
+// of the page, relative links are resolved against it.
+func ExtractFields(r io.Reader, pageURL string, fields []Field) (map[string]any, error) {
+	compiled, err := compileFields(fields)
+	if err != nil {
+		return nil, err
+	}
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return nil, err
+	}
+	root, err := html.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+	return extractFields(compiled, root, base), nil
+}
+
+// compiledField is a Field with its selector parsed.
+type compiledField struct {
+	Field
+	css    cascadia.Selector
+	xpath  *xpath.Expr
+	fields []compiledField
+}
+
+func compileFields(fields []Field) ([]compiledField, error) {
+	count := 0
+	return compileLevel(fields, "", &count)
+}
+
+func compileLevel(fields []Field, parent string, count *int) ([]compiledField, error) {
+	compiled := make([]compiledField, 0, len(fields))
+	names := map[string]bool{}
+	for _, field := range fields {
+		path := parent + field.Name
+		if *count++; *count > maxFields {
+			return nil, fmt.Errorf("%w: more than %d fields", ErrInvalidField, maxFields)
+		}
+		switch {
+		case field.Name == "":
+			return nil, fmt.Errorf("%w: a field of %q has no name", ErrInvalidField, strings.TrimSuffix(parent, "."))
+		case names[field.Name]:
+			return nil, fmt.Errorf("%w: %q is defined twice", ErrInvalidField, path)
+		case (field.CSS == "") == (field.XPath == ""):
+			return nil, fmt.Errorf("%w: %q needs either a css or an xpath selector", ErrInvalidField, path)
+		case field.Attribute != "" && len(field.Fields) > 0:
+			return nil, fmt.Errorf("%w: group %q cannot read an attribute", ErrInvalidField, path)
+		}
+		names[field.Name] = true
+
+		c := compiledField{Field: field}
+		var err error
+		if field.CSS != "" {
+			if c.css, err = cascadia.Compile(field.CSS); err != nil {
+				return nil, fmt.Errorf("%w: css selector of %q: %v", ErrInvalidField, path, err)
+			}
+		} else if c.xpath, err = xpath.Compile(field.XPath); err != nil {
+			return nil, fmt.Errorf("%w: xpath of %q: %v", ErrInvalidField, path, err)
+		}
+		if c.fields, err = compileLevel(field.Fields, path+".", count); err != nil {
+			return nil, err
+		}
+		compiled = append(compiled, c)
+	}
+	return compiled, nil
+}
+
+// extractFields returns the values of the fields within n. A field that matches nothing is nil,
+// or an empty list if it is Multiple.
+func extractFields(fields []compiledField, n *html.Node, base *url.URL) map[string]any {
+	data := make(map[string]any, len(fields))
+	for _, field := range fields {
+		values := []any{}
+		for _, m := range field.matches(n) {
+			if value, ok := field.value(m, base); ok {
+				values = append(values, value)
+			}
+		}
+		switch {
+		case field.Multiple:
+			data[field.Name] = values
+		case len(values) > 0:
+			data[field.Name] = values[0]
+		default:
+			data[field.Name] = nil
+		}
+	}
+	return data
+}
+
+// match is an element, or the value of an attribute or of an XPath expression that does not
+// select nodes, such as count() or string().
+type match struct {
+	node  *html.Node
+	value string
+}
+
+func (f compiledField) matches(n *html.Node) []match {
+	if f.css != nil {
+		nodes := cascadia.QueryAll(n, f.css)
+		matches := make([]match, len(nodes))
+		for i, node := range nodes {
+			matches[i] = match{node: node}
+		}
+		return matches
+	}
+
+	switch result := f.xpath.Evaluate(htmlquery.CreateXPathNavigator(n)).(type) {
+	case *xpath.NodeIterator:
+		var matches []match
+		for result.MoveNext() {
+			nav := result.Current().(*htmlquery.NodeNavigator)
+			switch nav.NodeType() {
+			case xpath.AttributeNode, xpath.TextNode:
+				matches = append(matches, match{value: nav.Value()})
+			default:
+				matches = append(matches, match{node: nav.Current()})
+			}
+		}
+		return matches
+	case string:
+		return []match{{value: result}}
+	case float64:
+		return []match{{value: strconv.FormatFloat(result, 'f', -1, 64)}}
+	case bool:
+		return []match{{value: strconv.FormatBool(result)}}
+	default:
+		return nil
+	}
+}
+
+// value returns the value of a match, false if it has none: an attribute the element does not
+// have, or a group that did not match an element.
+func (f compiledField) value(m match, base *url.URL) (any, bool) {
+	if m.node == nil {
+		if len(f.fields) > 0 {
+			return nil, false
+		}
+		return strings.TrimSpace(m.value), true
+	}
+	if len(f.fields) > 0 {
+		return extractFields(f.fields, m.node, base), true
+	}
+	if f.Attribute == "" {
+		return cleanText(textContent(m.node)), true
+	}
+	for _, a := range m.node.Attr {
+		if !strings.EqualFold(a.Key, f.Attribute) {
+			continue
+		}
+		if a.Key == "href" || a.Key == "src" {
+			return resolveAny(base, a.Val), true
+		}
+		return strings.TrimSpace(a.Val), true
+	}
+	return nil, false
+}
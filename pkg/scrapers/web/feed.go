@@ -0,0 +1,495 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/html/charset"
+)
+
+// Feed media types, as in Feed.Type.
+const (
+	FeedRSS  = "application/rss+xml"
+	FeedAtom = "application/atom+xml"
+	FeedRDF  = "application/rdf+xml"
+	FeedJSON = "application/feed+json"
+)
+
+// DefaultFeedLimit is the number of items ReadFeeds returns if FeedOptions.Limit is not set.
+const DefaultFeedLimit = 100
+
+// maxFeedLinks bounds the number of feeds read from the links of a web page.
+const maxFeedLinks = 5
+
+var (
+	// ErrNoFeed is returned when a URL is neither a feed nor a page that links to one.
+	ErrNoFeed = errors.New("no feed found")
+	// errNotFeed is returned by parseFeed for other documents
+	errNotFeed = errors.New("not a feed")
+)
+
+// FeedItem is an entry of an RSS, Atom or JSON feed.
+type FeedItem struct {
+	// ID is the guid or id of the entry, or its URL if it has none.
+	ID    string `json:"id"`
+	URL   string `json:"url,omitempty"`
+	Title string `json:"title,omitempty"`
+	// Summary and Content are the HTML, or plain text, of the entry as the feed gives them.
+	Summary     string      `json:"summary,omitempty"`
+	Content     string      `json:"content,omitempty"`
+	Authors     []string    `json:"authors,omitempty"`
+	Categories  []string    `json:"categories,omitempty"`
+	PublishedAt *time.Time  `json:"publishedAt,omitempty"`
+	UpdatedAt   *time.Time  `json:"updatedAt,omitempty"`
+	Enclosures  []Enclosure `json:"enclosures,omitempty"`
+	// FeedURL is the address of the feed the entry was read from.
+	FeedURL string `json:"feedUrl"`
+}
+
+// Enclosure is a file attached to a feed entry, such as a podcast episode.
+type Enclosure struct {
+	URL    string `json:"url"`
+	Type   string `json:"type,omitempty"`
+	Length int64  `json:"length,omitempty"`
+}
+
+// FeedOptions select the entries ReadFeeds returns.
+type FeedOptions struct {
+	// Since, if set, skips the entries that were not published or updated after it. Entries
+	// without dates are kept.
+	Since time.Time
+	// Limit is the number of entries to return, the newest first. DefaultFeedLimit if zero.
+	Limit int
+}
+
+// FeedResult holds the feeds that were read and their entries, the newest first.
+type FeedResult struct {
+	Feeds []Feed     `json:"feeds"`
+	Items []FeedItem `json:"items"`
+}
+
+// ReadFeeds reads the RSS, Atom or JSON feed at uri. If uri is a web page, the feeds it links to
+// are read instead. The requests are subject to the DefaultPolicy.
+func ReadFeeds(ctx context.Context, uri string, opts FeedOptions) (*FeedResult, error) {
+	start, err := url.Parse(uri)
+	if err != nil {
+		return nil, err
+	}
+	policy := DefaultPolicy()
+	feeds, err := loadFeeds(ctx, newFetcher(policy, policy.transport(), 0), start)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = DefaultFeedLimit
+	}
+	result := &FeedResult{Feeds: make([]Feed, 0, len(feeds)), Items: []FeedItem{}}
+	for _, feed := range feeds {
+		result.Feeds = append(result.Feeds, feed.Feed)
+		for _, item := range feed.Items {
+			if changedSince(item.lastChange(), opts.Since) {
+				result.Items = append(result.Items, item)
+			}
+		}
+	}
+	sort.SliceStable(result.Items, func(i, j int) bool {
+		return newer(result.Items[i].lastChange(), result.Items[j].lastChange())
+	})
+	if len(result.Items) > limit {
+		result.Items = result.Items[:limit]
+	}
+	return result, nil
+}
+
+// lastChange returns the update date of the entry, or its publication date.
+func (item FeedItem) lastChange() *time.Time {
+	if item.UpdatedAt != nil {
+		return item.UpdatedAt
+	}
+	return item.PublishedAt
+}
+
+// changedSince reports whether a date is after since. Unknown dates and a zero since always are.
+func changedSince(date *time.Time, since time.Time) bool {
+	return since.IsZero() || date == nil || date.After(since)
+}
+
+// newer orders dates from the newest to the oldest, unknown dates last.
+func newer(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a != nil
+	}
+	return a.After(*b)
+}
+
+// parsedFeed is a feed with its entries.
+type parsedFeed struct {
+	Feed
+	Items []FeedItem
+}
+
+// loadFeeds reads the feed at start, or the feeds a web page at start links to.
+func loadFeeds(ctx context.Context, f *fetcher, start *url.URL) ([]*parsedFeed, error) {
+	page, err := f.get(ctx, start)
+	if err != nil {
+		return nil, err
+	}
+	feed, err := parseFeed(page.Body, page.URL)
+	if err == nil {
+		return []*parsedFeed{feed}, nil
+	}
+	if !errors.Is(err, errNotFeed) {
+		return nil, fmt.Errorf("%s: %w", page.URL, err)
+	}
+
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(page.Body))
+	if err != nil {
+		return nil, fmt.Errorf("%w at %s", ErrNoFeed, start)
+	}
+	var loaded []*parsedFeed
+	for i, link := range feeds(doc.Selection, page.URL) {
+		if i == maxFeedLinks {
+			break
+		}
+		feedURL, err := url.Parse(link.URL)
+		if err != nil {
+			continue
+		}
+		page, err := f.get(ctx, feedURL)
+		if err != nil {
+			logrus.Warnf("[-] Unable to read feed %s: %v", feedURL, err)
+			continue
+		}
+		feed, err := parseFeed(page.Body, page.URL)
+		if err != nil {
+			logrus.Warnf("[-] Unable to parse feed %s: %v", feedURL, err)
+			continue
+		}
+		if feed.Title == "" {
+			feed.Title = link.Title
+		}
+		loaded = append(loaded, feed)
+	}
+	if len(loaded) == 0 {
+		return nil, fmt.Errorf("%w at %s", ErrNoFeed, start)
+	}
+	return loaded, nil
+}
+
+// parseFeed parses an RSS 2.0, RSS 1.0, Atom or JSON feed. It returns errNotFeed for other
+// documents. Relative links are resolved against the address of the feed.
+func parseFeed(body []byte, feedURL *url.URL) (*parsedFeed, error) {
+	trimmed := bytes.TrimSpace(bytes.TrimPrefix(body, []byte("\xef\xbb\xbf")))
+	if bytes.HasPrefix(trimmed, []byte("{")) {
+		return parseJSONFeed(trimmed, feedURL)
+	}
+	switch root, _ := xmlRoot(body); root {
+	case "rss", "RDF":
+		return parseRSS(body, feedURL)
+	case "feed":
+		return parseAtom(body, feedURL)
+	default:
+		return nil, errNotFeed
+	}
+}
+
+// xmlDecoder returns a decoder that accepts the character sets feeds and sitemaps are served in,
+// and the HTML entities some of them use.
+func xmlDecoder(r io.Reader) *xml.Decoder {
+	d := xml.NewDecoder(r)
+	d.CharsetReader = charset.NewReaderLabel
+	d.Strict = false
+	d.Entity = xml.HTMLEntity
+	return d
+}
+
+// xmlRoot returns the local name of the root element of an XML document.
+func xmlRoot(body []byte) (string, error) {
+	d := xmlDecoder(bytes.NewReader(body))
+	for {
+		token, err := d.Token()
+		if err != nil {
+			return "", err
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name.Local, nil
+		}
+	}
+}
+
+type rssDocument struct {
+	Channel struct {
+		Title string    `xml:"title"`
+		Items []rssItem `xml:"item"`
+	} `xml:"channel"`
+	// RSS 1.0 lists the items next to the channel
+	Items []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string    `xml:"title"`
+	Links       []xmlLink `xml:"link"`
+	GUID        string    `xml:"guid"`
+	About       string    `xml:"http://www.w3.org/1999/02/22-rdf-syntax-ns# about,attr"`
+	Description string    `xml:"description"`
+	Content     string    `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	PubDate     string    `xml:"pubDate"`
+	Date        string    `xml:"http://purl.org/dc/elements/1.1/ date"`
+	Updated     string    `xml:"http://www.w3.org/2005/Atom updated"`
+	Author      string    `xml:"author"`
+	Creators    []string  `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Categories  []string  `xml:"category"`
+	Enclosures  []struct {
+		URL    string `xml:"url,attr"`
+		Type   string `xml:"type,attr"`
+		Length string `xml:"length,attr"`
+	} `xml:"enclosure"`
+}
+
+// xmlLink is an RSS link, given as text, or an Atom link, given as attributes. RSS feeds often
+// hold both.
+type xmlLink struct {
+	Text   string `xml:",chardata"`
+	Href   string `xml:"href,attr"`
+	Rel    string `xml:"rel,attr"`
+	Type   string `xml:"type,attr"`
+	Length string `xml:"length,attr"`
+}
+
+func parseRSS(body []byte, feedURL *url.URL) (*parsedFeed, error) {
+	var doc rssDocument
+	if err := xmlDecoder(bytes.NewReader(body)).Decode(&doc); err != nil {
+		return nil, err
+	}
+	feed := &parsedFeed{Feed: Feed{URL: feedURL.String(), Type: FeedRSS, Title: cleanText(doc.Channel.Title)}}
+	items := doc.Channel.Items
+	if len(doc.Items) > 0 {
+		feed.Type = FeedRDF
+		items = doc.Items
+	}
+	for _, entry := range items {
+		item := FeedItem{
+			ID:          strings.TrimSpace(entry.GUID),
+			Title:       cleanText(entry.Title),
+			Summary:     strings.TrimSpace(entry.Description),
+			Content:     strings.TrimSpace(entry.Content),
+			Categories:  trimAll(entry.Categories),
+			PublishedAt: parseDate(firstNonEmpty(entry.PubDate, entry.Date)),
+			UpdatedAt:   parseDate(entry.Updated),
+			FeedURL:     feed.URL,
+		}
+		for _, link := range entry.Links {
+			if href := firstNonEmpty(link.Text, link.Href); href != "" && (link.Rel == "" || link.Rel == "alternate") {
+				item.URL = resolveAny(feedURL, href)
+				break
+			}
+		}
+		if item.URL == "" && entry.About != "" {
+			item.URL = resolveAny(feedURL, entry.About)
+		}
+		item.Authors = appendNames(item.Authors, rssAuthor(entry.Author))
+		for _, creator := range entry.Creators {
+			item.Authors = appendNames(item.Authors, creator)
+		}
+		for _, enclosure := range entry.Enclosures {
+			item.Enclosures = appendEnclosure(item.Enclosures, feedURL, enclosure.URL, enclosure.Type, enclosure.Length)
+		}
+		feed.Items = append(feed.Items, item.withID())
+	}
+	return feed, nil
+}
+
+// rssAuthor returns the name of an RSS author, given as "email (Name)" or as either of them.
+func rssAuthor(author string) string {
+	if open := strings.Index(author, "("); open >= 0 && strings.HasSuffix(strings.TrimSpace(author), ")") {
+		return strings.TrimSuffix(strings.TrimSpace(author[open+1:]), ")")
+	}
+	return author
+}
+
+type atomFeed struct {
+	Title   atomText    `xml:"title"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomEntry struct {
+	ID        string    `xml:"id"`
+	Title     atomText  `xml:"title"`
+	Links     []xmlLink `xml:"link"`
+	Summary   atomText  `xml:"summary"`
+	Content   atomText  `xml:"content"`
+	Published string    `xml:"published"`
+	Updated   string    `xml:"updated"`
+	Authors   []struct {
+		Name string `xml:"name"`
+	} `xml:"author"`
+	Categories []struct {
+		Term  string `xml:"term,attr"`
+		Label string `xml:"label,attr"`
+	} `xml:"category"`
+}
+
+// atomText is an Atom text construct: text, escaped HTML or inline XHTML.
+type atomText struct {
+	Type  string `xml:"type,attr"`
+	Text  string `xml:",chardata"`
+	Inner string `xml:",innerxml"`
+}
+
+func (t atomText) String() string {
+	if t.Type == "xhtml" {
+		return strings.TrimSpace(t.Inner)
+	}
+	return strings.TrimSpace(t.Text)
+}
+
+func parseAtom(body []byte, feedURL *url.URL) (*parsedFeed, error) {
+	var doc atomFeed
+	if err := xmlDecoder(bytes.NewReader(body)).Decode(&doc); err != nil {
+		return nil, err
+	}
+	feed := &parsedFeed{Feed: Feed{URL: feedURL.String(), Type: FeedAtom, Title: cleanText(doc.Title.String())}}
+	for _, entry := range doc.Entries {
+		item := FeedItem{
+			ID:          strings.TrimSpace(entry.ID),
+			Title:       cleanText(entry.Title.String()),
+			Summary:     entry.Summary.String(),
+			Content:     entry.Content.String(),
+			PublishedAt: parseDate(entry.Published),
+			UpdatedAt:   parseDate(entry.Updated),
+			FeedURL:     feed.URL,
+		}
+		for _, link := range entry.Links {
+			switch link.Rel {
+			case "", "alternate":
+				if item.URL == "" {
+					item.URL = resolveAny(feedURL, link.Href)
+				}
+			case "enclosure":
+				item.Enclosures = appendEnclosure(item.Enclosures, feedURL, link.Href, link.Type, link.Length)
+			}
+		}
+		for _, author := range entry.Authors {
+			item.Authors = appendNames(item.Authors, author.Name)
+		}
+		for _, category := range entry.Categories {
+			if name := strings.TrimSpace(firstNonEmpty(category.Label, category.Term)); name != "" {
+				item.Categories = append(item.Categories, name)
+			}
+		}
+		feed.Items = append(feed.Items, item.withID())
+	}
+	return feed, nil
+}
+
+type jsonFeed struct {
+	Version string `json:"version"`
+	Title   string `json:"title"`
+	Items   []struct {
+		ID            any          `json:"id"`
+		URL           string       `json:"url"`
+		ExternalURL   string       `json:"external_url"`
+		Title         string       `json:"title"`
+		ContentHTML   string       `json:"content_html"`
+		ContentText   string       `json:"content_text"`
+		Summary       string       `json:"summary"`
+		DatePublished string       `json:"date_published"`
+		DateModified  string       `json:"date_modified"`
+		Author        *jsonAuthor  `json:"author"`
+		Authors       []jsonAuthor `json:"authors"`
+		Tags          []string     `json:"tags"`
+		Attachments   []struct {
+			URL         string `json:"url"`
+			MimeType    string `json:"mime_type"`
+			SizeInBytes int64  `json:"size_in_bytes"`
+		} `json:"attachments"`
+	} `json:"items"`
+}
+
+type jsonAuthor struct {
+	Name string `json:"name"`
+}
+
+func parseJSONFeed(body []byte, feedURL *url.URL) (*parsedFeed, error) {
+	var doc jsonFeed
+	if err := json.Unmarshal(body, &doc); err != nil || !strings.HasPrefix(doc.Version, "https://jsonfeed.org/") {
+		return nil, errNotFeed
+	}
+	feed := &parsedFeed{Feed: Feed{URL: feedURL.String(), Type: FeedJSON, Title: cleanText(doc.Title)}}
+	for _, entry := range doc.Items {
+		item := FeedItem{
+			URL:         resolveAny(feedURL, firstNonEmpty(entry.URL, entry.ExternalURL)),
+			Title:       cleanText(entry.Title),
+			Summary:     strings.TrimSpace(entry.Summary),
+			Content:     strings.TrimSpace(firstNonEmpty(entry.ContentHTML, entry.ContentText)),
+			Categories:  trimAll(entry.Tags),
+			PublishedAt: parseDate(entry.DatePublished),
+			UpdatedAt:   parseDate(entry.DateModified),
+			FeedURL:     feed.URL,
+		}
+		// Version 1 allowed numbers as IDs
+		if entry.ID != nil {
+			item.ID = strings.TrimSpace(fmt.Sprint(entry.ID))
+		}
+		if entry.Author != nil {
+			item.Authors = appendNames(item.Authors, entry.Author.Name)
+		}
+		for _, author := range entry.Authors {
+			item.Authors = appendNames(item.Authors, author.Name)
+		}
+		for _, attachment := range entry.Attachments {
+			item.Enclosures = appendEnclosure(item.Enclosures, feedURL, attachment.URL, attachment.MimeType, strconv.FormatInt(attachment.SizeInBytes, 10))
+		}
+		feed.Items = append(feed.Items, item.withID())
+	}
+	return feed, nil
+}
+
+// withID sets the ID of an entry without one to its URL.
+func (item FeedItem) withID() FeedItem {
+	if item.ID == "" {
+		item.ID = item.URL
+	}
+	return item
+}
+
+func appendEnclosure(enclosures []Enclosure, base *url.URL, href, mediaType, length string) []Enclosure {
+	if strings.TrimSpace(href) == "" {
+		return enclosures
+	}
+	size, _ := strconv.ParseInt(strings.TrimSpace(length), 10, 64)
+	return append(enclosures, Enclosure{URL: resolveAny(base, href), Type: strings.TrimSpace(mediaType), Length: size})
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+func trimAll(values []string) []string {
+	var trimmed []string
+	for _, value := range values {
+		if value = cleanText(value); value != "" {
+			trimmed = append(trimmed, value)
+		}
+	}
+	return trimmed
+}
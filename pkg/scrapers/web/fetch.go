@@ -0,0 +1,116 @@
+package web
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// maxFetchBytes bounds the size of a sitemap, feed or robots.txt file.
+const maxFetchBytes = 10 << 20
+
+// fetcher downloads the sitemaps, feeds and robots.txt files of a site outside of the crawl,
+// subject to the same policy.
+type fetcher struct {
+	policy   Policy
+	client   *http.Client
+	maxBytes int
+	// userAgent is the User-Agent header of the requests
+	userAgent string
+	// onBytes, if set, is called with the size of every response
+	onBytes func(int)
+}
+
+func newFetcher(policy Policy, transport http.RoundTripper, maxBytes int) *fetcher {
+	if maxBytes <= 0 || maxBytes > maxFetchBytes {
+		maxBytes = maxFetchBytes
+	}
+	return &fetcher{
+		policy:    policy,
+		client:    &http.Client{Transport: transport, CheckRedirect: policy.checkRedirect},
+		maxBytes:  maxBytes,
+		userAgent: DefaultPoliteness().userAgent(),
+	}
+}
+
+// errNotModified is returned by a conditional request when the file did not change.
+var errNotModified = errors.New("not modified")
+
+// fetched is a downloaded file, URL is its address after redirects.
+type fetched struct {
+	URL    *url.URL
+	Header http.Header
+	Body   []byte
+}
+
+// get downloads a file. Gzip compressed files, such as sitemap.xml.gz, are decompressed.
+func (f *fetcher) get(ctx context.Context, u *url.URL) (*fetched, error) {
+	return f.getIfChanged(ctx, u, "", "")
+}
+
+// getIfChanged downloads a file unless it still has the given ETag or was not modified after
+// lastModified, an HTTP date, in which case it returns errNotModified.
+func (f *fetcher) getIfChanged(ctx context.Context, u *url.URL, etag, lastModified string) (*fetched, error) {
+	if err := f.policy.CheckURL(u); err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", f.userAgent)
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	if lastModified != "" {
+		req.Header.Set("If-Modified-Since", lastModified)
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		if violation := policyViolation(err, u); violation != nil {
+			return nil, violation
+		}
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified && (etag != "" || lastModified != "") {
+		return nil, errNotModified
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", u, resp.Status)
+	}
+
+	body, err := f.read(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("GET %s: %w", u, err)
+	}
+	if bytes.HasPrefix(body, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("GET %s: %w", u, err)
+		}
+		if body, err = f.read(gz); err != nil {
+			return nil, fmt.Errorf("GET %s: %w", u, err)
+		}
+	}
+	return &fetched{URL: resp.Request.URL, Header: resp.Header, Body: body}, nil
+}
+
+func (f *fetcher) read(r io.Reader) ([]byte, error) {
+	body, err := io.ReadAll(io.LimitReader(r, int64(f.maxBytes)+1))
+	if f.onBytes != nil {
+		f.onBytes(len(body))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > f.maxBytes {
+		return nil, fmt.Errorf("larger than %d bytes", f.maxBytes)
+	}
+	return body, nil
+}
@@ -0,0 +1,157 @@
+package web
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// Kinds of the files a crawl reaches that are not HTML pages.
+const (
+	FilePDF    = "pdf"    // A PDF document, its text is extracted
+	FileText   = "text"   // A text document, such as text/plain, CSV or XML, returned as it is
+	FileJSON   = "json"   // A JSON document, returned as it is
+	FileBinary = "binary" // Anything else, only described
+)
+
+// File is a response of a crawl that is not an HTML page.
+type File struct {
+	URL string `json:"url"`
+	// ContentType is the media type of the file, from the Content-Type header or sniffed from its
+	// content when the header is missing or generic.
+	ContentType string `json:"contentType"`
+	Kind        string `json:"kind"`
+	Size        int    `json:"size"`
+	// SHA256 is the hex encoded hash of the content of the file.
+	SHA256 string `json:"sha256"`
+	// Title and Author are read from the document information of PDFs.
+	Title  string `json:"title,omitempty"`
+	Author string `json:"author,omitempty"`
+	Pages  int    `json:"pages,omitempty"`
+	// Text is the text of a PDF or the content of a text document.
+	Text string `json:"text,omitempty"`
+	// JSON is the content of a JSON document.
+	JSON json.RawMessage `json:"json,omitempty"`
+	// Error tells why the text of a PDF, or the content of a JSON document, could not be read.
+	Error string `json:"error,omitempty"`
+}
+
+// ReadFile describes a file and extracts its text. pageURL is the address of the file and
+// contentType the value of its Content-Type header, if any.
+func ReadFile(body []byte, pageURL string, contentType string) File {
+	sum := sha256.Sum256(body)
+	file := File{
+		URL:         pageURL,
+		ContentType: fileType(body, contentType),
+		Size:        len(body),
+		SHA256:      hex.EncodeToString(sum[:]),
+	}
+
+	switch {
+	case file.ContentType == "application/pdf":
+		file.Kind = FilePDF
+		if err := readPDF(body, &file); err != nil {
+			file.Error = err.Error()
+		}
+	case file.ContentType == "application/json" || strings.HasSuffix(file.ContentType, "+json"):
+		file.Kind = FileJSON
+		if json.Valid(body) {
+			file.JSON = json.RawMessage(body)
+		} else {
+			file.Error = "invalid JSON"
+		}
+	case textType(file.ContentType) && utf8.Valid(body):
+		file.Kind = FileText
+		file.Text = string(body)
+	default:
+		file.Kind = FileBinary
+	}
+	return file
+}
+
+// isHTML reports whether a response is an HTML page, which the OnHTML callbacks of the
+// collector handle, the same way colly does.
+func isHTML(contentType string) bool {
+	return strings.Contains(strings.ToLower(contentType), "html")
+}
+
+// fileType returns the media type of a file. Files without a Content-Type, or with a generic one,
+// are sniffed.
+func fileType(body []byte, contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType == "application/octet-stream" || mediaType == "binary/octet-stream" {
+		if bytes.HasPrefix(body, []byte("%PDF-")) {
+			return "application/pdf"
+		}
+		mediaType, _, _ = mime.ParseMediaType(http.DetectContentType(body))
+	}
+	return mediaType
+}
+
+func textType(mediaType string) bool {
+	switch {
+	case strings.HasPrefix(mediaType, "text/"), strings.HasSuffix(mediaType, "xml"):
+		return true
+	default:
+		switch mediaType {
+		case "application/javascript", "application/x-ndjson", "application/csv", "application/yaml", "application/x-yaml":
+			return true
+		}
+		return false
+	}
+}
+
+// readPDF sets the text, page count and document information of a PDF file. The text of every
+// page is read line by line, from top to bottom, and the pages are separated by blank lines.
+func readPDF(body []byte, file *File) (err error) {
+	// The parser panics on some malformed files
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("unable to read PDF: %v", r)
+		}
+	}()
+
+	reader, err := pdf.NewReader(bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		return fmt.Errorf("unable to read PDF: %w", err)
+	}
+	info := reader.Trailer().Key("Info")
+	file.Title = strings.TrimSpace(info.Key("Title").Text())
+	file.Author = strings.TrimSpace(info.Key("Author").Text())
+	file.Pages = reader.NumPage()
+
+	pages := make([]string, 0, file.Pages)
+	for i := 1; i <= file.Pages; i++ {
+		page := reader.Page(i)
+		if page.V.IsNull() {
+			continue
+		}
+		rows, err := page.GetTextByRow()
+		if err != nil {
+			return fmt.Errorf("unable to read page %d of PDF: %w", i, err)
+		}
+		lines := make([]string, 0, len(rows))
+		for _, row := range rows {
+			var line strings.Builder
+			for _, text := range row.Content {
+				line.WriteString(text.S)
+			}
+			if text := strings.TrimSpace(line.String()); text != "" {
+				lines = append(lines, text)
+			}
+		}
+		if len(lines) > 0 {
+			pages = append(pages, strings.Join(lines, "\n"))
+		}
+	}
+	file.Text = strings.Join(pages, "\n\n")
+	return nil
+}
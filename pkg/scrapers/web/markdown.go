@@ -0,0 +1,435 @@
+package web
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// renderer converts HTML to Markdown or to plain text. Both keep the headings, paragraphs, lists,
+// tables and code blocks; the plain text leaves out the markup, links and images.
+type renderer struct {
+	markdown bool
+	base     *url.URL
+}
+
+var blockElements = map[atom.Atom]bool{
+	atom.Address: true, atom.Article: true, atom.Aside: true, atom.Blockquote: true, atom.Center: true,
+	atom.Details: true, atom.Dialog: true, atom.Dd: true, atom.Div: true, atom.Dl: true, atom.Dt: true,
+	atom.Fieldset: true, atom.Figcaption: true, atom.Figure: true, atom.Footer: true, atom.Form: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Header: true, atom.Hgroup: true, atom.Hr: true, atom.Li: true, atom.Main: true, atom.Nav: true,
+	atom.Ol: true, atom.P: true, atom.Pre: true, atom.Section: true, atom.Summary: true, atom.Table: true,
+	atom.Ul: true,
+}
+
+var skippedElements = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Template: true, atom.Head: true,
+	atom.Svg: true, atom.Iframe: true, atom.Button: true, atom.Input: true, atom.Select: true,
+	atom.Textarea: true, atom.Object: true, atom.Embed: true,
+}
+
+// blocks renders the children of n as blocks, which are separated by blank lines in the output.
+func (r *renderer) blocks(n *html.Node) []string {
+	var blocks []string
+	var inline strings.Builder
+	flush := func() {
+		if text := cleanInline(inline.String()); text != "" {
+			blocks = append(blocks, text)
+		}
+		inline.Reset()
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && skippedElements[c.DataAtom] {
+			continue
+		}
+		if c.Type == html.ElementNode && blockElements[c.DataAtom] {
+			flush()
+			blocks = append(blocks, r.block(c)...)
+			continue
+		}
+		r.inline(c, &inline)
+	}
+	flush()
+	return blocks
+}
+
+func (r *renderer) block(n *html.Node) []string {
+	switch n.DataAtom {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		text := strings.ReplaceAll(r.inlineText(n), "\n", " ")
+		if text == "" {
+			return nil
+		}
+		if r.markdown {
+			level := int(n.Data[1] - '0')
+			text = strings.Repeat("#", level) + " " + text
+		}
+		return []string{text}
+	case atom.Ul, atom.Ol:
+		if list := r.list(n); list != "" {
+			return []string{list}
+		}
+		return nil
+	case atom.Pre:
+		if strings.TrimSpace(textContent(n)) == "" {
+			return nil
+		}
+		return []string{r.pre(n)}
+	case atom.Blockquote:
+		quote := strings.Join(r.blocks(n), "\n\n")
+		if quote == "" {
+			return nil
+		}
+		if r.markdown {
+			quote = prefixLines(quote, "> ", ">")
+		}
+		return []string{quote}
+	case atom.Table:
+		if table := r.table(n); table != "" {
+			return []string{table}
+		}
+		return nil
+	case atom.Hr:
+		if r.markdown {
+			return []string{"---"}
+		}
+		return nil
+	case atom.Dl:
+		if list := r.definitions(n); list != "" {
+			return []string{list}
+		}
+		return nil
+	default:
+		return r.blocks(n)
+	}
+}
+
+// inline renders the inline content of n to b. Whitespace is collapsed, line breaks are only
+// kept for br elements.
+func (r *renderer) inline(n *html.Node, b *strings.Builder) {
+	switch n.Type {
+	case html.TextNode:
+		b.WriteString(collapseSpaces(n.Data))
+		return
+	case html.ElementNode:
+	default:
+		return
+	}
+	if skippedElements[n.DataAtom] {
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.Br:
+		b.WriteString("\n")
+	case atom.A:
+		href := r.resolve(attr(n, "href"))
+		if !r.markdown || href == "" {
+			r.children(n, b)
+			return
+		}
+		if text := strings.ReplaceAll(r.inlineText(n), "\n", " "); text != "" {
+			r.wrapSpaces(n, b, "["+text+"]("+href+")")
+		}
+	case atom.Img:
+		src := r.resolve(firstAttr(n, "src", "data-src"))
+		if r.markdown && src != "" {
+			fmt.Fprintf(b, "![%s](%s)", cleanText(attr(n, "alt")), src)
+		}
+	case atom.Strong, atom.B:
+		r.emphasis(n, b, "**")
+	case atom.Em, atom.I:
+		r.emphasis(n, b, "*")
+	case atom.Del, atom.S, atom.Strike:
+		r.emphasis(n, b, "~~")
+	case atom.Code, atom.Kbd, atom.Samp, atom.Tt:
+		if text := cleanText(textContent(n)); text != "" && r.markdown {
+			r.wrapSpaces(n, b, "`"+text+"`")
+		} else {
+			b.WriteString(collapseSpaces(textContent(n)))
+		}
+	default:
+		r.children(n, b)
+	}
+}
+
+func (r *renderer) children(n *html.Node, b *strings.Builder) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		r.inline(c, b)
+	}
+}
+
+// inlineText returns the inline content of n with collapsed whitespace.
+func (r *renderer) inlineText(n *html.Node) string {
+	var b strings.Builder
+	r.children(n, &b)
+	return cleanInline(b.String())
+}
+
+// emphasis wraps the inline content of n in the Markdown marker.
+func (r *renderer) emphasis(n *html.Node, b *strings.Builder, marker string) {
+	if !r.markdown {
+		r.children(n, b)
+		return
+	}
+	if text := r.inlineText(n); text != "" {
+		r.wrapSpaces(n, b, marker+text+marker)
+	}
+}
+
+// wrapSpaces writes text with the leading and trailing whitespace of the content of n, which
+// the Markdown markup would otherwise swallow.
+func (r *renderer) wrapSpaces(n *html.Node, b *strings.Builder, text string) {
+	raw := textContent(n)
+	if strings.TrimLeft(raw, " \t\r\n") != raw {
+		b.WriteString(" ")
+	}
+	b.WriteString(text)
+	if strings.TrimRight(raw, " \t\r\n") != raw {
+		b.WriteString(" ")
+	}
+}
+
+func (r *renderer) list(n *html.Node) string {
+	ordered := n.DataAtom == atom.Ol
+	number := 1
+	if start, err := strconv.Atoi(attr(n, "start")); err == nil {
+		number = start
+	}
+	var items []string
+	for li := n.FirstChild; li != nil; li = li.NextSibling {
+		if li.Type != html.ElementNode || li.DataAtom != atom.Li {
+			continue
+		}
+		marker := "- "
+		if ordered {
+			marker = strconv.Itoa(number) + ". "
+			number++
+		}
+		content := strings.Join(r.blocks(li), "\n")
+		if content == "" {
+			continue
+		}
+		items = append(items, marker+prefixLines(content, strings.Repeat(" ", len(marker)), "")[len(marker):])
+	}
+	return strings.Join(items, "\n")
+}
+
+func (r *renderer) definitions(n *html.Node) string {
+	var lines []string
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode {
+			continue
+		}
+		text := strings.Join(r.blocks(c), "\n")
+		if text == "" {
+			continue
+		}
+		switch {
+		case c.DataAtom == atom.Dt && r.markdown:
+			lines = append(lines, "**"+text+"**")
+		case c.DataAtom == atom.Dd && r.markdown:
+			lines = append(lines, ": "+prefixLines(text, "  ", "")[2:])
+		default:
+			lines = append(lines, text)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (r *renderer) pre(n *html.Node) string {
+	code := strings.Trim(textContent(n), "\n")
+	if !r.markdown {
+		return code
+	}
+	language := codeLanguage(attr(n, "class"))
+	for c := n.FirstChild; c != nil && language == ""; c = c.NextSibling {
+		if c.Type == html.ElementNode && c.DataAtom == atom.Code {
+			language = codeLanguage(attr(c, "class"))
+		}
+	}
+	fence := "```"
+	for strings.Contains(code, fence) {
+		fence += "`"
+	}
+	return fence + language + "\n" + code + "\n" + fence
+}
+
+// codeLanguage returns the language of a code block from its "language-go" or "lang-go" class.
+func codeLanguage(class string) string {
+	for _, name := range strings.Fields(class) {
+		for _, prefix := range []string{"language-", "lang-"} {
+			if strings.HasPrefix(name, prefix) {
+				return strings.TrimPrefix(name, prefix)
+			}
+		}
+	}
+	return ""
+}
+
+func (r *renderer) table(n *html.Node) string {
+	var rows [][]string
+	var caption string
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode {
+				continue
+			}
+			switch c.DataAtom {
+			case atom.Caption:
+				caption = strings.ReplaceAll(r.inlineText(c), "\n", " ")
+			case atom.Thead, atom.Tbody, atom.Tfoot:
+				walk(c)
+			case atom.Tr:
+				var row []string
+				for cell := c.FirstChild; cell != nil; cell = cell.NextSibling {
+					if cell.Type == html.ElementNode && (cell.DataAtom == atom.Td || cell.DataAtom == atom.Th) {
+						row = append(row, r.cell(cell))
+					}
+				}
+				if len(row) > 0 {
+					rows = append(rows, row)
+				}
+			}
+		}
+	}
+	walk(n)
+	if len(rows) == 0 {
+		return caption
+	}
+
+	columns := 0
+	for _, row := range rows {
+		columns = max(columns, len(row))
+	}
+	var lines []string
+	if caption != "" {
+		lines = append(lines, caption, "")
+	}
+	for i, row := range rows {
+		for len(row) < columns {
+			row = append(row, "")
+		}
+		if !r.markdown {
+			lines = append(lines, strings.Join(row, "\t"))
+			continue
+		}
+		lines = append(lines, "| "+strings.Join(row, " | ")+" |")
+		if i == 0 {
+			lines = append(lines, "|"+strings.Repeat(" --- |", columns))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// cell returns the content of a table cell on one line.
+func (r *renderer) cell(n *html.Node) string {
+	text := strings.Join(strings.Fields(strings.Join(r.blocks(n), " ")), " ")
+	if r.markdown {
+		text = strings.ReplaceAll(text, "|", `\|`)
+	}
+	return text
+}
+
+// resolve returns the absolute URL of a link, or an empty string for links within the page and
+// links that are not http, https or mailto.
+func (r *renderer) resolve(href string) string {
+	href = strings.TrimSpace(href)
+	if href == "" || strings.HasPrefix(href, "#") {
+		return ""
+	}
+	u, err := r.base.Parse(href)
+	if err != nil {
+		return ""
+	}
+	switch u.Scheme {
+	case "http", "https", "mailto":
+		return u.String()
+	default:
+		return ""
+	}
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func firstAttr(n *html.Node, keys ...string) string {
+	for _, key := range keys {
+		if value := attr(n, key); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// textContent returns the text of n and its descendants as is.
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		b.WriteString(textContent(c))
+	}
+	return b.String()
+}
+
+// cleanText collapses whitespace, line breaks included.
+func cleanText(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// collapseSpaces replaces each run of whitespace, line breaks included, with a single space.
+func collapseSpaces(text string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range text {
+		if unicode.IsSpace(r) {
+			if !space {
+				b.WriteByte(' ')
+			}
+			space = true
+			continue
+		}
+		space = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// cleanInline collapses whitespace within lines and drops empty lines. Line breaks come from br elements.
+func cleanInline(text string) string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = cleanText(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// prefixLines prefixes each line of text, empty lines with emptyPrefix.
+func prefixLines(text, prefix, emptyPrefix string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if line == "" {
+			lines[i] = emptyPrefix
+		} else {
+			lines[i] = prefix + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,388 @@
+package web
+
+import (
+	"encoding/json"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/sirupsen/logrus"
+)
+
+// Metadata is the structured data a page embeds for search engines and social networks.
+type Metadata struct {
+	URL string `json:"url"`
+	// JSONLD holds the JSON-LD objects of the page. Top-level arrays and @graph lists are
+	// flattened into separate objects.
+	JSONLD []map[string]any `json:"jsonLd,omitempty"`
+	// OpenGraph holds the og:, article:, book: and profile: meta tags, Twitter the twitter: meta
+	// tags, keyed by property. The first value of a repeated property is kept.
+	OpenGraph map[string]string `json:"openGraph,omitempty"`
+	Twitter   map[string]string `json:"twitter,omitempty"`
+	// Microdata holds the top-level items, in the JSON format of the HTML microdata specification.
+	Microdata []MicrodataItem `json:"microdata,omitempty"`
+	// Feeds are the RSS, Atom and JSON feeds the page links to.
+	Feeds []Feed `json:"feeds,omitempty"`
+	// PublishedAt, ModifiedAt and Authors are read from the structured data above, the first
+	// source that has them wins.
+	PublishedAt *time.Time `json:"publishedAt,omitempty"`
+	ModifiedAt  *time.Time `json:"modifiedAt,omitempty"`
+	Authors     []string   `json:"authors,omitempty"`
+}
+
+// MicrodataItem is an itemscope element. Property values are strings or nested items.
+type MicrodataItem struct {
+	Type       []string         `json:"type,omitempty"`
+	ID         string           `json:"id,omitempty"`
+	Properties map[string][]any `json:"properties"`
+}
+
+// Feed is a feed linked with <link rel="alternate">.
+type Feed struct {
+	URL   string `json:"url"`
+	Type  string `json:"type"`
+	Title string `json:"title,omitempty"`
+}
+
+// feedTypes are the media types of the feeds in Metadata.Feeds.
+var feedTypes = map[string]bool{
+	"application/rss+xml":   true,
+	"application/atom+xml":  true,
+	"application/feed+json": true,
+	"application/rdf+xml":   true,
+}
+
+// ExtractMetadata parses an HTML page and returns its structured data. pageURL is the address of
+// the page, relative links are resolved against it.
+func ExtractMetadata(r io.Reader, pageURL string) (Metadata, error) {
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return Metadata{}, err
+	}
+	doc, err := goquery.NewDocumentFromReader(r)
+	if err != nil {
+		return Metadata{}, err
+	}
+	return extractMetadata(doc.Selection, base), nil
+}
+
+func extractMetadata(page *goquery.Selection, base *url.URL) Metadata {
+	meta := Metadata{
+		URL:       base.String(),
+		JSONLD:    jsonLD(page),
+		Microdata: microdata(page, base),
+		Feeds:     feeds(page, base),
+	}
+	meta.OpenGraph, meta.Twitter = socialMeta(page)
+	meta.PublishedAt, meta.ModifiedAt = pageDates(page, meta)
+	meta.Authors = pageAuthors(page, meta)
+	return meta
+}
+
+func jsonLD(page *goquery.Selection) []map[string]any {
+	var objects []map[string]any
+	page.Find(`script[type="application/ld+json" i]`).Each(func(_ int, s *goquery.Selection) {
+		var value any
+		if err := json.Unmarshal([]byte(strings.TrimSpace(s.Text())), &value); err != nil {
+			logrus.Debugf("[-] Skipping invalid JSON-LD: %v", err)
+			return
+		}
+		objects = appendJSONLD(objects, value)
+	})
+	return objects
+}
+
+// appendJSONLD appends the objects of a JSON-LD value, flattening arrays and @graph lists.
+func appendJSONLD(objects []map[string]any, value any) []map[string]any {
+	switch v := value.(type) {
+	case []any:
+		for _, item := range v {
+			objects = appendJSONLD(objects, item)
+		}
+	case map[string]any:
+		if graph, ok := v["@graph"]; ok {
+			return appendJSONLD(objects, graph)
+		}
+		objects = append(objects, v)
+	}
+	return objects
+}
+
+// socialMeta returns the OpenGraph and Twitter Card meta tags. Sites use both the property and
+// the name attribute for either.
+func socialMeta(page *goquery.Selection) (openGraph, twitter map[string]string) {
+	openGraph, twitter = map[string]string{}, map[string]string{}
+	page.Find("meta[content]").Each(func(_ int, s *goquery.Selection) {
+		key := strings.ToLower(strings.TrimSpace(firstAttr(s.Nodes[0], "property", "name")))
+		content := strings.TrimSpace(attr(s.Nodes[0], "content"))
+		if key == "" || content == "" {
+			return
+		}
+		var target map[string]string
+		switch prefix, _, _ := strings.Cut(key, ":"); prefix {
+		case "og", "article", "book", "profile":
+			target = openGraph
+		case "twitter":
+			target = twitter
+		default:
+			return
+		}
+		if _, ok := target[key]; !ok {
+			target[key] = content
+		}
+	})
+	if len(openGraph) == 0 {
+		openGraph = nil
+	}
+	if len(twitter) == 0 {
+		twitter = nil
+	}
+	return openGraph, twitter
+}
+
+// microdata returns the items that are not properties of other items.
+func microdata(page *goquery.Selection, base *url.URL) []MicrodataItem {
+	var items []MicrodataItem
+	page.Find("[itemscope]").Not("[itemprop]").Each(func(_ int, s *goquery.Selection) {
+		items = append(items, microdataItem(s, base))
+	})
+	return items
+}
+
+func microdataItem(scope *goquery.Selection, base *url.URL) MicrodataItem {
+	item := MicrodataItem{
+		Type:       strings.Fields(attr(scope.Nodes[0], "itemtype")),
+		ID:         strings.TrimSpace(attr(scope.Nodes[0], "itemid")),
+		Properties: map[string][]any{},
+	}
+	scope.Find("[itemprop]").Each(func(_ int, s *goquery.Selection) {
+		// Properties of nested items belong to those
+		if owner := s.Parent().Closest("[itemscope]"); owner.Length() == 0 || owner.Nodes[0] != scope.Nodes[0] {
+			return
+		}
+		var value any
+		if s.Is("[itemscope]") {
+			value = microdataItem(s, base)
+		} else {
+			value = microdataValue(s, base)
+		}
+		for _, name := range strings.Fields(attr(s.Nodes[0], "itemprop")) {
+			item.Properties[name] = append(item.Properties[name], value)
+		}
+	})
+	return item
+}
+
+// microdataValue returns the value of a property element as defined by the HTML specification.
+func microdataValue(s *goquery.Selection, base *url.URL) string {
+	n := s.Nodes[0]
+	switch n.Data {
+	case "meta":
+		return attr(n, "content")
+	case "audio", "embed", "iframe", "img", "source", "track", "video":
+		return resolveAny(base, attr(n, "src"))
+	case "a", "area", "link":
+		return resolveAny(base, attr(n, "href"))
+	case "object":
+		return resolveAny(base, attr(n, "data"))
+	case "data", "meter":
+		return attr(n, "value")
+	case "time":
+		if datetime, ok := s.Attr("datetime"); ok {
+			return datetime
+		}
+	}
+	return cleanText(s.Text())
+}
+
+// resolveAny resolves a URL against base, keeping it as it is if it does not parse.
+func resolveAny(base *url.URL, ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return ""
+	}
+	u, err := base.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return u.String()
+}
+
+func feeds(page *goquery.Selection, base *url.URL) []Feed {
+	var list []Feed
+	page.Find(`link[rel~="alternate" i][href][type]`).Each(func(_ int, s *goquery.Selection) {
+		mediaType := strings.ToLower(strings.TrimSpace(attr(s.Nodes[0], "type")))
+		if !feedTypes[mediaType] {
+			return
+		}
+		list = append(list, Feed{
+			URL:   resolveAny(base, attr(s.Nodes[0], "href")),
+			Type:  mediaType,
+			Title: cleanText(attr(s.Nodes[0], "title")),
+		})
+	})
+	return list
+}
+
+// Keys of the publication and modification dates in meta tags, JSON-LD and microdata.
+var (
+	publishedKeys = []string{"article:published_time", "og:published_time", "datepublished", "date", "pubdate",
+		"publish_date", "dc.date", "dc.date.issued", "dcterms.created", "dcterms.issued"}
+	modifiedKeys = []string{"article:modified_time", "og:updated_time", "datemodified", "last-modified",
+		"dcterms.modified"}
+)
+
+// pageDates returns the publication and modification dates from JSON-LD, meta tags, microdata or
+// time elements, in that order.
+func pageDates(page *goquery.Selection, meta Metadata) (published, modified *time.Time) {
+	for _, object := range meta.JSONLD {
+		if published == nil {
+			published = parseDate(jsonString(object["datePublished"]))
+		}
+		if modified == nil {
+			modified = parseDate(jsonString(object["dateModified"]))
+		}
+	}
+
+	values := map[string]string{}
+	for key, value := range meta.OpenGraph {
+		values[key] = value
+	}
+	page.Find("meta[content]").Each(func(_ int, s *goquery.Selection) {
+		key := strings.ToLower(strings.TrimSpace(firstAttr(s.Nodes[0], "name", "itemprop", "http-equiv")))
+		if _, ok := values[key]; key != "" && !ok {
+			values[key] = strings.TrimSpace(attr(s.Nodes[0], "content"))
+		}
+	})
+	page.Find(`time[itemprop][datetime]`).Each(func(_ int, s *goquery.Selection) {
+		key := strings.ToLower(attr(s.Nodes[0], "itemprop"))
+		if _, ok := values[key]; !ok {
+			values[key] = attr(s.Nodes[0], "datetime")
+		}
+	})
+	for _, key := range publishedKeys {
+		if published == nil {
+			published = parseDate(values[key])
+		}
+	}
+	for _, key := range modifiedKeys {
+		if modified == nil {
+			modified = parseDate(values[key])
+		}
+	}
+
+	if published == nil {
+		published = parseDate(page.Find("time[pubdate][datetime], article time[datetime]").First().AttrOr("datetime", ""))
+	}
+	return published, modified
+}
+
+// dateLayouts are the date formats found in structured data and feeds: variants of ISO 8601 and
+// of the RFC 822 dates of RSS.
+var dateLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"Mon, 2 Jan 2006 15:04 -0700",
+	"Mon, 2 Jan 2006 15:04 MST",
+	"2 Jan 2006 15:04:05 -0700",
+	"2 Jan 2006 15:04:05 MST",
+}
+
+func parseDate(value string) *time.Time {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			t = t.UTC()
+			return &t
+		}
+	}
+	return nil
+}
+
+// pageAuthors returns the authors from JSON-LD, meta tags or microdata, in that order.
+func pageAuthors(page *goquery.Selection, meta Metadata) []string {
+	var authors []string
+	for _, object := range meta.JSONLD {
+		authors = appendNames(authors, object["author"])
+	}
+	if len(authors) > 0 {
+		return authors
+	}
+
+	page.Find(`meta[name="author" i][content], meta[property="article:author" i][content]`).Each(func(_ int, s *goquery.Selection) {
+		authors = appendNames(authors, attr(s.Nodes[0], "content"))
+	})
+	if len(authors) > 0 {
+		return authors
+	}
+
+	for _, item := range meta.Microdata {
+		for _, author := range item.Properties["author"] {
+			switch v := author.(type) {
+			case string:
+				authors = appendNames(authors, v)
+			case MicrodataItem:
+				if names := v.Properties["name"]; len(names) > 0 {
+					authors = appendNames(authors, names[0])
+				}
+			}
+		}
+	}
+	return authors
+}
+
+// appendNames appends the names in a JSON-LD author value: a string, a Person or Organization
+// object or an array of them. Names already in the list are skipped.
+func appendNames(names []string, value any) []string {
+	switch v := value.(type) {
+	case []any:
+		for _, item := range v {
+			names = appendNames(names, item)
+		}
+	case map[string]any:
+		names = appendNames(names, jsonString(v["name"]))
+	case string:
+		name := cleanText(v)
+		if name == "" {
+			return names
+		}
+		for _, existing := range names {
+			if existing == name {
+				return names
+			}
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// jsonString returns a JSON-LD string value, or the first string of an array of values.
+func jsonString(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []any:
+		for _, item := range v {
+			if s := jsonString(item); s != "" {
+				return s
+			}
+		}
+	case map[string]any:
+		// Values may be given as {"@value": "2024-01-02"}
+		return jsonString(v["@value"])
+	}
+	return ""
+}
@@ -0,0 +1,131 @@
+package web
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/Gzgod/masa-oracle/pkg/scrapers/normalized"
+)
+
+// CollectedDataToPosts converts the result of a web scrape to normalized posts, one per document
+// if the main content was extracted and else one per section and per PDF or text file. url is the
+// address the scrape started from.
+func CollectedDataToPosts(url string, data CollectedData) []normalized.Post {
+	if len(data.Documents) > 0 {
+		return documentsToPosts(url, data.Documents, data.Metadata)
+	}
+	posts := make([]normalized.Post, 0, len(data.Sections))
+	for i, section := range data.Sections {
+		post := normalized.Post{
+			ID:         strconv.Itoa(i),
+			Source:     normalized.SourceWeb,
+			URL:        url,
+			Title:      strings.TrimSpace(section.Title),
+			Text:       strings.Join(section.Paragraphs, "\n\n"),
+			ChannelID:  url,
+			Extensions: normalized.Extension(normalized.SourceWeb, section),
+		}
+		for _, image := range section.Images {
+			post.Media = append(post.Media, normalized.Media{Type: normalized.MediaImage, URL: image})
+		}
+		posts = append(posts, post)
+	}
+	for _, file := range data.Files {
+		if file.Text == "" {
+			continue
+		}
+		posts = append(posts, normalized.Post{
+			ID:         strconv.Itoa(len(posts)),
+			Source:     normalized.SourceWeb,
+			URL:        file.URL,
+			Title:      file.Title,
+			Text:       file.Text,
+			ChannelID:  url,
+			Extensions: normalized.Extension(normalized.SourceWeb, file),
+		})
+	}
+	return posts
+}
+
+// documentsToPosts converts documents to posts, with the publication date and first author from
+// the metadata of the same page, if any.
+func documentsToPosts(url string, documents []Document, metadata []Metadata) []normalized.Post {
+	pageMetadata := make(map[string]Metadata, len(metadata))
+	for _, meta := range metadata {
+		pageMetadata[meta.URL] = meta
+	}
+	posts := make([]normalized.Post, 0, len(documents))
+	for i, doc := range documents {
+		postURL := doc.CanonicalURL
+		if postURL == "" {
+			postURL = doc.URL
+		}
+		post := normalized.Post{
+			ID:         strconv.Itoa(i),
+			Source:     normalized.SourceWeb,
+			URL:        postURL,
+			Title:      doc.Title,
+			Text:       doc.Content,
+			ChannelID:  url,
+			Extensions: normalized.Extension(normalized.SourceWeb, doc),
+		}
+		if meta, ok := pageMetadata[doc.URL]; ok {
+			post.CreatedAt = meta.PublishedAt
+			if len(meta.Authors) > 0 {
+				post.Author = &normalized.Author{ID: meta.Authors[0], Source: normalized.SourceWeb, DisplayName: meta.Authors[0]}
+			}
+		}
+		posts = append(posts, post)
+	}
+	return posts
+}
+
+// FeedItemsToPosts converts feed entries to normalized posts. The HTML of the entries is
+// converted to plain text.
+func FeedItemsToPosts(items []FeedItem) []normalized.Post {
+	posts := make([]normalized.Post, 0, len(items))
+	for _, item := range items {
+		post := normalized.Post{
+			ID:         item.ID,
+			Source:     normalized.SourceWeb,
+			URL:        item.URL,
+			Title:      item.Title,
+			Text:       htmlText(firstNonEmpty(item.Content, item.Summary)),
+			CreatedAt:  item.PublishedAt,
+			ChannelID:  item.FeedURL,
+			Tags:       item.Categories,
+			Extensions: normalized.Extension(normalized.SourceWeb, item),
+		}
+		if post.CreatedAt == nil {
+			post.CreatedAt = item.UpdatedAt
+		}
+		if len(item.Authors) > 0 {
+			post.Author = &normalized.Author{ID: item.Authors[0], Source: normalized.SourceWeb, DisplayName: item.Authors[0]}
+		}
+		for _, enclosure := range item.Enclosures {
+			post.Media = append(post.Media, normalized.Media{
+				Type:     enclosureMediaType(enclosure.Type),
+				URL:      enclosure.URL,
+				MimeType: enclosure.Type,
+				Size:     enclosure.Length,
+			})
+		}
+		posts = append(posts, post)
+	}
+	return posts
+}
+
+func enclosureMediaType(mimeType string) normalized.MediaType {
+	switch {
+	case strings.HasPrefix(mimeType, "image/gif"):
+		return normalized.MediaGIF
+	case strings.HasPrefix(mimeType, "image/"):
+		return normalized.MediaImage
+	case strings.HasPrefix(mimeType, "video/"):
+		return normalized.MediaVideo
+	case strings.HasPrefix(mimeType, "audio/"):
+		return normalized.MediaAudio
+	default:
+		return normalized.MediaDocument
+	}
+}
@@ -0,0 +1,210 @@
+package web
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+var (
+	// ErrUnsupportedScheme is returned for URLs that are not http or https.
+	ErrUnsupportedScheme = errors.New("unsupported scheme")
+	// ErrBlockedAddress is returned for hosts that resolve to a loopback, private, link-local or
+	// otherwise reserved address.
+	ErrBlockedAddress = errors.New("private or reserved address")
+	// ErrDomainDenied is returned for domains on the denylist.
+	ErrDomainDenied = errors.New("domain is denied")
+	// ErrDomainNotAllowed is returned for domains missing from a non-empty allowlist.
+	ErrDomainNotAllowed = errors.New("domain is not allowed")
+)
+
+// PolicyError reports a URL the web scraper refused to fetch. Err is one of ErrUnsupportedScheme,
+// ErrBlockedAddress, ErrDomainDenied or ErrDomainNotAllowed.
+type PolicyError struct {
+	URL     string
+	Address string // The resolved address, for ErrBlockedAddress
+	Err     error
+}
+
+func (e *PolicyError) Error() string {
+	msg := "web scraper policy: "
+	if e.URL != "" {
+		msg += e.URL + ": "
+	}
+	msg += e.Err.Error()
+	if e.Address != "" {
+		msg += " " + e.Address
+	}
+	return msg
+}
+
+func (e *PolicyError) Unwrap() error {
+	return e.Err
+}
+
+// Policy decides which URLs the web scraper may fetch. Addresses are checked when connecting,
+// after DNS resolution, so a public name that resolves to an internal address is blocked as well,
+// also when it is reached through a redirect.
+type Policy struct {
+	// AllowedDomains, if not empty, limits the scraper to these domains and their subdomains. Domains
+	// are matched case insensitively, a leading "*." is ignored.
+	AllowedDomains []string
+	// DeniedDomains are never fetched, nor their subdomains. They take precedence over AllowedDomains.
+	DeniedDomains []string
+	// AllowPrivateNetworks disables the address checks, for scraping a local network on purpose.
+	AllowPrivateNetworks bool
+}
+
+var (
+	defaultPolicy      Policy
+	defaultPolicyMutex sync.RWMutex
+)
+
+// DefaultPolicy returns the policy ScrapeWebData applies.
+func DefaultPolicy() Policy {
+	defaultPolicyMutex.RLock()
+	defer defaultPolicyMutex.RUnlock()
+	return defaultPolicy
+}
+
+// SetDefaultPolicy replaces the policy ScrapeWebData applies.
+func SetDefaultPolicy(policy Policy) {
+	defaultPolicyMutex.Lock()
+	defer defaultPolicyMutex.Unlock()
+	defaultPolicy = policy
+}
+
+// CheckURL returns a *PolicyError if the scheme, domain or literal address of u is not allowed.
+// Host names are resolved and checked when connecting.
+func (p Policy) CheckURL(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return &PolicyError{URL: u.String(), Err: ErrUnsupportedScheme}
+	}
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if matchDomain(host, normalizeDomains(p.DeniedDomains)) {
+		return &PolicyError{URL: u.String(), Err: ErrDomainDenied}
+	}
+	if allowed := normalizeDomains(p.AllowedDomains); len(allowed) > 0 && !matchDomain(host, allowed) {
+		return &PolicyError{URL: u.String(), Err: ErrDomainNotAllowed}
+	}
+	if addr, err := netip.ParseAddr(host); err == nil && !p.AllowPrivateNetworks && blockedAddr(addr) {
+		return &PolicyError{URL: u.String(), Address: addr.String(), Err: ErrBlockedAddress}
+	}
+	return nil
+}
+
+// transport returns an HTTP transport that refuses connections to blocked addresses. It does not
+// use the proxy from the environment, the address checks would apply to the proxy instead of the
+// scraped site.
+func (p Policy) transport() *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   p.control,
+	}
+	return &http.Transport{
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+}
+
+// control is called with the resolved address of every connection, before connecting.
+func (p Policy) control(_, address string, _ syscall.RawConn) error {
+	if p.AllowPrivateNetworks {
+		return nil
+	}
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return &PolicyError{Address: address, Err: ErrBlockedAddress}
+	}
+	if blockedAddr(addrPort.Addr()) {
+		return &PolicyError{Address: addrPort.Addr().String(), Err: ErrBlockedAddress}
+	}
+	return nil
+}
+
+// checkRedirect applies the policy to every redirect, and follows at most 10 of them like the
+// default HTTP client.
+func (p Policy) checkRedirect(req *http.Request, via []*http.Request) error {
+	if err := p.CheckURL(req.URL); err != nil {
+		return err
+	}
+	if len(via) >= 10 {
+		return http.ErrUseLastResponse
+	}
+	return nil
+}
+
+// reservedPrefixes are the special-purpose ranges not covered by the netip predicates.
+var reservedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),       // "This" network
+	netip.MustParsePrefix("100.64.0.0/10"),   // Carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),    // IETF protocol assignments
+	netip.MustParsePrefix("192.0.2.0/24"),    // Documentation
+	netip.MustParsePrefix("198.18.0.0/15"),   // Benchmarking
+	netip.MustParsePrefix("198.51.100.0/24"), // Documentation
+	netip.MustParsePrefix("203.0.113.0/24"),  // Documentation
+	netip.MustParsePrefix("240.0.0.0/4"),     // Reserved and broadcast
+	netip.MustParsePrefix("64:ff9b::/96"),    // NAT64, may translate to an internal IPv4 address
+	netip.MustParsePrefix("64:ff9b:1::/48"),  // Local-use NAT64
+	netip.MustParsePrefix("2001:db8::/32"),   // Documentation
+	netip.MustParsePrefix("fec0::/10"),       // Deprecated site-local
+}
+
+// blockedAddr reports whether addr is a loopback, private, link-local, multicast or reserved address.
+func blockedAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() || addr.IsUnspecified() {
+		return true
+	}
+	for _, prefix := range reservedPrefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchDomain reports whether host is one of the domains or a subdomain of one.
+func matchDomain(host string, domains []string) bool {
+	for _, domain := range domains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeDomains(domains []string) []string {
+	normalized := make([]string, 0, len(domains))
+	for _, domain := range domains {
+		domain = strings.TrimPrefix(strings.TrimPrefix(strings.ToLower(strings.TrimSpace(domain)), "*"), ".")
+		if domain = strings.TrimSuffix(domain, "."); domain != "" {
+			normalized = append(normalized, domain)
+		}
+	}
+	return normalized
+}
+
+// policyViolation returns the *PolicyError in err, if any, with the URL filled in.
+func policyViolation(err error, u *url.URL) *PolicyError {
+	var policyErr *PolicyError
+	if !errors.As(err, &policyErr) {
+		return nil
+	}
+	if policyErr.URL == "" && u != nil {
+		return &PolicyError{URL: u.String(), Address: policyErr.Address, Err: policyErr.Err}
+	}
+	return policyErr
+}
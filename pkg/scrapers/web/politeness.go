@@ -0,0 +1,362 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/temoto/robotstxt"
+)
+
+// DefaultUserAgent identifies the scraper to the sites it fetches.
+const DefaultUserAgent = "Mozilla/5.0 (compatible; MasaWebScraper/1.0)"
+
+// Default request rate per site.
+const (
+	DefaultParallelism = 4
+	DefaultDelay       = 500 * time.Millisecond
+)
+
+// Retries of requests answered with 429 Too Many Requests or 503 Service Unavailable.
+const (
+	maxRetries      = 3
+	minRetryDelay   = time.Second
+	maxRetryDelay   = 5 * time.Minute
+	maxRobotsLength = 500 << 10
+)
+
+// Politeness sets the rate at which the scraper fetches pages from a site, and whether it
+// follows the robots.txt rules of the site. Limits apply per host, so a crawl of several sites
+// fetches from each one at the configured rate.
+type Politeness struct {
+	// UserAgent is sent with every request, DefaultUserAgent if empty. The robots.txt rules for
+	// its product name, such as MasaWebScraper, apply.
+	UserAgent string
+	// RespectRobotsTxt skips the pages the robots.txt file of their site disallows, and waits
+	// at least its Crawl-delay between two requests to the site.
+	RespectRobotsTxt bool
+	// Parallelism is the number of requests in flight per host, DefaultParallelism if zero.
+	Parallelism int
+	// Delay is the time between the start of two requests to a host, DefaultDelay if zero.
+	// A negative Delay disables it.
+	Delay time.Duration
+	// Domains overrides the parallelism and delay of some domains and their subdomains.
+	Domains []DomainLimit
+}
+
+// DomainLimit is the request rate of a domain and its subdomains. Zero values keep the rate of
+// the Politeness.
+type DomainLimit struct {
+	Domain      string
+	Parallelism int
+	Delay       time.Duration
+}
+
+var (
+	defaultPoliteness      Politeness
+	defaultPolitenessMutex sync.RWMutex
+)
+
+// DefaultPoliteness returns the politeness ScrapeWebData applies.
+func DefaultPoliteness() Politeness {
+	defaultPolitenessMutex.RLock()
+	defer defaultPolitenessMutex.RUnlock()
+	return defaultPoliteness
+}
+
+// SetDefaultPoliteness replaces the politeness ScrapeWebData applies.
+func SetDefaultPoliteness(politeness Politeness) {
+	defaultPolitenessMutex.Lock()
+	defer defaultPolitenessMutex.Unlock()
+	defaultPoliteness = politeness
+}
+
+// ParseDomainLimit parses a domain limit written as domain=parallelism/delay, such as
+// example.com=1/2s. Either the parallelism or the delay may be left out: example.com=2 or
+// example.com=/5s.
+func ParseDomainLimit(spec string) (DomainLimit, error) {
+	domain, rate, ok := strings.Cut(spec, "=")
+	limit := DomainLimit{Domain: strings.TrimSpace(domain)}
+	if !ok || limit.Domain == "" {
+		return DomainLimit{}, fmt.Errorf("invalid domain limit %q, expected domain=parallelism/delay", spec)
+	}
+	parallelism, delay, _ := strings.Cut(strings.TrimSpace(rate), "/")
+	if parallelism != "" {
+		n, err := strconv.Atoi(parallelism)
+		if err != nil || n < 1 {
+			return DomainLimit{}, fmt.Errorf("invalid parallelism in domain limit %q", spec)
+		}
+		limit.Parallelism = n
+	}
+	if delay != "" {
+		d, err := time.ParseDuration(delay)
+		if err != nil || d < 0 {
+			return DomainLimit{}, fmt.Errorf("invalid delay in domain limit %q", spec)
+		}
+		limit.Delay = d
+	}
+	return limit, nil
+}
+
+func (p Politeness) userAgent() string {
+	if p.UserAgent == "" {
+		return DefaultUserAgent
+	}
+	return p.UserAgent
+}
+
+// rate returns the parallelism and delay of a host.
+func (p Politeness) rate(host string) (int, time.Duration) {
+	parallelism, delay := p.Parallelism, p.Delay
+	if parallelism <= 0 {
+		parallelism = DefaultParallelism
+	}
+	if delay == 0 {
+		delay = DefaultDelay
+	}
+	// The most specific domain wins
+	matched := ""
+	for _, limit := range p.Domains {
+		domain := normalizeDomains([]string{limit.Domain})
+		if len(domain) == 0 || len(domain[0]) <= len(matched) || !matchDomain(host, domain) {
+			continue
+		}
+		matched = domain[0]
+		if limit.Parallelism > 0 {
+			parallelism = limit.Parallelism
+		}
+		if limit.Delay != 0 {
+			delay = limit.Delay
+		}
+	}
+	return parallelism, max(delay, 0)
+}
+
+// robotsAgent returns the product name robots.txt groups are matched against: the name
+// following "compatible;" in browser-like user agents, else the first product name.
+func robotsAgent(userAgent string) string {
+	agent := userAgent
+	if _, compatible, ok := strings.Cut(userAgent, "compatible;"); ok {
+		agent = compatible
+	}
+	agent = strings.TrimSpace(agent)
+	if i := strings.IndexAny(agent, "/;) "); i >= 0 {
+		agent = agent[:i]
+	}
+	return agent
+}
+
+// throttle limits the requests of a crawl per host: the number in flight, the time between
+// their starts, and pauses after a site asked to slow down.
+type throttle struct {
+	politeness Politeness
+	mutex      sync.Mutex
+	hosts      map[string]*hostThrottle
+}
+
+type hostThrottle struct {
+	slots chan struct{}
+	delay time.Duration
+	mutex sync.Mutex
+	// next is the earliest start of the next request
+	next time.Time
+}
+
+func newThrottle(politeness Politeness) *throttle {
+	return &throttle{politeness: politeness, hosts: map[string]*hostThrottle{}}
+}
+
+func (t *throttle) host(host string) *hostThrottle {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	h, ok := t.hosts[host]
+	if !ok {
+		hostname := strings.TrimSuffix(strings.ToLower(host), ".")
+		if u, err := url.Parse("//" + host); err == nil {
+			hostname = strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+		}
+		parallelism, delay := t.politeness.rate(hostname)
+		h = &hostThrottle{slots: make(chan struct{}, parallelism), delay: delay}
+		t.hosts[host] = h
+	}
+	return h
+}
+
+// slowDown raises the delay of a host to at least delay, such as its robots.txt Crawl-delay.
+func (t *throttle) slowDown(host string, delay time.Duration) {
+	h := t.host(host)
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.delay = max(h.delay, delay)
+}
+
+// pause holds the requests to a host until the given time.
+func (t *throttle) pause(host string, until time.Time) {
+	h := t.host(host)
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if until.After(h.next) {
+		h.next = until
+	}
+}
+
+// transport applies the limits of the throttle to the requests of base. Requests answered with
+// 429 Too Many Requests or 503 Service Unavailable pause their host for the time the site asks,
+// or an increasing delay, and are sent again up to maxRetries times. A paused host does not hold
+// up the requests to other hosts.
+func (t *throttle) transport(base http.RoundTripper) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		h := t.host(req.URL.Host)
+		for attempt := 0; ; attempt++ {
+			release, err := h.start(req.Context())
+			if err != nil {
+				return nil, err
+			}
+			resp, err := base.RoundTrip(req)
+			if err != nil {
+				release()
+				return nil, err
+			}
+			if (resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable) &&
+				attempt < maxRetries && req.Body == nil {
+				delay := retryDelay(resp.Header, attempt)
+				logrus.Warnf("[-] Rate limited by %s. Retrying %s after %v", req.URL.Host, req.URL, delay)
+				t.pause(req.URL.Host, time.Now().Add(delay))
+				_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4<<10))
+				resp.Body.Close()
+				release()
+				continue
+			}
+			// The request is in flight until its body is read
+			resp.Body = &releasingBody{ReadCloser: resp.Body, release: release}
+			return resp, nil
+		}
+	})
+}
+
+// start waits for a free slot and the start time of the next request to the host. The returned
+// function frees the slot.
+func (h *hostThrottle) start(ctx context.Context) (func(), error) {
+	select {
+	case h.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	release := sync.OnceFunc(func() { <-h.slots })
+
+	h.mutex.Lock()
+	start := time.Now()
+	if h.next.After(start) {
+		start = h.next
+	}
+	h.next = start.Add(h.delay)
+	h.mutex.Unlock()
+	if wait := time.Until(start); wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			release()
+			return nil, ctx.Err()
+		}
+	}
+	return release, nil
+}
+
+// releasingBody calls release when the response body is closed.
+type releasingBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	defer b.release()
+	return b.ReadCloser.Close()
+}
+
+// robots caches the robots.txt rules of the sites of a crawl.
+type robots struct {
+	fetcher *fetcher
+	agent   string
+	mutex   sync.Mutex
+	sites   map[string]*robotsSite
+}
+
+type robotsSite struct {
+	once  sync.Once
+	group *robotstxt.Group
+}
+
+func newRobots(f *fetcher, userAgent string) *robots {
+	return &robots{fetcher: f, agent: robotsAgent(userAgent), sites: map[string]*robotsSite{}}
+}
+
+// group returns the rules of the site of u for the user agent. The robots.txt file of a site is
+// fetched once, a site without one, or with one that cannot be fetched, has no rules. Server
+// errors disallow the whole site, as the robots.txt specification asks.
+func (r *robots) group(ctx context.Context, u *url.URL) *robotstxt.Group {
+	key := u.Scheme + "://" + u.Host
+	r.mutex.Lock()
+	site, ok := r.sites[key]
+	if !ok {
+		site = &robotsSite{}
+		r.sites[key] = site
+	}
+	r.mutex.Unlock()
+
+	site.once.Do(func() {
+		data, err := r.fetch(ctx, &url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/robots.txt"})
+		if err != nil {
+			logrus.Debugf("[-] No robots.txt for %s: %v", u.Host, err)
+			data, _ = robotstxt.FromStatusAndBytes(http.StatusNotFound, nil)
+		}
+		site.group = data.FindGroup(r.agent)
+	})
+	return site.group
+}
+
+func (r *robots) fetch(ctx context.Context, u *url.URL) (*robotstxt.RobotsData, error) {
+	if err := r.fetcher.policy.CheckURL(u); err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", r.fetcher.userAgent)
+	resp, err := r.fetcher.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxRobotsLength))
+	if r.fetcher.onBytes != nil {
+		r.fetcher.onBytes(len(body))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return robotstxt.FromStatusAndBytes(resp.StatusCode, body)
+}
+
+// retryDelay returns the time to wait before retrying a request, from the Retry-After header
+// of its response, in seconds or as a date, or else doubling from minRetryDelay with every attempt.
+func retryDelay(header http.Header, attempt int) time.Duration {
+	delay := minRetryDelay << min(attempt, 8)
+	if value := strings.TrimSpace(header.Get("Retry-After")); value != "" {
+		if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+			delay = time.Duration(seconds) * time.Second
+		} else if date, err := http.ParseTime(value); err == nil {
+			delay = time.Until(date)
+		}
+	}
+	return min(max(delay, 0), maxRetryDelay)
+}
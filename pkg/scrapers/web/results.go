@@ -0,0 +1,279 @@
+package web
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/gocolly/colly/v2"
+)
+
+// Page is a page a crawl scraped.
+type Page struct {
+	// URL is the canonical URL of the page, after redirects.
+	URL string `json:"url"`
+	// Depth is 1 for the start URLs, 2 for the pages they link to, and so on.
+	Depth int `json:"depth"`
+	// Parent is the page the link to the page was found on, the one with the smallest URL when
+	// several pages of the same depth link to it. Start URLs have none.
+	Parent   string    `json:"parent,omitempty"`
+	Sections []Section `json:"sections"`
+}
+
+// pageResult is what a crawl collected from a page.
+type pageResult struct {
+	Page
+	// requested is the canonical URL the page was requested with, before redirects.
+	requested string
+	fetched   bool
+	// links holds the canonical URLs of the links found on the page.
+	links     []string
+	document  *Document
+	metadata  *Metadata
+	extracted *Extraction
+	file      *File
+}
+
+// results collects the pages of a crawl, concurrently. Pages are identified by their canonical URL,
+// so that a page linked to under several URLs is requested once, and a page reached through
+// several redirects is returned once. Links are followed as they are found, and a page keeps the
+// smallest depth and parent it is reached with, so that the depth and parent of every page do not
+// depend on the order in which the responses arrive.
+type results struct {
+	mutex    sync.Mutex
+	maxDepth int
+	// scheduled holds the pages requested by canonical URL, requests the page of every request.
+	scheduled  map[string]*pageResult
+	requests   map[uint32]*pageResult
+	followed   map[string]bool
+	disallowed map[string]bool
+}
+
+func newResults(maxDepth int) *results {
+	return &results{
+		maxDepth:   maxDepth,
+		scheduled:  map[string]*pageResult{},
+		requests:   map[uint32]*pageResult{},
+		followed:   map[string]bool{},
+		disallowed: map[string]bool{},
+	}
+}
+
+// schedule records the start URLs of the crawl and returns the canonical URLs to request.
+func (res *results) schedule(uri []*url.URL) []string {
+	res.mutex.Lock()
+	defer res.mutex.Unlock()
+	visits := make([]string, 0, len(uri))
+	for _, u := range uri {
+		canonical := canonicalURL(u)
+		if _, ok := res.scheduled[canonical]; ok {
+			continue
+		}
+		res.scheduled[canonical] = &pageResult{Page: Page{URL: canonical, Depth: 1}, requested: canonical}
+		visits = append(visits, canonical)
+	}
+	return visits
+}
+
+// request assigns a request to the page it fetches.
+func (res *results) request(r *colly.Request) {
+	res.mutex.Lock()
+	defer res.mutex.Unlock()
+	if page, ok := res.scheduled[canonicalURL(r.URL)]; ok {
+		res.requests[r.ID] = page
+	}
+}
+
+// page returns the page a request fetches, nil if unknown. Only the callbacks of the request
+// change its content, one after the other; its URL, depth and parent change under the mutex.
+func (res *results) page(r *colly.Request) *pageResult {
+	res.mutex.Lock()
+	defer res.mutex.Unlock()
+	return res.requests[r.ID]
+}
+
+// respond records the response to a request and returns its page.
+func (res *results) respond(r *colly.Response) *pageResult {
+	res.mutex.Lock()
+	defer res.mutex.Unlock()
+	page := res.requests[r.Request.ID]
+	if page != nil {
+		page.fetched = true
+		page.URL = canonicalURL(r.Request.URL)
+	}
+	return page
+}
+
+// link records a link found on the page of r and returns the canonical URLs to request.
+func (res *results) link(r *colly.Request, link *url.URL) []string {
+	canonical := canonicalURL(link)
+	res.mutex.Lock()
+	defer res.mutex.Unlock()
+	res.followed[canonical] = true
+	page, ok := res.requests[r.ID]
+	if !ok {
+		return nil
+	}
+	page.links = append(page.links, canonical)
+	return res.reach(page, canonical, nil)
+}
+
+// reach records that parent links to link and appends the pages to request to visits. A page
+// reached with a smaller depth, or the same depth and a parent with a smaller URL, takes them,
+// and the pages it links to are reached again with its new depth.
+func (res *results) reach(parent *pageResult, link string, visits []string) []string {
+	depth := parent.Depth + 1
+	if depth > res.maxDepth {
+		return visits
+	}
+	page, ok := res.scheduled[link]
+	if !ok {
+		res.scheduled[link] = &pageResult{Page: Page{URL: link, Depth: depth, Parent: parent.URL}, requested: link}
+		return append(visits, link)
+	}
+	if depth > page.Depth || (depth == page.Depth && parent.URL >= page.Parent) {
+		return visits
+	}
+	shallower := depth < page.Depth
+	page.Depth, page.Parent = depth, parent.URL
+	if shallower {
+		for _, child := range page.links {
+			visits = res.reach(page, child, visits)
+		}
+	}
+	return visits
+}
+
+// disallow records a page robots.txt disallows.
+func (res *results) disallow(u *url.URL) {
+	res.mutex.Lock()
+	defer res.mutex.Unlock()
+	res.disallowed[canonicalURL(u)] = true
+}
+
+// depth returns the depth of the page of a request, 0 if unknown.
+func (res *results) depth(r *colly.Request) int {
+	res.mutex.Lock()
+	defer res.mutex.Unlock()
+	if page, ok := res.requests[r.ID]; ok {
+		return page.Depth
+	}
+	return 0
+}
+
+// collect adds the pages to data, sorted by depth and URL. The pages that redirected to a page
+// already collected are left out.
+func (res *results) collect(data *CollectedData) {
+	res.mutex.Lock()
+	defer res.mutex.Unlock()
+
+	pages := make([]*pageResult, 0, len(res.scheduled))
+	for _, page := range res.scheduled {
+		if page.fetched {
+			pages = append(pages, page)
+		}
+	}
+	sort.Slice(pages, func(i, j int) bool {
+		a, b := pages[i], pages[j]
+		if a.Depth != b.Depth {
+			return a.Depth < b.Depth
+		}
+		if a.URL != b.URL {
+			return a.URL < b.URL
+		}
+		return a.requested < b.requested
+	})
+
+	seen := make(map[string]bool, len(pages))
+	for _, page := range pages {
+		if seen[page.URL] {
+			continue
+		}
+		seen[page.URL] = true
+		data.Results = append(data.Results, page.Page)
+		data.Sections = append(data.Sections, page.Sections...)
+		if page.document != nil {
+			data.Documents = append(data.Documents, *page.document)
+		}
+		if page.metadata != nil {
+			data.Metadata = append(data.Metadata, *page.metadata)
+		}
+		if page.extracted != nil {
+			data.Extracted = append(data.Extracted, *page.extracted)
+		}
+		if page.file != nil {
+			data.Files = append(data.Files, *page.file)
+		}
+	}
+	data.Pages = sortedKeys(res.followed)
+	data.Disallowed = sortedKeys(res.disallowed)
+}
+
+func sortedKeys(set map[string]bool) []string {
+	if len(set) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// pageSections splits a page into sections at its h1 and h2 headings. The paragraphs and images
+// before the first heading are left out, as are paragraphs repeated within a section.
+func pageSections(page *goquery.Selection, r *colly.Request) []Section {
+	var sections []Section
+	page.Find("h1, h2, p, img").Each(func(_ int, s *goquery.Selection) {
+		switch goquery.NodeName(s) {
+		case "h1", "h2":
+			sections = append(sections, Section{Title: s.Text()})
+		case "p":
+			if len(sections) == 0 {
+				return
+			}
+			section := &sections[len(sections)-1]
+			text := s.Text()
+			for _, paragraph := range section.Paragraphs {
+				if paragraph == text {
+					return
+				}
+			}
+			section.Paragraphs = append(section.Paragraphs, text)
+		case "img":
+			if len(sections) == 0 {
+				return
+			}
+			section := &sections[len(sections)-1]
+			src, _ := s.Attr("src")
+			section.Images = append(section.Images, r.AbsoluteURL(src))
+		}
+	})
+	return sections
+}
+
+// canonicalURL returns the URL pages are identified by: the scheme and host in lower case,
+// without the default port, the fragment and an empty query, with a path and the query
+// parameters sorted.
+func canonicalURL(u *url.URL) string {
+	c := *u
+	c.Scheme = strings.ToLower(c.Scheme)
+	c.Host = strings.ToLower(c.Host)
+	if port := c.Port(); (c.Scheme == "http" && port == "80") || (c.Scheme == "https" && port == "443") {
+		c.Host = strings.TrimSuffix(c.Host, ":"+port)
+	}
+	c.Fragment, c.RawFragment = "", ""
+	if c.Path == "" && c.Opaque == "" {
+		c.Path, c.RawPath = "/", ""
+	}
+	c.ForceQuery = false
+	if c.RawQuery != "" {
+		params := strings.FieldsFunc(c.RawQuery, func(r rune) bool { return r == '&' })
+		sort.Strings(params)
+		c.RawQuery = strings.Join(params, "&")
+	}
+	return c.String()
+}
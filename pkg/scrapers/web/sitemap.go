@@ -0,0 +1,219 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/temoto/robotstxt"
+)
+
+// Ways a crawl finds the pages to scrape.
+const (
+	DiscoverLinks   = "links"   // Follow the links of the start URLs, the default
+	DiscoverSitemap = "sitemap" // Scrape the pages listed in the sitemaps of the sites of the start URLs
+	DiscoverFeed    = "feed"    // Scrape the pages listed in the feeds at, or linked from, the start URLs
+)
+
+// Bounds of sitemap discovery. The sitemap protocol allows 50,000 URLs per file.
+const (
+	maxSitemaps   = 50
+	maxDiscovered = 50000
+)
+
+// ErrNoSitemap is returned when neither robots.txt nor /sitemap.xml lead to a sitemap.
+var ErrNoSitemap = errors.New("no sitemap found")
+
+// DiscoveredURL is a page listed in a sitemap or a feed.
+type DiscoveredURL struct {
+	URL          string     `json:"url"`
+	LastModified *time.Time `json:"lastModified,omitempty"`
+	// Source is the address of the sitemap or feed that lists the page.
+	Source string `json:"source"`
+}
+
+// validDiscover returns an error if mode is not empty or one of the Discover modes.
+func validDiscover(mode string) error {
+	switch mode {
+	case "", DiscoverLinks, DiscoverSitemap, DiscoverFeed:
+		return nil
+	default:
+		return fmt.Errorf("invalid discover mode %q, expected %s, %s or %s", mode, DiscoverLinks, DiscoverSitemap, DiscoverFeed)
+	}
+}
+
+// discover returns the pages the sitemaps or the feeds of the start URLs list, that changed
+// after since, the most recently changed first. Pages without a date are listed last.
+func discover(ctx context.Context, f *fetcher, mode string, starts []*url.URL, since time.Time) ([]DiscoveredURL, error) {
+	var (
+		found []DiscoveredURL
+		err   error
+	)
+	if mode == DiscoverSitemap {
+		found, err = discoverSitemaps(ctx, f, starts, since)
+	} else {
+		found, err = discoverFeeds(ctx, f, starts, since)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(found))
+	unique := found[:0]
+	for _, page := range found {
+		if !seen[page.URL] {
+			seen[page.URL] = true
+			unique = append(unique, page)
+		}
+	}
+	sort.SliceStable(unique, func(i, j int) bool {
+		return newer(unique[i].LastModified, unique[j].LastModified)
+	})
+	return unique, nil
+}
+
+// sitemapDocument is a sitemap, with url elements, or a sitemap index, with sitemap elements.
+type sitemapDocument struct {
+	URLs     []sitemapEntry `xml:"url"`
+	Sitemaps []sitemapEntry `xml:"sitemap"`
+}
+
+type sitemapEntry struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod"`
+}
+
+func discoverSitemaps(ctx context.Context, f *fetcher, starts []*url.URL, since time.Time) ([]DiscoveredURL, error) {
+	var queue []*url.URL
+	for _, start := range starts {
+		sources, err := sitemapSources(ctx, f, start)
+		if err != nil {
+			return nil, err
+		}
+		queue = append(queue, sources...)
+	}
+
+	var (
+		found  []DiscoveredURL
+		seen   = map[string]bool{}
+		parsed int
+	)
+	for len(queue) > 0 && len(seen) < maxSitemaps && len(found) < maxDiscovered {
+		source := queue[0]
+		queue = queue[1:]
+		if seen[source.String()] {
+			continue
+		}
+		seen[source.String()] = true
+
+		file, err := f.get(ctx, source)
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			logrus.Warnf("[-] Unable to read sitemap %s: %v", source, err)
+			continue
+		}
+		root, _ := xmlRoot(file.Body)
+		switch root {
+		case "urlset", "sitemapindex":
+		default:
+			// The sitemap protocol accepts feeds as sitemaps
+			feed, err := parseFeed(file.Body, file.URL)
+			if err != nil {
+				logrus.Warnf("[-] %s is not a sitemap", source)
+				continue
+			}
+			parsed++
+			found = append(found, feedPages(feed, since)...)
+			continue
+		}
+		var doc sitemapDocument
+		if err := xmlDecoder(bytes.NewReader(file.Body)).Decode(&doc); err != nil {
+			logrus.Warnf("[-] Unable to parse sitemap %s: %v", source, err)
+			continue
+		}
+		parsed++
+		for _, entry := range doc.Sitemaps {
+			// Sitemaps that did not change list no changed pages
+			if loc, err := file.URL.Parse(strings.TrimSpace(entry.Loc)); err == nil && changedSince(parseDate(entry.LastMod), since) {
+				queue = append(queue, loc)
+			}
+		}
+		for _, entry := range doc.URLs {
+			lastMod := parseDate(entry.LastMod)
+			if strings.TrimSpace(entry.Loc) == "" || !changedSince(lastMod, since) {
+				continue
+			}
+			found = append(found, DiscoveredURL{URL: resolveAny(file.URL, entry.Loc), LastModified: lastMod, Source: file.URL.String()})
+		}
+	}
+	if parsed == 0 {
+		return nil, fmt.Errorf("%w for %s", ErrNoSitemap, starts[0].Host)
+	}
+	if len(found) > maxDiscovered {
+		found = found[:maxDiscovered]
+	}
+	return found, nil
+}
+
+// sitemapSources returns the sitemaps of the site of start: start itself if it looks like a
+// sitemap, else the sitemaps listed in robots.txt, else /sitemap.xml.
+func sitemapSources(ctx context.Context, f *fetcher, start *url.URL) ([]*url.URL, error) {
+	if path := strings.ToLower(start.Path); strings.HasSuffix(path, ".xml") || strings.HasSuffix(path, ".xml.gz") {
+		return []*url.URL{start}, nil
+	}
+	site := &url.URL{Scheme: start.Scheme, Host: start.Host, Path: "/"}
+	var sources []*url.URL
+	robots, err := f.get(ctx, site.JoinPath("robots.txt"))
+	var violation *PolicyError
+	switch {
+	case errors.As(err, &violation):
+		return nil, violation
+	case err != nil:
+		logrus.Debugf("[-] No robots.txt for %s: %v", site.Host, err)
+	default:
+		if data, err := robotstxt.FromBytes(robots.Body); err == nil {
+			for _, sitemap := range data.Sitemaps {
+				if u, err := site.Parse(strings.TrimSpace(sitemap)); err == nil {
+					sources = append(sources, u)
+				}
+			}
+		}
+	}
+	if len(sources) == 0 {
+		sources = append(sources, site.JoinPath("sitemap.xml"))
+	}
+	return sources, nil
+}
+
+func discoverFeeds(ctx context.Context, f *fetcher, starts []*url.URL, since time.Time) ([]DiscoveredURL, error) {
+	var found []DiscoveredURL
+	for _, start := range starts {
+		feeds, err := loadFeeds(ctx, f, start)
+		if err != nil {
+			return nil, err
+		}
+		for _, feed := range feeds {
+			found = append(found, feedPages(feed, since)...)
+		}
+	}
+	return found, nil
+}
+
+// feedPages returns the pages of the entries of a feed that changed after since.
+func feedPages(feed *parsedFeed, since time.Time) []DiscoveredURL {
+	var pages []DiscoveredURL
+	for _, item := range feed.Items {
+		if item.URL != "" && changedSince(item.lastChange(), since) {
+			pages = append(pages, DiscoveredURL{URL: item.URL, LastModified: item.lastChange(), Source: feed.URL})
+		}
+	}
+	return pages
+}
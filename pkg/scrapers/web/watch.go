@@ -0,0 +1,290 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	ds "github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	"github.com/sirupsen/logrus"
+)
+
+// Intervals of a Watcher.
+const (
+	DefaultWatchInterval = time.Hour
+	MinWatchInterval     = time.Minute
+)
+
+// watchTimeout bounds the check of a page.
+const watchTimeout = time.Minute
+
+// watchPrefix is the datastore key prefix of the page states.
+const watchPrefix = "/web/watch"
+
+// WatchConfig selects the pages a Watcher checks for changes.
+type WatchConfig struct {
+	URLs []string
+	// Interval is the time between two checks of the pages, DefaultWatchInterval if zero.
+	Interval time.Duration
+	// Format is the format of the main content the pages are compared on, ContentText if empty.
+	// ContentMarkdown also detects changes of links and images.
+	Format string
+	// Store keeps the state of the pages across restarts. The state is kept in memory if nil.
+	Store ds.Datastore
+}
+
+// PageState is what a Watcher knows of a page since its last check.
+type PageState struct {
+	URL          string `json:"url"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+	// Hash is the hex encoded SHA-256 hash of the main content of an HTML page, of the text of a
+	// PDF, or of the page itself.
+	Hash      string    `json:"hash"`
+	Title     string    `json:"title,omitempty"`
+	Text      string    `json:"text"`
+	CheckedAt time.Time `json:"checkedAt"`
+	ChangedAt time.Time `json:"changedAt"`
+}
+
+// Change is the change of the content of a watched page between two checks.
+type Change struct {
+	URL          string `json:"url"`
+	Title        string `json:"title,omitempty"`
+	PreviousHash string `json:"previousHash"`
+	Hash         string `json:"hash"`
+	// Diff is the unified diff of the main content of the page, line by line.
+	Diff string `json:"diff"`
+	// PreviousChangeAt is when the page was first seen with its previous content.
+	PreviousChangeAt time.Time `json:"previousChangeAt"`
+	ChangedAt        time.Time `json:"changedAt"`
+}
+
+// ChangeHandler is called with every change a Watcher detects. When it returns an error the
+// Watcher keeps the previous state of the page, so the change is reported again at the next check.
+type ChangeHandler func(Change) error
+
+// Watcher periodically fetches web pages and reports the changes of their main content. Pages
+// are fetched with conditional requests, so that servers can answer that a page did not change
+// without sending it again. The requests are subject to the DefaultPolicy.
+type Watcher struct {
+	cfg     WatchConfig
+	pages   []*url.URL
+	handler ChangeHandler
+}
+
+// NewWatcher returns a Watcher of the pages of cfg that calls handler with their changes.
+func NewWatcher(cfg WatchConfig, handler ChangeHandler) (*Watcher, error) {
+	if len(cfg.URLs) == 0 {
+		return nil, errors.New("no pages to watch")
+	}
+	if cfg.Interval == 0 {
+		cfg.Interval = DefaultWatchInterval
+	}
+	if cfg.Interval < MinWatchInterval {
+		return nil, fmt.Errorf("watch interval %s is shorter than %s", cfg.Interval, MinWatchInterval)
+	}
+	if cfg.Format == "" {
+		cfg.Format = ContentText
+	}
+	if err := validContentFormat(cfg.Format); err != nil {
+		return nil, err
+	}
+	if cfg.Store == nil {
+		cfg.Store = dssync.MutexWrap(ds.NewMapDatastore())
+	}
+
+	w := &Watcher{cfg: cfg, handler: handler}
+	for _, page := range cfg.URLs {
+		u, err := url.Parse(page)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("invalid page URL %q", page)
+		}
+		w.pages = append(w.pages, u)
+	}
+	return w, nil
+}
+
+// Run checks the pages right away and then at every interval, until ctx is done.
+func (w *Watcher) Run(ctx context.Context) error {
+	ticker := time.NewTicker(w.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		w.Check(ctx)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check checks every page once. Pages that cannot be fetched are logged and checked again
+// next time.
+func (w *Watcher) Check(ctx context.Context) {
+	f := w.fetcher()
+	defer f.client.CloseIdleConnections()
+	for _, page := range w.pages {
+		if ctx.Err() != nil {
+			return
+		}
+		if _, err := w.check(ctx, f, page); err != nil {
+			logrus.Warnf("[-] Unable to check %s for changes: %v", page, err)
+		}
+	}
+}
+
+// CheckPage checks a single page and returns its change, nil if the page did not change or was
+// never checked before. The handler of the Watcher is called with the change too, its error is
+// returned with the change.
+func (w *Watcher) CheckPage(ctx context.Context, pageURL string) (*Change, error) {
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		return nil, err
+	}
+	f := w.fetcher()
+	defer f.client.CloseIdleConnections()
+	return w.check(ctx, f, u)
+}
+
+// State returns the state of a page, nil if it was never checked.
+func (w *Watcher) State(ctx context.Context, pageURL string) (*PageState, error) {
+	data, err := w.cfg.Store.Get(ctx, watchKey(pageURL))
+	if errors.Is(err, ds.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var state PageState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+func (w *Watcher) fetcher() *fetcher {
+	policy := DefaultPolicy()
+	return newFetcher(policy, policy.transport(), 0)
+}
+
+func (w *Watcher) check(ctx context.Context, f *fetcher, page *url.URL) (*Change, error) {
+	previous, err := w.State(ctx, page.String())
+	if err != nil {
+		return nil, fmt.Errorf("unable to load the state of the page: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, watchTimeout)
+	defer cancel()
+	var file *fetched
+	if previous != nil {
+		file, err = f.getIfChanged(ctx, page, previous.ETag, previous.LastModified)
+	} else {
+		file, err = f.get(ctx, page)
+	}
+	now := time.Now().UTC()
+	if errors.Is(err, errNotModified) {
+		previous.CheckedAt = now
+		return nil, w.save(ctx, *previous)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	state := pageState(file, w.cfg.Format)
+	state.URL = page.String()
+	state.CheckedAt, state.ChangedAt = now, now
+
+	var change *Change
+	switch {
+	case previous == nil:
+	case previous.Hash == state.Hash:
+		state.ChangedAt = previous.ChangedAt
+	default:
+		change = &Change{
+			URL:              state.URL,
+			Title:            state.Title,
+			PreviousHash:     previous.Hash,
+			Hash:             state.Hash,
+			Diff:             UnifiedDiff(previous.Text, state.Text),
+			PreviousChangeAt: previous.ChangedAt,
+			ChangedAt:        now,
+		}
+	}
+	if change != nil && w.handler != nil {
+		if err := w.handler(*change); err != nil {
+			// Forget the validators, a conditional request would not fetch the change again.
+			previous.ETag, previous.LastModified, previous.CheckedAt = "", "", now
+			if saveErr := w.save(ctx, *previous); saveErr != nil {
+				logrus.Warnf("[-] Unable to save the state of %s: %v", page, saveErr)
+			}
+			return change, fmt.Errorf("unable to handle the change: %w", err)
+		}
+	}
+	if err := w.save(ctx, state); err != nil {
+		return nil, err
+	}
+	return change, nil
+}
+
+func (w *Watcher) save(ctx context.Context, state PageState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	if err := w.cfg.Store.Put(ctx, watchKey(state.URL), data); err != nil {
+		return fmt.Errorf("unable to save the state of the page: %w", err)
+	}
+	return nil
+}
+
+// watchKey returns the datastore key of the state of a page. URLs are hashed, as they may
+// contain characters keys cannot.
+func watchKey(pageURL string) ds.Key {
+	sum := sha256.Sum256([]byte(pageURL))
+	return ds.NewKey(watchPrefix).ChildString(hex.EncodeToString(sum[:]))
+}
+
+// pageState returns the content of a fetched page: the main content of an HTML page, the text of
+// a PDF, the content of text and JSON documents, and only the hash of anything else.
+func pageState(file *fetched, format string) PageState {
+	state := PageState{
+		ETag:         file.Header.Get("ETag"),
+		LastModified: file.Header.Get("Last-Modified"),
+	}
+	contentType := file.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = http.DetectContentType(file.Body)
+	}
+
+	hashed := file.Body
+	if isHTML(contentType) {
+		if page, err := goquery.NewDocumentFromReader(bytes.NewReader(file.Body)); err == nil {
+			doc := extractDocument(page.Selection, file.URL, file.Header, format)
+			state.Title, state.Text = doc.Title, doc.Content
+			hashed = []byte(doc.Content)
+		}
+	} else {
+		read := ReadFile(file.Body, file.URL.String(), contentType)
+		switch read.Kind {
+		case FilePDF:
+			// PDFs are compared on their text, generating the same document again changes its bytes
+			state.Title, state.Text = read.Title, read.Text
+			hashed = []byte(read.Text)
+		case FileText, FileJSON:
+			state.Text = string(file.Body)
+		}
+	}
+	sum := sha256.Sum256(hashed)
+	state.Hash = hex.EncodeToString(sum[:])
+	return state
+}
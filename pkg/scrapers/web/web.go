@@ -2,12 +2,10 @@ package web
 
 import (
 	"encoding/json"
-	"net/http"
-	"strconv"
-	"strings"
+	"net/url"
+	"sync"
 	"time"
 
-	"github.com/cenkalti/backoff/v4"
 	"github.com/gocolly/colly/v2"
 	"github.com/sirupsen/logrus"
 )
@@ -22,16 +20,43 @@ type Section struct {
 }
 
 // CollectedData represents the aggregated result of the scraping process.
-// It contains a slice of Section structs, each representing a distinct part of a scraped webpage.
+// It contains the scraped pages, sorted by depth and URL, and the results of the options of the
+// crawl in the same order, so that the scrapes of the same pages can be compared.
 type CollectedData struct {
-	Sections []Section `json:"sections"` // Sections is a collection of webpage sections that have been scraped.
-	Pages    []string  `json:"pages"`
+	// Results holds the pages scraped, each with its sections.
+	Results []Page `json:"results"`
+	// Sections holds the sections of all the pages, in the order of Results.
+	Sections []Section `json:"sections"`
+	// Pages lists the canonical URLs of the links found within the scope of the crawl, sorted.
+	Pages []string `json:"pages"`
+	// Documents holds the main content of each page when CrawlOptions.Content is set.
+	Documents []Document `json:"documents,omitempty"`
+	// Metadata holds the structured data of each page when CrawlOptions.Metadata is set.
+	Metadata []Metadata `json:"metadata,omitempty"`
+	// Extracted holds the values of CrawlOptions.Extract on each page.
+	Extracted []Extraction `json:"extracted,omitempty"`
+	// Files describes the responses that are not HTML pages, such as PDFs, text and JSON documents.
+	Files []File `json:"files,omitempty"`
+	// Disallowed lists the pages that were not scraped because robots.txt disallows them, when
+	// the DefaultPoliteness respects robots.txt.
+	Disallowed []string `json:"disallowed,omitempty"`
+	// Discovered lists the pages found in sitemaps or feeds when CrawlOptions.Discover is set.
+	Discovered []DiscoveredURL `json:"discovered,omitempty"`
+	// PageCount is the number of pages requested, ByteCount the response bytes downloaded and
+	// StopReason tells why the crawl ended.
+	PageCount  int    `json:"pageCount"`
+	ByteCount  int    `json:"byteCount"`
+	StopReason string `json:"stopReason"`
 }
 
 // ScrapeWebData initiates the scraping process for the given list of URIs.
 // It returns a CollectedData struct containing the scraped sections from each URI,
 // and an error if any occurred during the scraping process.
 //
+// The URLs, redirects and followed links are subject to the DefaultPolicy. A URL in uri that the
+// policy blocks fails the scrape with a *PolicyError, blocked links are skipped. Requests are sent
+// at the rate, and with the user agent, of the DefaultPoliteness.
+//
 // Parameters:
 //   - uri: []string - list of URLs to scrape
 //   - depth: int - depth of how many subpages to scrape
@@ -51,110 +76,215 @@ type CollectedData struct {
 //		logrus.WithField("result", string(res)).Info("Scraping completed")
 //	}()
 func ScrapeWebData(uri []string, depth int) ([]byte, error) {
+	return ScrapeWebDataWithOptions(uri, CrawlOptions{Depth: depth})
+}
+
+// ScrapeWebDataWithOptions scrapes the given URIs like ScrapeWebData, within the depth, budgets and
+// scope of opts. When a budget runs out the crawl stops cleanly, and the data collected so far is
+// returned with the StopReason set.
+func ScrapeWebDataWithOptions(uri []string, opts CrawlOptions) ([]byte, error) {
 	// Set default depth to 1 if 0 is provided
+	depth := opts.Depth
 	if depth <= 0 {
 		depth = 1
 	}
 
-	var collectedData CollectedData
+	policy := DefaultPolicy()
+	politeness := DefaultPoliteness()
+	starts := make([]*url.URL, 0, len(uri))
+	for _, u := range uri {
+		parsed, err := url.Parse(u)
+		if err != nil {
+			return nil, err
+		}
+		if err := policy.CheckURL(parsed); err != nil {
+			return nil, err
+		}
+		starts = append(starts, parsed)
+	}
+
+	budget, err := newCrawl(starts, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// mutex guards policyErr, the callbacks run concurrently and collect into pages
+	var (
+		collectedData CollectedData
+		policyErr     *PolicyError
+		mutex         sync.Mutex
+	)
+
+	discovering := opts.Discover == DiscoverSitemap || opts.Discover == DiscoverFeed
+	if discovering {
+		f := newFetcher(policy, budget.transport(policy.transport()), opts.MaxBytes)
+		f.onBytes = budget.addBytes
+		discovered, err := discover(budget.ctx, f, opts.Discover, starts, opts.Since)
+		if err != nil {
+			budget.finish()
+			return nil, err
+		}
+		starts = starts[:0:0]
+		for _, page := range discovered {
+			parsed, err := url.Parse(page.URL)
+			if err != nil || policy.CheckURL(parsed) != nil || !budget.follows(parsed) {
+				continue
+			}
+			collectedData.Discovered = append(collectedData.Discovered, page)
+			starts = append(starts, parsed)
+		}
+		depth = 1
+	}
 
+	// The depth of the pages is tracked by pages, links are followed as they are found
+	pages := newResults(depth)
 	c := colly.NewCollector(
 		colly.Async(true), // Enable asynchronous requests
-		colly.AllowURLRevisit(),
+		// robots.txt is checked below, with the crawl delay
 		colly.IgnoreRobotsTxt(),
-		colly.MaxDepth(depth),
+		colly.UserAgent(politeness.userAgent()),
 	)
 
-	// Adjust the parallelism and delay based on your needs and server capacity
-	limitRule := colly.LimitRule{
-		DomainGlob:  "*",
-		Parallelism: 4,                      // Increased parallelism
-		Delay:       500 * time.Millisecond, // Reduced delay
-	}
-	if err := c.Limit(&limitRule); err != nil {
-		logrus.Errorf("[-] Unable to set scraper limit. Using default. Error: %v", err)
+	// Limit the rate per host, check the address of every connection and the target of every redirect
+	throttle := newThrottle(politeness)
+	c.WithTransport(budget.transport(throttle.transport(policy.transport())))
+	c.SetRedirectHandler(policy.checkRedirect)
+	if opts.MaxBytes > 0 && opts.MaxBytes < c.MaxBodySize {
+		c.MaxBodySize = opts.MaxBytes
 	}
 
 	// Increase the timeout slightly if necessary
 	c.SetRequestTimeout(240 * time.Second) // Increased to 4 minutes
 
-	// Initialize a backoff strategy
-	backoffStrategy := backoff.NewExponentialBackOff()
+	var robotsRules *robots
+	if politeness.RespectRobotsTxt {
+		f := newFetcher(policy, budget.transport(policy.transport()), 0)
+		f.onBytes = budget.addBytes
+		robotsRules = newRobots(f, politeness.userAgent())
+	}
 
-	c.OnError(func(r *colly.Response, err error) {
-		if r.StatusCode == http.StatusTooManyRequests {
-			// Parse the Retry-After header (in seconds)
-			retryAfter, convErr := strconv.Atoi(r.Headers.Get("Retry-After"))
-			if convErr != nil {
-				// If not in seconds, it might be a date. Handle accordingly.
-				logrus.Debugf("[-] Retry-After: %s", r.Headers.Get("Retry-After"))
+	c.OnRequest(func(r *colly.Request) {
+		pages.request(r)
+		if robotsRules != nil {
+			group := robotsRules.group(budget.ctx, r.URL)
+			if !group.Test(r.URL.RequestURI()) {
+				logrus.Infof("[-] Not scraping %s, disallowed by robots.txt", r.URL)
+				pages.disallow(r.URL)
+				r.Abort()
+				return
 			}
-			// Calculate the next delay
-			nextDelay := backoffStrategy.NextBackOff()
-			if retryAfter > 0 {
-				nextDelay = time.Duration(retryAfter) * time.Second
+			if group.CrawlDelay > 0 {
+				throttle.slowDown(r.URL.Host, group.CrawlDelay)
 			}
-			logrus.Warnf("[-] Rate limited. Retrying after %v", nextDelay)
-			time.Sleep(nextDelay)
-			// Retry the request
-			_ = r.Request.Retry()
-		} else {
-			logrus.Errorf("[-] Request URL: %s failed with error: %v", r.Request.URL, err)
+		}
+		if !budget.startPage(r.URL) {
+			r.Abort()
 		}
 	})
 
-	c.OnHTML("h1, h2", func(e *colly.HTMLElement) {
-		// Directly append a new Section to collectedData.Sections
-		collectedData.Sections = append(collectedData.Sections, Section{Title: e.Text})
-	})
+	c.OnResponse(func(r *colly.Response) {
+		budget.addBytes(len(r.Body))
+		page := pages.respond(r)
 
-	c.OnHTML("p", func(e *colly.HTMLElement) {
-		// Check if there are any sections to append paragraphs to
-		if len(collectedData.Sections) > 0 {
-			// Get a reference to the last section
-			lastSection := &collectedData.Sections[len(collectedData.Sections)-1]
-			// Append the paragraph to the last section
-			// Check for duplicate paragraphs before appending
-			isDuplicate := false
-			for _, paragraph := range lastSection.Paragraphs {
-				if paragraph == e.Text {
-					isDuplicate = true
-					break
-				}
+		// The OnHTML callbacks only handle HTML pages
+		contentType := r.Headers.Get("Content-Type")
+		if page == nil || isHTML(contentType) {
+			return
+		}
+		file := ReadFile(r.Body, r.Request.URL.String(), contentType)
+		page.file = &file
+		if opts.Content != "" && file.Text != "" {
+			page.document = &Document{
+				URL:     file.URL,
+				Title:   file.Title,
+				Format:  ContentText,
+				Content: file.Text,
 			}
-			// Handle dupes
-			if !isDuplicate {
-				lastSection.Paragraphs = append(lastSection.Paragraphs, e.Text)
+		}
+	})
+
+	c.OnError(func(r *colly.Response, err error) {
+		if budget.stopped() {
+			// Requests cancelled when a budget ran out
+			logrus.Debugf("[-] Request URL: %s stopped: %v", r.Request.URL, err)
+			return
+		}
+		if violation := policyViolation(err, r.Request.URL); violation != nil {
+			logrus.Warnf("[-] Not scraping %s", violation)
+			// Only the requested URLs fail the scrape, links and discovered pages are skipped
+			if pages.depth(r.Request) == 1 && !discovering {
+				mutex.Lock()
+				policyErr = violation
+				mutex.Unlock()
 			}
+			return
 		}
+		// Rate limited requests were already retried by the throttle
+		logrus.Errorf("[-] Request URL: %s failed with error: %v", r.Request.URL, err)
 	})
 
-	c.OnHTML("img", func(e *colly.HTMLElement) {
-		imageURL := e.Request.AbsoluteURL(e.Attr("src"))
-		if len(collectedData.Sections) > 0 {
-			lastSection := &collectedData.Sections[len(collectedData.Sections)-1]
-			lastSection.Images = append(lastSection.Images, imageURL)
+	c.OnHTML("html", func(e *colly.HTMLElement) {
+		page := pages.page(e.Request)
+		if page == nil {
+			return
+		}
+		page.Sections = pageSections(e.DOM, e.Request)
+		if opts.Content != "" {
+			doc := extractDocument(e.DOM, e.Request.URL, *e.Response.Headers, opts.Content)
+			page.document = &doc
+		}
+		if opts.Metadata {
+			meta := extractMetadata(e.DOM, e.Request.URL)
+			page.metadata = &meta
+		}
+		if len(budget.extract) > 0 {
+			// Select from the document, so that absolute XPath expressions start at its root
+			root := e.DOM.Nodes[0]
+			for root.Parent != nil {
+				root = root.Parent
+			}
+			page.extracted = &Extraction{URL: e.Request.URL.String(), Data: extractFields(budget.extract, root, e.Request.URL)}
 		}
 	})
 
 	c.OnHTML("a", func(e *colly.HTMLElement) {
 		pageURL := e.Request.AbsoluteURL(e.Attr("href"))
-		// Check if the URL protocol is supported (http or https)
-		if strings.HasPrefix(pageURL, "http://") || strings.HasPrefix(pageURL, "https://") {
-			collectedData.Pages = append(collectedData.Pages, pageURL)
-			_ = e.Request.Visit(pageURL)
+		parsed, err := url.Parse(pageURL)
+		if pageURL == "" || err != nil {
+			return
+		}
+		// Skip links the policy blocks, including other protocols than http and https
+		if err := policy.CheckURL(parsed); err != nil {
+			logrus.Debugf("[-] Not following %s", err)
+			return
+		}
+		if !budget.follows(parsed) {
+			return
+		}
+		for _, link := range pages.link(e.Request, parsed) {
+			if budget.stopped() {
+				return
+			}
+			if err := c.Visit(link); err != nil {
+				logrus.Debugf("[-] Not scraping %s: %v", link, err)
+			}
 		}
 	})
 
-	for _, u := range uri {
-		err := c.Visit(u)
-		if err != nil {
+	for _, u := range pages.schedule(starts) {
+		if err := c.Visit(u); err != nil {
+			budget.finish()
 			return nil, err
 		}
 	}
-
-	// Wait for all requests to finish
 	c.Wait()
+	collectedData.StopReason = budget.finish()
+	pages.collect(&collectedData)
+	collectedData.PageCount, collectedData.ByteCount = budget.pages, budget.bytes
+
+	if policyErr != nil {
+		return nil, policyErr
+	}
 
 	j, _ := json.Marshal(collectedData)
 	return j, nil
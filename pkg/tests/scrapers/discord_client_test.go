@@ -0,0 +1,144 @@
+package scrapers_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/Gzgod/masa-oracle/pkg/scrapers/discord"
+)
+
+var _ = Describe("Discord REST client", func() {
+	var (
+		server  *httptest.Server
+		client  *discord.Client
+		handler http.HandlerFunc
+		calls   atomic.Int32
+	)
+
+	BeforeEach(func() {
+		calls.Store(0)
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			if r.URL.Path == "/oauth2/token" {
+				Expect(r.Header.Get("Authorization")).To(BeEmpty())
+			} else {
+				Expect(r.Header.Get("Authorization")).To(Equal("Bot test-token"))
+			}
+			handler(w, r)
+		}))
+		client = discord.NewClient(server.URL, "test-token")
+		client.MaxRetryWait = time.Second
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("retries after the Retry-After of a 429", func() {
+		handler = func(w http.ResponseWriter, r *http.Request) {
+			if calls.Load() == 1 {
+				w.Header().Set("Retry-After", "0.05")
+				w.WriteHeader(http.StatusTooManyRequests)
+				_, _ = w.Write([]byte(`{"message": "You are being rate limited.", "retry_after": 0.05, "global": false}`))
+				return
+			}
+			_, _ = w.Write([]byte(`[{"id": "1", "content": "hi"}]`))
+		}
+		var messages []discord.ChannelMessage
+		Expect(client.Get(context.Background(), "/channels/123/messages", nil, &messages)).To(Succeed())
+		Expect(messages).To(HaveLen(1))
+		Expect(calls.Load()).To(Equal(int32(2)))
+	})
+
+	It("returns a RateLimitError when the limit is longer than it waits", func() {
+		handler = func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-RateLimit-Global", "true")
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = w.Write([]byte(`{"retry_after": 60, "global": true}`))
+		}
+		err := client.Get(context.Background(), "/users/1", nil, nil)
+		Expect(errors.Is(err, discord.ErrRateLimited)).To(BeTrue())
+		var limit *discord.RateLimitError
+		Expect(errors.As(err, &limit)).To(BeTrue())
+		Expect(limit.Global).To(BeTrue())
+
+		// The global limit applies to every route without another request
+		err = client.Get(context.Background(), "/guilds/2/channels", nil, nil)
+		Expect(errors.Is(err, discord.ErrRateLimited)).To(BeTrue())
+		Expect(calls.Load()).To(Equal(int32(1)))
+	})
+
+	It("waits for an exhausted bucket to reset", func() {
+		handler = func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-RateLimit-Bucket", "abc")
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset-After", "0.2")
+			_, _ = w.Write([]byte(`[]`))
+		}
+		Expect(client.Get(context.Background(), "/channels/123/messages", nil, nil)).To(Succeed())
+		start := time.Now()
+		Expect(client.Get(context.Background(), "/channels/123/messages", nil, nil)).To(Succeed())
+		Expect(time.Since(start)).To(BeNumerically(">=", 150*time.Millisecond))
+
+		// Another channel has its own limit
+		start = time.Now()
+		Expect(client.Get(context.Background(), "/channels/456/messages", nil, nil)).To(Succeed())
+		Expect(time.Since(start)).To(BeNumerically("<", 150*time.Millisecond))
+	})
+
+	It("retries server errors and returns typed API errors", func() {
+		handler = func(w http.ResponseWriter, r *http.Request) {
+			if calls.Load() == 1 {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message": "Unknown Channel", "code": 10003}`))
+		}
+		err := client.Get(context.Background(), "/channels/123/messages", nil, nil)
+		Expect(errors.Is(err, discord.ErrNotFound)).To(BeTrue())
+		var apiErr *discord.APIError
+		Expect(errors.As(err, &apiErr)).To(BeTrue())
+		Expect(apiErr.Code).To(Equal(10003))
+		Expect(apiErr.Route).To(Equal("GET /channels/123/messages"))
+		Expect(calls.Load()).To(Equal(int32(2)))
+	})
+
+	It("does not retry server errors of non-idempotent requests", func() {
+		handler = func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.Method).To(Equal(http.MethodPost))
+			w.WriteHeader(http.StatusBadGateway)
+		}
+		previous := discord.DefaultClient()
+		discord.SetDefaultClient(client)
+		defer discord.SetDefaultClient(previous)
+
+		_, err := discord.ExchangeCode("code")
+		var apiErr *discord.APIError
+		Expect(errors.As(err, &apiErr)).To(BeTrue())
+		Expect(apiErr.StatusCode).To(Equal(http.StatusBadGateway))
+		Expect(calls.Load()).To(Equal(int32(1)))
+	})
+
+	It("is used by the package functions", func() {
+		handler = func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.URL.Path).To(Equal("/guilds/42/channels"))
+			_, _ = w.Write([]byte(`[{"id": "7", "guild_id": "42", "name": "general"}]`))
+		}
+		previous := discord.DefaultClient()
+		discord.SetDefaultClient(client)
+		defer discord.SetDefaultClient(previous)
+
+		channels, err := discord.GetGuildChannels("42")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(channels).To(HaveLen(1))
+		Expect(channels[0].Name).To(Equal("general"))
+	})
+})
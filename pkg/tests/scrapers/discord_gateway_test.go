@@ -0,0 +1,221 @@
+package scrapers_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/Gzgod/masa-oracle/pkg/scrapers/discord"
+)
+
+type gatewayFrame struct {
+	Op       int             `json:"op"`
+	Data     json.RawMessage `json:"d,omitempty"`
+	Sequence int64           `json:"s,omitempty"`
+	Type     string          `json:"t,omitempty"`
+}
+
+// mockGateway is a local Discord gateway. Each connection is handed to the next session script.
+type mockGateway struct {
+	server   *httptest.Server
+	mu       sync.Mutex
+	sessions []func(conn *websocket.Conn, hello gatewayFrame)
+	received []gatewayFrame
+}
+
+func newMockGateway(sessions ...func(conn *websocket.Conn, hello gatewayFrame)) *mockGateway {
+	mock := &mockGateway{sessions: sessions}
+	upgrader := websocket.Upgrader{}
+	mock.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		_ = conn.WriteJSON(gatewayFrame{Op: 10, Data: json.RawMessage(`{"heartbeat_interval": 100}`)})
+		var frame gatewayFrame
+		if err := conn.ReadJSON(&frame); err != nil {
+			return
+		}
+		mock.mu.Lock()
+		mock.received = append(mock.received, frame)
+		session := mock.sessions[0]
+		if len(mock.sessions) > 1 {
+			mock.sessions = mock.sessions[1:]
+		}
+		mock.mu.Unlock()
+		session(conn, frame)
+	}))
+	return mock
+}
+
+func (m *mockGateway) url() string {
+	return "ws" + strings.TrimPrefix(m.server.URL, "http")
+}
+
+func (m *mockGateway) frames() []gatewayFrame {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]gatewayFrame(nil), m.received...)
+}
+
+func dispatch(conn *websocket.Conn, seq int64, event string, data string) {
+	_ = conn.WriteJSON(gatewayFrame{Op: 0, Sequence: seq, Type: event, Data: json.RawMessage(data)})
+}
+
+// acknowledgeHeartbeats answers heartbeats until the client disconnects.
+func acknowledgeHeartbeats(conn *websocket.Conn) {
+	for {
+		var frame gatewayFrame
+		if err := conn.ReadJSON(&frame); err != nil {
+			return
+		}
+		if frame.Op == 1 {
+			_ = conn.WriteJSON(gatewayFrame{Op: 11})
+		}
+	}
+}
+
+var _ = Describe("Discord gateway", func() {
+	It("receives the selected messages and resumes after a disconnect", func() {
+		var mock *mockGateway
+		mock = newMockGateway(
+			func(conn *websocket.Conn, hello gatewayFrame) {
+				dispatch(conn, 1, "READY", `{"session_id": "session-1", "resume_gateway_url": "`+mock.url()+`"}`)
+				dispatch(conn, 2, "MESSAGE_CREATE", `{"id": "10", "channel_id": "c1", "guild_id": "g1", "content": "first"}`)
+				dispatch(conn, 3, "MESSAGE_CREATE", `{"id": "11", "channel_id": "c2", "guild_id": "g2", "content": "not subscribed"}`)
+				_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(4000, "unknown error"))
+			},
+			func(conn *websocket.Conn, hello gatewayFrame) {
+				dispatch(conn, 4, "RESUMED", `{}`)
+				dispatch(conn, 5, "MESSAGE_CREATE", `{"id": "12", "channel_id": "c1", "guild_id": "g1", "content": "after resume"}`)
+				acknowledgeHeartbeats(conn)
+			},
+		)
+		defer mock.server.Close()
+
+		var mu sync.Mutex
+		var messages []discord.ChannelMessage
+		gateway := discord.NewGateway(discord.GatewayConfig{
+			URL:        mock.url(),
+			Token:      "test-token",
+			ChannelIDs: []string{"c1"},
+		}, func(message discord.ChannelMessage) {
+			mu.Lock()
+			defer mu.Unlock()
+			messages = append(messages, message)
+		})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() { done <- gateway.Run(ctx) }()
+
+		Eventually(func() int {
+			mu.Lock()
+			defer mu.Unlock()
+			return len(messages)
+		}, 5*time.Second, 20*time.Millisecond).Should(Equal(2))
+		cancel()
+		Eventually(done, time.Second).Should(Receive(BeNil()))
+
+		Expect(messages[0].Content).To(Equal("first"))
+		Expect(messages[1].Content).To(Equal("after resume"))
+
+		frames := mock.frames()
+		Expect(frames).To(HaveLen(2))
+		Expect(frames[0].Op).To(Equal(2))
+		Expect(frames[1].Op).To(Equal(6))
+		var resume struct {
+			SessionID string `json:"session_id"`
+			Seq       int64  `json:"seq"`
+		}
+		Expect(json.Unmarshal(frames[1].Data, &resume)).To(Succeed())
+		Expect(resume.SessionID).To(Equal("session-1"))
+		Expect(resume.Seq).To(Equal(int64(3)))
+	})
+
+	It("keeps the session resumable when the gateway asks for a reconnect", func() {
+		closeCodes := make(chan int, 1)
+		var mock *mockGateway
+		mock = newMockGateway(
+			func(conn *websocket.Conn, hello gatewayFrame) {
+				dispatch(conn, 1, "READY", `{"session_id": "session-1", "resume_gateway_url": "`+mock.url()+`"}`)
+				dispatch(conn, 2, "MESSAGE_CREATE", `{"id": "10", "channel_id": "c1", "guild_id": "g1", "content": "first"}`)
+				_ = conn.WriteJSON(gatewayFrame{Op: 7})
+				for {
+					var frame gatewayFrame
+					if err := conn.ReadJSON(&frame); err != nil {
+						var closeErr *websocket.CloseError
+						if errors.As(err, &closeErr) {
+							closeCodes <- closeErr.Code
+						}
+						return
+					}
+				}
+			},
+			func(conn *websocket.Conn, hello gatewayFrame) {
+				dispatch(conn, 3, "RESUMED", `{}`)
+				dispatch(conn, 4, "MESSAGE_CREATE", `{"id": "11", "channel_id": "c1", "guild_id": "g1", "content": "after resume"}`)
+				acknowledgeHeartbeats(conn)
+			},
+		)
+		defer mock.server.Close()
+
+		var mu sync.Mutex
+		var messages []discord.ChannelMessage
+		gateway := discord.NewGateway(discord.GatewayConfig{URL: mock.url(), Token: "test-token"}, func(message discord.ChannelMessage) {
+			mu.Lock()
+			defer mu.Unlock()
+			messages = append(messages, message)
+		})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() { done <- gateway.Run(ctx) }()
+
+		Eventually(func() int {
+			mu.Lock()
+			defer mu.Unlock()
+			return len(messages)
+		}, 5*time.Second, 20*time.Millisecond).Should(Equal(2))
+		cancel()
+		Eventually(done, time.Second).Should(Receive(BeNil()))
+
+		// 1000 and 1001 would make Discord invalidate the session
+		Expect(closeCodes).To(Receive(Equal(4000)))
+		Expect(messages[1].Content).To(Equal("after resume"))
+
+		frames := mock.frames()
+		Expect(frames).To(HaveLen(2))
+		Expect(frames[1].Op).To(Equal(6))
+		var resume struct {
+			SessionID string `json:"session_id"`
+			Seq       int64  `json:"seq"`
+		}
+		Expect(json.Unmarshal(frames[1].Data, &resume)).To(Succeed())
+		Expect(resume.SessionID).To(Equal("session-1"))
+		Expect(resume.Seq).To(Equal(int64(2)))
+	})
+
+	It("stops when authentication fails", func() {
+		mock := newMockGateway(func(conn *websocket.Conn, hello gatewayFrame) {
+			_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(4004, "authentication failed"))
+		})
+		defer mock.server.Close()
+
+		gateway := discord.NewGateway(discord.GatewayConfig{URL: mock.url(), Token: "bad-token"}, nil)
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		err := gateway.Run(ctx)
+		Expect(errors.Is(err, discord.ErrGatewayClosed)).To(BeTrue())
+	})
+})
@@ -0,0 +1,120 @@
+package scrapers_test
+
+import (
+	"errors"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/Gzgod/masa-oracle/pkg/scrapers/discord"
+)
+
+// fakeChannel serves pages of a channel the way the Discord API does: at most limit messages
+// before or after the cursor, newest first.
+type fakeChannel struct {
+	messages []discord.ChannelMessage // oldest first
+	requests int
+	failAt   int
+}
+
+func newFakeChannel(start time.Time, count int) *fakeChannel {
+	channel := &fakeChannel{}
+	for i := 0; i < count; i++ {
+		id := discord.SnowflakeFromTime(start.Add(time.Duration(i) * time.Minute))
+		channel.messages = append(channel.messages, discord.ChannelMessage{ID: id, ChannelID: "c1", Content: "message " + strconv.Itoa(i)})
+	}
+	return channel
+}
+
+func (f *fakeChannel) fetch(channelID string, query url.Values) ([]discord.ChannelMessage, error) {
+	f.requests++
+	if f.failAt > 0 && f.requests == f.failAt {
+		return nil, errors.New("status code: 500")
+	}
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	var page []discord.ChannelMessage
+	if after := query.Get("after"); after != "" {
+		cursor, _ := strconv.ParseUint(after, 10, 64)
+		for _, message := range f.messages {
+			if id, _ := strconv.ParseUint(message.ID, 10, 64); id > cursor && len(page) < limit {
+				page = append(page, message)
+			}
+		}
+	} else {
+		cursor := uint64(1<<64 - 1)
+		if before := query.Get("before"); before != "" {
+			cursor, _ = strconv.ParseUint(before, 10, 64)
+		}
+		for i := len(f.messages) - 1; i >= 0; i-- {
+			if id, _ := strconv.ParseUint(f.messages[i].ID, 10, 64); id < cursor && len(page) < limit {
+				page = append(page, f.messages[i])
+			}
+		}
+	}
+	sort.Slice(page, func(i, j int) bool { return page[i].ID > page[j].ID })
+	return page, nil
+}
+
+var _ = Describe("Discord channel history", func() {
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	It("walks backward through the whole channel", func() {
+		channel := newFakeChannel(start, 250)
+		history, err := discord.WalkChannelHistory(discord.HistoryOptions{ChannelID: "c1"}, channel.fetch)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(history.MessageCount).To(Equal(250))
+		Expect(history.Chunks).To(HaveLen(3))
+		Expect(history.Complete).To(BeTrue())
+		Expect(history.StopReason).To(Equal(discord.StopEnd))
+		Expect(history.Chunks[0].FirstID).To(Equal(channel.messages[249].ID))
+		Expect(history.Cursor).To(Equal(channel.messages[0].ID))
+	})
+
+	It("stops at the limit and resumes from the cursor", func() {
+		channel := newFakeChannel(start, 250)
+		history, err := discord.WalkChannelHistory(discord.HistoryOptions{ChannelID: "c1", Limit: 120}, channel.fetch)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(history.MessageCount).To(Equal(120))
+		Expect(history.StopReason).To(Equal(discord.StopLimit))
+		Expect(history.Complete).To(BeFalse())
+
+		rest, err := discord.WalkChannelHistory(discord.HistoryOptions{ChannelID: "c1", Before: history.Cursor}, channel.fetch)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rest.MessageCount).To(Equal(130))
+		Expect(rest.Chunks[0].FirstID).To(Equal(channel.messages[129].ID))
+	})
+
+	It("walks forward within a date range", func() {
+		channel := newFakeChannel(start, 250)
+		history, err := discord.WalkChannelHistory(discord.HistoryOptions{
+			ChannelID: "c1",
+			Direction: discord.DirectionForward,
+			Since:     start.Add(10 * time.Minute),
+			Until:     start.Add(59*time.Minute + 30*time.Second),
+			ChunkSize: 20,
+		}, channel.fetch)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(history.MessageCount).To(Equal(50))
+		Expect(history.StopReason).To(Equal(discord.StopDate))
+		Expect(history.Chunks[0].FirstID).To(Equal(channel.messages[10].ID))
+		Expect(history.Cursor).To(Equal(channel.messages[59].ID))
+	})
+
+	It("keeps the collected pages when a page fails", func() {
+		channel := newFakeChannel(start, 250)
+		channel.failAt = 2
+		history, err := discord.WalkChannelHistory(discord.HistoryOptions{ChannelID: "c1"}, channel.fetch)
+		Expect(err).To(HaveOccurred())
+		Expect(history.MessageCount).To(Equal(100))
+		Expect(history.Cursor).To(Equal(channel.messages[150].ID))
+	})
+
+	It("converts snowflakes to times", func() {
+		Expect(discord.SnowflakeTime(discord.SnowflakeFromTime(start))).To(Equal(start))
+		Expect(discord.SnowflakeTime("175928847299117063")).To(Equal(time.Date(2016, 4, 30, 11, 18, 25, 796000000, time.UTC)))
+	})
+})
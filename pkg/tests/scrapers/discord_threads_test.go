@@ -0,0 +1,83 @@
+package scrapers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/Gzgod/masa-oracle/pkg/scrapers/discord"
+)
+
+var _ = Describe("Discord threads, pins and reactions", func() {
+	var (
+		server   *httptest.Server
+		previous *discord.Client
+	)
+
+	BeforeEach(func() {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/channels/100", func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"id": "100", "guild_id": "1", "name": "forum", "type": 15}`))
+		})
+		mux.HandleFunc("/guilds/1/threads/active", func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"threads": [
+				{"id": "200", "parent_id": "100", "name": "First post", "type": 11, "message_count": 4, "applied_tags": ["7"]},
+				{"id": "201", "parent_id": "999", "name": "Elsewhere", "type": 11}
+			]}`))
+		})
+		mux.HandleFunc("/channels/100/threads/archived/public", func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.URL.Query().Get("before")).To(Equal("2024-01-01T00:00:00Z"))
+			Expect(r.URL.Query().Get("limit")).To(Equal("2"))
+			_, _ = w.Write([]byte(`{"threads": [{"id": "202", "parent_id": "100", "thread_metadata": {"archived": true}}], "has_more": true}`))
+		})
+		mux.HandleFunc("/channels/100/pins", func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`[{"id": "300", "channel_id": "100", "content": "rules", "pinned": true,
+				"reactions": [{"count": 3, "emoji": {"name": "🔥"}}, {"count": 2, "emoji": {"id": "55", "name": "masa"}}]}]`))
+		})
+		mux.HandleFunc("/channels/100/messages/300/reactions/", func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.URL.EscapedPath()).To(Equal("/channels/100/messages/300/reactions/masa:55"))
+			Expect(r.URL.Query().Get("after")).To(Equal("10"))
+			_, _ = w.Write([]byte(`[{"id": "11", "username": "naut"}]`))
+		})
+		server = httptest.NewServer(mux)
+		previous = discord.DefaultClient()
+		discord.SetDefaultClient(discord.NewClient(server.URL, "test-token"))
+	})
+
+	AfterEach(func() {
+		discord.SetDefaultClient(previous)
+		server.Close()
+	})
+
+	It("lists the active threads of a channel", func() {
+		threads, err := discord.GetActiveThreads("100")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(threads).To(HaveLen(1))
+		Expect(threads[0].Name).To(Equal("First post"))
+		Expect(threads[0].AppliedTags).To(ConsistOf("7"))
+	})
+
+	It("pages archived threads", func() {
+		list, err := discord.GetArchivedThreads("100", false, "2024-01-01T00:00:00Z", "2")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(list.HasMore).To(BeTrue())
+		Expect(list.Threads[0].ThreadMetadata.Archived).To(BeTrue())
+	})
+
+	It("returns pinned messages with their reactions", func() {
+		messages, err := discord.GetPinnedMessages("100")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(messages).To(HaveLen(1))
+		Expect(messages[0].Pinned).To(BeTrue())
+		Expect(discord.MessageToPost(messages[0]).Metrics["reactions"]).To(Equal(5))
+	})
+
+	It("returns the users who reacted with an emoji", func() {
+		users, err := discord.GetReactionUsers("100", "300", "masa:55", "10", "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(users).To(HaveLen(1))
+		Expect(discord.UserProfilesToAuthors(users)[0].Username).To(Equal("naut"))
+	})
+})
@@ -0,0 +1,106 @@
+package scrapers_test
+
+import (
+	"encoding/json"
+	"time"
+
+	twitterscraper "github.com/masa-finance/masa-twitter-scraper"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/Gzgod/masa-oracle/pkg/scrapers/discord"
+	"github.com/Gzgod/masa-oracle/pkg/scrapers/normalized"
+	"github.com/Gzgod/masa-oracle/pkg/scrapers/telegram"
+	"github.com/Gzgod/masa-oracle/pkg/scrapers/twitter"
+	"github.com/Gzgod/masa-oracle/pkg/scrapers/web"
+)
+
+var _ = Describe("Normalized posts", func() {
+	It("converts tweets", func() {
+		createdAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+		results := []*twitter.TweetResult{{Tweet: &twitterscraper.Tweet{
+			ID:           "1",
+			Text:         "hello #masa",
+			Hashtags:     []string{"masa"},
+			Likes:        3,
+			PermanentURL: "https://twitter.com/masa/status/1",
+			TimeParsed:   createdAt,
+			UserID:       "42",
+			Username:     "masa",
+			Name:         "Masa",
+			Photos:       []twitterscraper.Photo{{ID: "p", URL: "https://pbs.twimg.com/p.jpg"}},
+		}}, {Tweet: nil}}
+
+		posts := twitter.TweetsToPosts(results)
+		Expect(posts).To(HaveLen(1))
+		post := posts[0]
+		Expect(post.Source).To(Equal(normalized.SourceTwitter))
+		Expect(post.ID).To(Equal("1"))
+		Expect(*post.CreatedAt).To(Equal(createdAt))
+		Expect(post.Author.Username).To(Equal("masa"))
+		Expect(post.Author.URL).To(Equal("https://twitter.com/masa"))
+		Expect(post.Metrics["likes"]).To(Equal(3))
+		Expect(post.Tags).To(ConsistOf("masa"))
+		Expect(post.Media).To(ConsistOf(normalized.Media{Type: normalized.MediaImage, URL: "https://pbs.twimg.com/p.jpg"}))
+		Expect(post.Extensions).To(HaveKey("twitter"))
+	})
+
+	It("converts Discord messages", func() {
+		var message discord.ChannelMessage
+		Expect(json.Unmarshal([]byte(`{
+			"id": "10", "channel_id": "20", "content": "gm",
+			"timestamp": "2024-05-01T12:00:00.000000+00:00",
+			"author": {"id": "30", "username": "alice", "avatar": "abc"}
+		}`), &message)).To(Succeed())
+
+		post := discord.MessageToPost(message)
+		Expect(post.Source).To(Equal(normalized.SourceDiscord))
+		Expect(post.Text).To(Equal("gm"))
+		Expect(post.ChannelID).To(Equal("20"))
+		Expect(post.CreatedAt).NotTo(BeNil())
+		Expect(post.Author.AvatarURL).To(Equal("https://cdn.discordapp.com/avatars/30/abc.png"))
+	})
+
+	It("converts Telegram messages", func() {
+		message := telegram.Message{
+			ID:    7,
+			Text:  "news",
+			Date:  time.Unix(1714564800, 0).UTC(),
+			Chat:  &telegram.Peer{ID: 99, Type: telegram.PeerChannel},
+			Views: 5,
+			Media: &telegram.Media{Type: telegram.MediaVideo, MimeType: "video/mp4", Size: 1024, FileName: "clip.mp4"},
+		}
+
+		posts := telegram.MessagesToPosts("masa", []telegram.Message{message})
+		Expect(posts).To(HaveLen(1))
+		post := posts[0]
+		Expect(post.URL).To(Equal("https://t.me/masa/7"))
+		Expect(post.Author.ID).To(Equal("99"))
+		Expect(post.CreatedAt.Unix()).To(Equal(int64(1714564800)))
+		Expect(post.Metrics).To(HaveKeyWithValue("views", 5))
+		Expect(post.Media).To(ConsistOf(normalized.Media{
+			Type: normalized.MediaVideo, MimeType: "video/mp4", Size: 1024, Name: "clip.mp4",
+		}))
+	})
+
+	It("converts web sections", func() {
+		data := web.CollectedData{Sections: []web.Section{{
+			Title:      " Intro ",
+			Paragraphs: []string{"first", "second"},
+			Images:     []string{"https://example.com/a.png"},
+		}}}
+
+		posts := web.CollectedDataToPosts("https://example.com", data)
+		Expect(posts).To(HaveLen(1))
+		Expect(posts[0].Title).To(Equal("Intro"))
+		Expect(posts[0].Text).To(Equal("first\n\nsecond"))
+		Expect(posts[0].URL).To(Equal("https://example.com"))
+		Expect(posts[0].Media).To(HaveLen(1))
+	})
+
+	It("validates the requested format", func() {
+		Expect(normalized.ValidateFormat("")).To(Succeed())
+		Expect(normalized.ValidateFormat(normalized.FormatNormalized)).To(Succeed())
+		Expect(normalized.ValidateFormat("xml")).NotTo(Succeed())
+	})
+})
@@ -0,0 +1,245 @@
+package scrapers_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/Gzgod/masa-oracle/pkg/scrapers/normalized"
+	"github.com/Gzgod/masa-oracle/pkg/scrapers/reddit"
+)
+
+var _ = Describe("Reddit scraper", func() {
+	var (
+		mux      *http.ServeMux
+		server   *httptest.Server
+		previous *reddit.Client
+		tokens   atomic.Int32
+	)
+
+	fixture := func(name string) http.HandlerFunc {
+		data, err := os.ReadFile(filepath.Join("testdata", "reddit", name))
+		Expect(err).NotTo(HaveOccurred())
+		return func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.Header.Get("Authorization")).To(Equal("Bearer token-1"))
+			Expect(r.Header.Get("User-Agent")).To(Equal("test-agent"))
+			Expect(r.URL.Query().Get("raw_json")).To(Equal("1"))
+			_, _ = w.Write(data)
+		}
+	}
+
+	BeforeEach(func() {
+		tokens.Store(0)
+		mux = http.NewServeMux()
+		mux.HandleFunc("/api/v1/access_token", func(w http.ResponseWriter, r *http.Request) {
+			id, secret, ok := r.BasicAuth()
+			Expect(ok).To(BeTrue())
+			Expect(id).To(Equal("client-id"))
+			Expect(secret).To(Equal("client-secret"))
+			Expect(r.ParseForm()).To(Succeed())
+			Expect(r.PostForm.Get("grant_type")).To(Equal("client_credentials"))
+			tokens.Add(1)
+			_, _ = w.Write([]byte(`{"access_token": "token-1", "token_type": "bearer", "expires_in": 86400, "scope": "*"}`))
+		})
+		server = httptest.NewServer(mux)
+
+		client := reddit.NewClient(server.URL, server.URL+"/api/v1/access_token")
+		client.ClientID, client.ClientSecret, client.UserAgent = "client-id", "client-secret", "test-agent"
+		previous = reddit.DefaultClient()
+		reddit.SetDefaultClient(client)
+	})
+
+	AfterEach(func() {
+		reddit.SetDefaultClient(previous)
+		server.Close()
+	})
+
+	It("pages through a subreddit listing with after tokens", func() {
+		page1, page2 := fixture("subreddit_new_page1.json"), fixture("subreddit_new_page2.json")
+		mux.HandleFunc("/r/golang/new", func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.URL.Query().Get("t")).To(Equal("week"))
+			switch r.URL.Query().Get("after") {
+			case "":
+				Expect(r.URL.Query().Get("limit")).To(Equal("3"))
+				page1(w, r)
+			case "t3_1b2c3e":
+				Expect(r.URL.Query().Get("limit")).To(Equal("1"))
+				page2(w, r)
+			default:
+				Fail("unexpected after token " + r.URL.Query().Get("after"))
+			}
+		})
+
+		listing, err := reddit.GetSubredditPosts(context.Background(), "golang", reddit.ListingOptions{Sort: "new", Time: "week", Limit: 3})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(listing.Posts).To(HaveLen(3))
+		Expect(listing.After).To(Equal("t3_1b2c3f"))
+		Expect(tokens.Load()).To(Equal(int32(1)))
+
+		first := listing.Posts[0]
+		Expect(first.Title).To(Equal(`Weekly "What are you working on?" thread`))
+		Expect(first.IsSelf).To(BeTrue())
+		Expect(first.Stickied).To(BeTrue())
+		Expect(first.Edited).To(BeZero())
+		Expect(first.Created().Unix()).To(Equal(int64(1717243200)))
+		Expect(listing.Posts[1].Edited).To(Equal(reddit.Edited(1717250000)))
+
+		posts := reddit.ListingToPosts(listing)
+		Expect(posts[0].Source).To(Equal(normalized.SourceReddit))
+		Expect(posts[0].URL).To(Equal("https://www.reddit.com/r/golang/comments/1b2c3d/weekly_what_are_you_working_on_thread/"))
+		Expect(posts[0].Tags).To(ConsistOf("discussion"))
+		Expect(posts[0].Author.ID).To(Equal("8xk2p"))
+		Expect(posts[1].Links).To(ConsistOf("https://go.dev/doc/go1.23"))
+		Expect(posts[1].Metrics).To(Equal(map[string]int{"score": 310, "comments": 57}))
+		Expect(posts[2].Media).To(HaveLen(1))
+		Expect(posts[2].Media[0].Type).To(Equal(normalized.MediaImage))
+	})
+
+	It("reads a comment tree with its missing replies", func() {
+		mux.HandleFunc("/comments/1b2c3d", func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.URL.Query().Get("sort")).To(Equal("top"))
+			Expect(r.URL.Query().Get("depth")).To(Equal("2"))
+			fixture("comments.json")(w, r)
+		})
+
+		tree, err := reddit.GetComments(context.Background(), "t3_1b2c3d", reddit.CommentOptions{Sort: "top", Depth: 2})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(tree.Post.ID).To(Equal("1b2c3d"))
+		Expect(tree.Comments).To(HaveLen(2))
+		Expect(reddit.CountComments(tree.Comments)).To(Equal(3))
+
+		top := tree.Comments[0]
+		Expect(top.Body).To(Equal("A CLI for tracking **build times**."))
+		Expect(top.Replies).To(HaveLen(1))
+		Expect(top.Replies[0].Depth).To(Equal(1))
+		Expect(top.More).NotTo(BeNil())
+		Expect(top.More.Children).To(ConsistOf("kc5", "kc6"))
+		Expect(tree.Comments[1].Replies).To(BeEmpty())
+
+		posts := reddit.CommentTreeToPosts(tree)
+		Expect(posts).To(HaveLen(4))
+		Expect(posts[1].ReplyToID).To(Equal("1b2c3d"))
+		Expect(posts[2].ReplyToID).To(Equal("kc1"))
+		Expect(posts[2].Author).To(BeNil())
+		Expect(posts[3].ID).To(Equal("kc3"))
+	})
+
+	It("reads the submissions of a user", func() {
+		mux.HandleFunc("/user/gopher/submitted", func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.URL.Query().Get("sort")).To(Equal("top"))
+			Expect(r.URL.Query().Get("t")).To(Equal("all"))
+			fixture("user_submitted.json")(w, r)
+		})
+
+		listing, err := reddit.GetUserPosts(context.Background(), "gopher", reddit.ListingOptions{Sort: "top", Time: "all"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(listing.Posts).To(HaveLen(1))
+		Expect(listing.After).To(BeEmpty())
+	})
+
+	It("searches within a subreddit", func() {
+		mux.HandleFunc("/r/CryptoCurrency/search", func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.URL.Query().Get("q")).To(Equal("masa oracle"))
+			Expect(r.URL.Query().Get("restrict_sr")).To(Equal("true"))
+			Expect(r.URL.Query().Get("sort")).To(Equal("relevance"))
+			fixture("search.json")(w, r)
+		})
+
+		listing, err := reddit.Search(context.Background(), reddit.SearchOptions{Query: "masa oracle", Subreddit: "CryptoCurrency", Limit: 1})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(listing.Posts[0].Flair).To(Equal("DISCUSSION"))
+		Expect(listing.After).To(Equal("t3_9zz9zz"))
+	})
+
+	It("renews the access token once when it is rejected", func() {
+		var calls atomic.Int32
+		mux.HandleFunc("/user/gopher/submitted", func(w http.ResponseWriter, r *http.Request) {
+			if calls.Add(1) == 1 {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			fixture("user_submitted.json")(w, r)
+		})
+
+		_, err := reddit.GetUserPosts(context.Background(), "gopher", reddit.ListingOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(tokens.Load()).To(Equal(int32(2)))
+	})
+
+	It("returns a rate limit error instead of waiting out a long limit", func() {
+		mux.HandleFunc("/r/golang/hot", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Ratelimit-Remaining", "0.0")
+			w.Header().Set("X-Ratelimit-Reset", "540")
+			w.WriteHeader(http.StatusTooManyRequests)
+		})
+
+		_, err := reddit.GetSubredditPosts(context.Background(), "golang", reddit.ListingOptions{})
+		Expect(errors.Is(err, reddit.ErrRateLimited)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("reddit rate limit exceeded (429 error)"))
+	})
+
+	It("returns the pages fetched before an error with the error", func() {
+		page1 := fixture("subreddit_new_page1.json")
+		mux.HandleFunc("/r/golang/new", func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("after") == "" {
+				page1(w, r)
+				return
+			}
+			w.Header().Set("X-Ratelimit-Remaining", "0.0")
+			w.Header().Set("X-Ratelimit-Reset", "540")
+			w.WriteHeader(http.StatusTooManyRequests)
+		})
+
+		listing, err := reddit.GetSubredditPosts(context.Background(), "golang", reddit.ListingOptions{Sort: "new", Time: "week", Limit: 3})
+		Expect(errors.Is(err, reddit.ErrRateLimited)).To(BeTrue())
+		Expect(listing.Posts).To(HaveLen(2))
+		Expect(listing.After).To(Equal("t3_1b2c3e"))
+		Expect(listing.Error).To(ContainSubstring("reddit rate limit exceeded"))
+		Expect(reddit.NormalizeListing(listing).Error).To(Equal(listing.Error))
+	})
+
+	It("reports private and missing subreddits", func() {
+		mux.HandleFunc("/r/secret/hot", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"reason": "private", "message": "Forbidden", "error": 403}`))
+		})
+
+		_, err := reddit.GetSubredditPosts(context.Background(), "secret", reddit.ListingOptions{})
+		Expect(errors.Is(err, reddit.ErrForbidden)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("Forbidden (private)"))
+	})
+
+	It("validates names and options before sending a request", func() {
+		_, err := reddit.GetSubredditPosts(context.Background(), "../admin", reddit.ListingOptions{})
+		Expect(errors.Is(err, reddit.ErrInvalidName)).To(BeTrue())
+		_, err = reddit.GetSubredditPosts(context.Background(), "golang", reddit.ListingOptions{Sort: "best"})
+		Expect(err).To(MatchError(ContainSubstring("invalid sort")))
+		_, err = reddit.GetUserPosts(context.Background(), "gopher", reddit.ListingOptions{Time: "decade"})
+		Expect(err).To(MatchError(ContainSubstring("invalid time window")))
+		_, err = reddit.Search(context.Background(), reddit.SearchOptions{})
+		Expect(err).To(HaveOccurred())
+		Expect(tokens.Load()).To(BeZero())
+	})
+
+	It("requires the app credentials", func() {
+		client := reddit.NewClient(server.URL, server.URL+"/api/v1/access_token")
+		reddit.SetDefaultClient(client)
+		for _, key := range []string{"REDDIT_CLIENT_ID", "REDDIT_CLIENT_SECRET"} {
+			previous, set := os.LookupEnv(key)
+			Expect(os.Unsetenv(key)).To(Succeed())
+			if set {
+				DeferCleanup(os.Setenv, key, previous)
+			}
+		}
+
+		_, err := reddit.GetSubredditPosts(context.Background(), "golang", reddit.ListingOptions{})
+		Expect(errors.Is(err, reddit.ErrMissingCredentials)).To(BeTrue())
+	})
+})
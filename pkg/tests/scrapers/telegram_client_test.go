@@ -0,0 +1,52 @@
+package scrapers_test
+
+import (
+	"context"
+	"errors"
+	"os"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/Gzgod/masa-oracle/pkg/scrapers/telegram"
+)
+
+var _ = Describe("Telegram client", func() {
+	setEnv := func(key, value string) {
+		previous, set := os.LookupEnv(key)
+		Expect(os.Setenv(key, value)).To(Succeed())
+		DeferCleanup(func() {
+			if set {
+				_ = os.Setenv(key, previous)
+			} else {
+				_ = os.Unsetenv(key)
+			}
+		})
+	}
+
+	It("returns an error instead of exiting when the credentials are missing", func() {
+		setEnv("TELEGRAM_APP_ID", "")
+		setEnv("TELEGRAM_APP_HASH", "")
+		_, err := telegram.GetClient(context.Background(), "")
+		Expect(errors.Is(err, telegram.ErrMissingCredentials)).To(BeTrue())
+
+		_, err = telegram.FetchChannelMessages(context.Background(), "masa")
+		Expect(errors.Is(err, telegram.ErrMissingCredentials)).To(BeTrue())
+	})
+
+	It("rejects an invalid app ID", func() {
+		setEnv("TELEGRAM_APP_ID", "not-a-number")
+		setEnv("TELEGRAM_APP_HASH", "hash")
+		_, err := telegram.GetClient(context.Background(), "")
+		Expect(err).To(MatchError(ContainSubstring("invalid TELEGRAM_APP_ID")))
+	})
+
+	It("returns an error for an unknown session", func() {
+		_, err := telegram.GetClient(context.Background(), "unknown")
+		Expect(errors.Is(err, telegram.ErrSessionNotFound)).To(BeTrue())
+	})
+
+	It("stops without a connection", func() {
+		Expect(telegram.Stop()).To(Succeed())
+	})
+})
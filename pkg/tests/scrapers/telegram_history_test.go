@@ -0,0 +1,157 @@
+package scrapers_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/gotd/td/tg"
+	"github.com/gotd/td/tgerr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/Gzgod/masa-oracle/pkg/scrapers/telegram"
+)
+
+// fakeHistory serves pages of a channel the way MessagesGetHistory does: at most limit messages
+// older than the offset ID or date, newest first.
+type fakeHistory struct {
+	messages  []*tg.Message // oldest first, one per minute
+	requests  []tg.MessagesGetHistoryRequest
+	floodWait map[int]string // request number to flood wait error
+}
+
+func newFakeHistory(start time.Time, count int) *fakeHistory {
+	history := &fakeHistory{floodWait: map[int]string{}}
+	for i := 1; i <= count; i++ {
+		history.messages = append(history.messages, &tg.Message{ID: i, Date: int(start.Add(time.Duration(i) * time.Minute).Unix())})
+	}
+	return history
+}
+
+func (f *fakeHistory) fetch(ctx context.Context, request *tg.MessagesGetHistoryRequest) (tg.MessagesMessagesClass, error) {
+	f.requests = append(f.requests, *request)
+	if message, ok := f.floodWait[len(f.requests)]; ok {
+		return nil, tgerr.New(420, message)
+	}
+	result := &tg.MessagesChannelMessages{Count: len(f.messages)}
+	for i := len(f.messages) - 1; i >= 0 && len(result.Messages) < request.Limit; i-- {
+		message := f.messages[i]
+		if request.OffsetID != 0 && message.ID >= request.OffsetID {
+			continue
+		}
+		if request.OffsetDate != 0 && message.Date >= request.OffsetDate {
+			continue
+		}
+		result.Messages = append(result.Messages, message)
+	}
+	return result, nil
+}
+
+var _ = Describe("Telegram channel history", func() {
+	var (
+		start   = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		history *fakeHistory
+		peer    = &tg.InputPeerChannel{ChannelID: 1}
+	)
+
+	BeforeEach(func() {
+		history = newFakeHistory(start, 250)
+	})
+
+	It("fetches the newest page by default", func() {
+		messages, err := telegram.WalkHistory(context.Background(), peer, telegram.HistoryOptions{}, history.fetch)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(messages).To(HaveLen(telegram.DefaultHistoryLimit))
+		Expect(messages[0].ID).To(Equal(250))
+		Expect(history.requests).To(HaveLen(1))
+	})
+
+	It("walks pages from the offset ID up to the limit", func() {
+		messages, err := telegram.WalkHistory(context.Background(), peer, telegram.HistoryOptions{OffsetID: 200, Limit: 120, PageSize: 50}, history.fetch)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(messages).To(HaveLen(120))
+		Expect(messages[0].ID).To(Equal(199))
+		Expect(messages[119].ID).To(Equal(80))
+		Expect(history.requests).To(HaveLen(3))
+		Expect(history.requests[1].OffsetID).To(Equal(150))
+		Expect(history.requests[2].Limit).To(Equal(20))
+	})
+
+	It("keeps the messages within the date range", func() {
+		opts := telegram.HistoryOptions{
+			Since: start.Add(10 * time.Minute),
+			Until: start.Add(30 * time.Minute),
+			Limit: 1000,
+		}
+		messages, err := telegram.WalkHistory(context.Background(), peer, opts, history.fetch)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(messages).To(HaveLen(21))
+		Expect(messages[0].ID).To(Equal(30))
+		Expect(messages[20].ID).To(Equal(10))
+	})
+
+	It("stops at the start of the history", func() {
+		messages, err := telegram.WalkHistory(context.Background(), peer, telegram.HistoryOptions{Limit: 1000}, history.fetch)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(messages).To(HaveLen(250))
+	})
+
+	It("waits out short flood waits", func() {
+		history.floodWait[2] = "FLOOD_WAIT_0"
+		messages, err := telegram.WalkHistory(context.Background(), peer, telegram.HistoryOptions{Limit: 150}, history.fetch)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(messages).To(HaveLen(150))
+		Expect(history.requests).To(HaveLen(3))
+		Expect(history.requests[2].OffsetID).To(Equal(151))
+	})
+
+	It("returns the messages fetched so far on a long flood wait", func() {
+		history.floodWait[2] = "FLOOD_WAIT_300"
+		messages, err := telegram.WalkHistory(context.Background(), peer, telegram.HistoryOptions{Limit: 150}, history.fetch)
+		Expect(err).To(HaveOccurred())
+		wait, ok := tgerr.AsFloodWait(err)
+		Expect(ok).To(BeTrue())
+		Expect(wait).To(Equal(300 * time.Second))
+		Expect(messages).To(HaveLen(100))
+		Expect(messages[99].ID).To(Equal(151))
+	})
+
+	It("reports a walk that reached the start of the history as complete", func() {
+		opts := telegram.HistoryOptions{Limit: 1000}
+		messages, err := telegram.WalkHistory(context.Background(), peer, opts, history.fetch)
+		result := telegram.NewChannelHistory(messages, opts, err)
+		Expect(result.Complete).To(BeTrue())
+		Expect(result.StopReason).To(Equal(telegram.StopEnd))
+		Expect(result.NextOffsetID).To(BeZero())
+	})
+
+	It("reports where to resume a walk that reached the limit", func() {
+		opts := telegram.HistoryOptions{Limit: 150}
+		messages, err := telegram.WalkHistory(context.Background(), peer, opts, history.fetch)
+		result := telegram.NewChannelHistory(messages, opts, err)
+		Expect(result.Complete).To(BeFalse())
+		Expect(result.StopReason).To(Equal(telegram.StopLimit))
+		Expect(result.NextOffsetID).To(Equal(101))
+	})
+
+	It("reports where to resume a walk cut short by an error", func() {
+		history.floodWait[2] = "FLOOD_WAIT_300"
+		opts := telegram.HistoryOptions{Limit: 150}
+		messages, err := telegram.WalkHistory(context.Background(), peer, opts, history.fetch)
+		result := telegram.NewChannelHistory(messages, opts, err)
+		Expect(result.Complete).To(BeFalse())
+		Expect(result.StopReason).To(Equal(telegram.StopError))
+		Expect(result.Error).To(ContainSubstring("flood wait"))
+		Expect(result.MessageCount).To(Equal(100))
+		Expect(result.NextOffsetID).To(Equal(151))
+	})
+
+	It("reports where to resume a walk that ran out of time", func() {
+		opts := telegram.HistoryOptions{OffsetID: 200}
+		result := telegram.NewChannelHistory(nil, opts, telegram.ErrTimeBudget)
+		Expect(result.Complete).To(BeFalse())
+		Expect(result.StopReason).To(Equal(telegram.StopTime))
+		Expect(result.Error).To(BeEmpty())
+		Expect(result.NextOffsetID).To(Equal(200))
+	})
+})
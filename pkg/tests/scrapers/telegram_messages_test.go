@@ -0,0 +1,109 @@
+package scrapers_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/gotd/td/tg"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/Gzgod/masa-oracle/pkg/scrapers/normalized"
+	"github.com/Gzgod/masa-oracle/pkg/scrapers/telegram"
+)
+
+var _ = Describe("Telegram messages", func() {
+	date := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	walk := func(page tg.MessagesMessagesClass) []telegram.Message {
+		messages, err := telegram.WalkHistory(context.Background(), &tg.InputPeerChannel{ChannelID: 1}, telegram.HistoryOptions{Limit: 10},
+			func(ctx context.Context, request *tg.MessagesGetHistoryRequest) (tg.MessagesMessagesClass, error) {
+				if request.OffsetID != 0 {
+					return &tg.MessagesChannelMessages{}, nil
+				}
+				return page, nil
+			})
+		Expect(err).NotTo(HaveOccurred())
+		return messages
+	}
+
+	It("describes supergroup messages, forwards and service messages", func() {
+		forwarded := &tg.Message{ID: 3, PeerID: &tg.PeerChannel{ChannelID: 1}, FromID: &tg.PeerUser{UserID: 7}, Date: int(date.Unix()), Message: "look"}
+		forwarded.SetFwdFrom(tg.MessageFwdHeader{FromID: &tg.PeerChannel{ChannelID: 2}, Date: int(date.Add(-time.Hour).Unix()), ChannelPost: 42})
+		forwarded.SetReplyTo(&tg.MessageReplyHeader{ReplyToMsgID: 2, ReplyToTopID: 1})
+		joined := &tg.MessageService{ID: 2, PeerID: &tg.PeerChannel{ChannelID: 1}, FromID: &tg.PeerUser{UserID: 7}, Date: int(date.Unix()), Action: &tg.MessageActionChatJoinedByLink{}}
+
+		messages := walk(&tg.MessagesChannelMessages{
+			Messages: []tg.MessageClass{forwarded, joined, &tg.MessageEmpty{ID: 1}},
+			Chats: []tg.ChatClass{
+				&tg.Channel{ID: 1, Title: "Masa Chat", Username: "masachat", Megagroup: true},
+				&tg.Channel{ID: 2, Title: "Masa News", Username: "masanews", Broadcast: true},
+			},
+			Users: []tg.UserClass{&tg.User{ID: 7, Username: "naut", FirstName: "Masa", LastName: "Naut"}},
+		})
+		Expect(messages).To(HaveLen(2))
+
+		message := messages[0]
+		Expect(message.Chat).To(Equal(&telegram.Peer{ID: 1, Type: telegram.PeerSupergroup, Username: "masachat", Name: "Masa Chat"}))
+		Expect(message.From).To(Equal(&telegram.Peer{ID: 7, Type: telegram.PeerUser, Username: "naut", Name: "Masa Naut"}))
+		Expect(message.ReplyToID).To(Equal(2))
+		Expect(message.ThreadID).To(Equal(1))
+		Expect(message.Forward.From.Type).To(Equal(telegram.PeerChannel))
+		Expect(message.Forward.From.Username).To(Equal("masanews"))
+		Expect(message.Forward.ChannelPostID).To(Equal(42))
+		Expect(message.Forward.Date).To(Equal(date.Add(-time.Hour)))
+
+		Expect(messages[1].Action).To(Equal("chatJoinedByLink"))
+		Expect(telegram.MessagesToPosts("masachat", messages)).To(HaveLen(1))
+	})
+
+	It("describes basic group messages", func() {
+		messages := walk(&tg.MessagesMessages{
+			Messages: []tg.MessageClass{&tg.Message{ID: 5, PeerID: &tg.PeerChat{ChatID: 9}, FromID: &tg.PeerUser{UserID: 7}, Date: int(date.Unix())}},
+			Chats:    []tg.ChatClass{&tg.Chat{ID: 9, Title: "Friends"}},
+		})
+		Expect(messages).To(HaveLen(1))
+		Expect(messages[0].Chat).To(Equal(&telegram.Peer{ID: 9, Type: telegram.PeerGroup, Name: "Friends"}))
+
+		post := telegram.MessageToPost("9", messages[0])
+		Expect(post.URL).To(BeEmpty())
+		Expect(post.Author.ID).To(Equal("7"))
+	})
+
+	It("keeps media and document metadata", func() {
+		video := &tg.Message{ID: 6, PeerID: &tg.PeerChannel{ChannelID: 1}, Date: int(date.Unix()), Media: &tg.MessageMediaDocument{Document: &tg.Document{
+			ID:       100,
+			MimeType: "video/mp4",
+			Size:     2048,
+			Attributes: []tg.DocumentAttributeClass{
+				&tg.DocumentAttributeVideo{W: 1280, H: 720, Duration: 12.5},
+				&tg.DocumentAttributeFilename{FileName: "clip.mp4"},
+			},
+		}}}
+		voice := &tg.Message{ID: 5, PeerID: &tg.PeerChannel{ChannelID: 1}, Date: int(date.Unix()), Media: &tg.MessageMediaDocument{Document: &tg.Document{
+			MimeType:   "audio/ogg",
+			Attributes: []tg.DocumentAttributeClass{&tg.DocumentAttributeAudio{Voice: true, Duration: 3}},
+		}}}
+		photo := &tg.Message{ID: 4, PeerID: &tg.PeerChannel{ChannelID: 1}, Date: int(date.Unix()), Media: &tg.MessageMediaPhoto{Photo: &tg.Photo{
+			ID:    200,
+			Sizes: []tg.PhotoSizeClass{&tg.PhotoSize{Type: "s", W: 90, H: 90, Size: 1000}, &tg.PhotoSize{Type: "y", W: 1280, H: 960, Size: 90000}},
+		}}}
+		page := &tg.Message{ID: 3, PeerID: &tg.PeerChannel{ChannelID: 1}, Date: int(date.Unix()), Media: &tg.MessageMediaWebPage{Webpage: &tg.WebPage{URL: "https://masa.ai", Title: "Masa"}}}
+
+		messages := walk(&tg.MessagesChannelMessages{Messages: []tg.MessageClass{video, voice, photo, page}})
+		Expect(messages).To(HaveLen(4))
+		Expect(messages[0].Media).To(Equal(&telegram.Media{Type: telegram.MediaVideo, ID: 100, MimeType: "video/mp4", FileName: "clip.mp4", Size: 2048, Width: 1280, Height: 720, Duration: 12.5}))
+		Expect(messages[1].Media).To(Equal(&telegram.Media{Type: telegram.MediaVoice, MimeType: "audio/ogg", Duration: 3}))
+		Expect(messages[2].Media).To(Equal(&telegram.Media{Type: telegram.MediaPhoto, ID: 200, Width: 1280, Height: 960, Size: 90000}))
+		Expect(messages[3].Media).To(Equal(&telegram.Media{Type: telegram.MediaWebPage, URL: "https://masa.ai", Title: "Masa"}))
+
+		posts := telegram.MessagesToPosts("masa", messages)
+		Expect(posts[1].Media).To(ConsistOf(normalized.Media{Type: normalized.MediaAudio, MimeType: "audio/ogg"}))
+		Expect(posts[3].Media).To(BeEmpty())
+	})
+
+	It("links comments to their channel post", func() {
+		post := telegram.MessageToPost("masa", telegram.Message{ID: 12, PostID: 3, Date: date})
+		Expect(post.URL).To(Equal("https://t.me/masa/3?comment=12"))
+	})
+})
@@ -0,0 +1,154 @@
+package scrapers_test
+
+import (
+	"context"
+	"path/filepath"
+	"time"
+
+	"github.com/gotd/td/tgerr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/Gzgod/masa-oracle/pkg/scrapers/telegram"
+)
+
+var _ = Describe("Telegram session manager", func() {
+	var (
+		dir     string
+		manager *telegram.SessionManager
+	)
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+		manager = telegram.NewSessionManager(dir, []string{"default", "alice", "bob"})
+	})
+
+	nextNames := func(n int) []string {
+		var names []string
+		for i := 0; i < n; i++ {
+			session := manager.GetNextSession()
+			if session == nil {
+				names = append(names, "")
+				continue
+			}
+			names = append(names, session.Name)
+		}
+		return names
+	}
+
+	It("rotates across the sessions", func() {
+		Expect(nextNames(4)).To(Equal([]string{"default", "alice", "bob", "default"}))
+		Expect(manager.GetSession("alice").Usage.Requests).To(Equal(int64(1)))
+	})
+
+	It("skips flood-waited sessions until the wait is over", func() {
+		manager.MarkFloodWait(manager.GetSession("alice"), time.Hour)
+		manager.MarkFloodWait(manager.GetSession("bob"), -time.Second)
+		Expect(nextNames(3)).To(Equal([]string{"default", "bob", "default"}))
+
+		sessions := manager.GetSessions()
+		Expect(sessions[1].Status).To(Equal(telegram.SessionStatusFloodWait))
+		Expect(sessions[1].FloodWaitUntil).NotTo(BeNil())
+		Expect(sessions[1].Usage.FloodWaits).To(Equal(int64(1)))
+		Expect(sessions[2].Status).To(Equal(telegram.SessionStatusActive))
+	})
+
+	It("skips disabled and unauthorized sessions", func() {
+		Expect(manager.SetSessionDisabled("default", true)).To(Succeed())
+		manager.MarkUnauthorized(manager.GetSession("bob"))
+		Expect(nextNames(2)).To(Equal([]string{"alice", "alice"}))
+
+		manager.MarkAuthenticated(manager.GetSession("bob"))
+		Expect(nextNames(2)).To(Equal([]string{"bob", "alice"}))
+
+		Expect(manager.SetSessionDisabled("carol", true)).To(MatchError(telegram.ErrSessionNotFound))
+	})
+
+	It("returns no session when every session is waiting", func() {
+		for _, name := range []string{"default", "alice", "bob"} {
+			manager.MarkFloodWait(manager.GetSession(name), time.Minute)
+		}
+		Expect(manager.GetNextSession()).To(BeNil())
+	})
+
+	It("adds sessions with valid names only", func() {
+		session, err := manager.AddSession("carol")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(manager.GetSession("carol")).To(BeIdenticalTo(session))
+
+		again, err := manager.AddSession("carol")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(again).To(BeIdenticalTo(session))
+
+		_, err = manager.AddSession("../escape")
+		Expect(err).To(MatchError(telegram.ErrInvalidSessionName))
+		Expect(manager.GetSessions()).To(HaveLen(4))
+	})
+
+	It("prefers the default session for updates", func() {
+		Expect(manager.DefaultSession().Name).To(Equal("default"))
+		other := telegram.NewSessionManager(dir, []string{"alice", "bob"})
+		Expect(other.DefaultSession().Name).To(Equal("alice"))
+	})
+
+	Describe("rotating a history walk", func() {
+		var offsets []int
+
+		// walk returns two messages below the offset, and a flood wait for the first session.
+		walk := func(ctx context.Context, opts telegram.HistoryOptions) (*telegram.Session, []telegram.Message, error) {
+			session := manager.GetNextSession()
+			if session == nil {
+				return nil, nil, telegram.ErrNoSessionAvailable
+			}
+			offsets = append(offsets, opts.OffsetID)
+			start := opts.OffsetID
+			if start == 0 {
+				start = 100
+			}
+			page := []telegram.Message{{ID: start - 1}, {ID: start - 2}}
+			if session.Name == "default" {
+				return session, page, tgerr.New(420, "FLOOD_WAIT_300")
+			}
+			return session, page, nil
+		}
+
+		BeforeEach(func() {
+			offsets = nil
+		})
+
+		It("continues a channel walk below the last message with the next session", func() {
+			messages, err := telegram.RotateSessions(context.Background(), manager, telegram.Target{Username: "channel"}, telegram.HistoryOptions{}, walk)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(messages).To(HaveLen(4))
+			Expect(offsets).To(Equal([]int{0, 98}))
+			Expect(manager.GetSessions()[0].Status).To(Equal(telegram.SessionStatusFloodWait))
+		})
+
+		It("does not carry a basic group walk over to another session", func() {
+			messages, err := telegram.RotateSessions(context.Background(), manager, telegram.Target{ChatID: 42}, telegram.HistoryOptions{}, walk)
+			_, floodWait := tgerr.AsFloodWait(err)
+			Expect(floodWait).To(BeTrue())
+			Expect(messages).To(HaveLen(2))
+			Expect(offsets).To(Equal([]int{0}))
+			Expect(manager.GetSessions()[0].Status).To(Equal(telegram.SessionStatusFloodWait))
+
+			history := telegram.NewChannelHistory(messages, telegram.HistoryOptions{}, err)
+			Expect(history.StopReason).To(Equal(telegram.StopError))
+			Expect(history.NextOffsetID).To(Equal(98))
+		})
+	})
+
+	It("keeps flood waits and disabled sessions across restarts", func() {
+		statePath := filepath.Join(dir, "sessions-state.json")
+		Expect(manager.LoadState(statePath)).To(Succeed())
+		manager.MarkFloodWait(manager.GetSession("alice"), time.Hour)
+		Expect(manager.SetSessionDisabled("bob", true)).To(Succeed())
+
+		restarted := telegram.NewSessionManager(dir, []string{"default", "alice", "bob"})
+		Expect(restarted.LoadState(statePath)).To(Succeed())
+		sessions := restarted.GetSessions()
+		Expect(sessions[1].Status).To(Equal(telegram.SessionStatusFloodWait))
+		Expect(sessions[2].Status).To(Equal(telegram.SessionStatusDisabled))
+		Expect(restarted.GetNextSession().Name).To(Equal("default"))
+	})
+})
@@ -0,0 +1,86 @@
+package scrapers_test
+
+import (
+	"context"
+	"path/filepath"
+
+	"github.com/gotd/td/telegram/updates"
+	"github.com/gotd/td/tg"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/Gzgod/masa-oracle/pkg/scrapers/telegram"
+)
+
+var _ = Describe("Telegram updates", func() {
+	newMessage := func(id int, peer tg.PeerClass, text string) tg.UpdatesClass {
+		return &tg.Updates{
+			Updates: []tg.UpdateClass{&tg.UpdateNewMessage{Message: &tg.Message{ID: id, PeerID: peer, Message: text}}},
+			Chats:   []tg.ChatClass{&tg.Chat{ID: 9, Title: "Friends"}, &tg.Channel{ID: 1, Title: "Masa News", Username: "masanews", Broadcast: true}},
+		}
+	}
+	newChannelMessage := func(id int, text string) tg.UpdatesClass {
+		return &tg.Updates{
+			Updates: []tg.UpdateClass{&tg.UpdateNewChannelMessage{Message: &tg.Message{ID: id, PeerID: &tg.PeerChannel{ChannelID: 1}, Message: text}}},
+			Chats:   []tg.ChatClass{&tg.Channel{ID: 1, Title: "Masa News", Username: "masanews", Broadcast: true}},
+		}
+	}
+
+	It("receives the messages of the selected groups once", func() {
+		var received []telegram.Message
+		subscription, err := telegram.NewUpdates(telegram.UpdatesConfig{Groups: []int64{9}}, func(message telegram.Message) {
+			received = append(received, message)
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		ctx := context.Background()
+		Expect(subscription.Handle(ctx, newMessage(1, &tg.PeerChat{ChatID: 9}, "hello"))).To(Succeed())
+		Expect(subscription.Handle(ctx, newMessage(1, &tg.PeerChat{ChatID: 9}, "hello"))).To(Succeed())
+		Expect(subscription.Handle(ctx, newMessage(2, &tg.PeerChat{ChatID: 8}, "other group"))).To(Succeed())
+		Expect(subscription.Handle(ctx, newChannelMessage(3, "not selected"))).To(Succeed())
+
+		Expect(received).To(HaveLen(1))
+		Expect(received[0].Text).To(Equal("hello"))
+		Expect(received[0].Chat).To(Equal(&telegram.Peer{ID: 9, Type: telegram.PeerGroup, Name: "Friends"}))
+	})
+
+	It("receives every channel and group but no private chats when nothing is selected", func() {
+		var received []telegram.Message
+		subscription, err := telegram.NewUpdates(telegram.UpdatesConfig{}, func(message telegram.Message) {
+			received = append(received, message)
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		ctx := context.Background()
+		Expect(subscription.Handle(ctx, newChannelMessage(3, "news"))).To(Succeed())
+		Expect(subscription.Handle(ctx, newMessage(4, &tg.PeerUser{UserID: 7}, "private"))).To(Succeed())
+
+		Expect(received).To(HaveLen(1))
+		Expect(received[0].Chat.Username).To(Equal("masanews"))
+	})
+
+	It("keeps the update state across restarts", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "telegram", "updates-state.json")
+		ctx := context.Background()
+
+		state, err := telegram.NewUpdateStateFile(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(state.SetState(ctx, 7, updates.State{Pts: 10, Qts: 1, Date: 100, Seq: 2})).To(Succeed())
+		Expect(state.SetPts(ctx, 7, 11)).To(Succeed())
+		Expect(state.SetChannelPts(ctx, 7, 1, 50)).To(Succeed())
+		Expect(state.SetChannelAccessHash(ctx, 7, 1, 1234)).To(Succeed())
+
+		restored, err := telegram.NewUpdateStateFile(path)
+		Expect(err).NotTo(HaveOccurred())
+		s, found, err := restored.GetState(ctx, 7)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(found).To(BeTrue())
+		Expect(s).To(Equal(updates.State{Pts: 11, Qts: 1, Date: 100, Seq: 2}))
+		pts, found, _ := restored.GetChannelPts(ctx, 7, 1)
+		Expect(found).To(BeTrue())
+		Expect(pts).To(Equal(50))
+		hash, found, _ := restored.GetChannelAccessHash(ctx, 7, 1)
+		Expect(found).To(BeTrue())
+		Expect(hash).To(Equal(int64(1234)))
+	})
+})
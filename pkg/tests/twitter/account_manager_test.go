@@ -0,0 +1,166 @@
+package scrapers_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/Gzgod/masa-oracle/pkg/scrapers/twitter"
+)
+
+var _ = Describe("Twitter Account Manager", func() {
+	var (
+		statePath string
+		accounts  []*twitter.TwitterAccount
+		manager   *twitter.TwitterAccountManager
+	)
+
+	newAccounts := func() []*twitter.TwitterAccount {
+		return []*twitter.TwitterAccount{
+			{Username: "alice", Password: "a"},
+			{Username: "bob", Password: "b"},
+		}
+	}
+
+	statusOf := func(m *twitter.TwitterAccountManager, username string) twitter.AccountInfo {
+		for _, info := range m.GetAccounts() {
+			if info.Username == username {
+				return info
+			}
+		}
+		Fail("account not found: " + username)
+		return twitter.AccountInfo{}
+	}
+
+	BeforeEach(func() {
+		statePath = filepath.Join(GinkgoT().TempDir(), "twitter_accounts_state.json")
+		accounts = newAccounts()
+		manager = twitter.NewTwitterAccountManager(accounts)
+		Expect(manager.LoadState(statePath)).To(Succeed())
+	})
+
+	It("reports the status of each account", func() {
+		manager.MarkAccountRateLimited(accounts[0], twitter.EndpointSearch, time.Time{})
+		manager.MarkAccountAuthFailed(accounts[1], errors.New("login failed"))
+
+		Expect(statusOf(manager, "alice").Status).To(Equal(twitter.AccountStatusRateLimited))
+		Expect(statusOf(manager, "alice").RateLimitedUntil).NotTo(BeNil())
+		Expect(statusOf(manager, "bob").Status).To(Equal(twitter.AccountStatusAuthFailing))
+
+		manager.MarkAccountAuthenticated(accounts[1])
+		Expect(statusOf(manager, "bob").Status).To(Equal(twitter.AccountStatusActive))
+	})
+
+	It("skips disabled accounts", func() {
+		Expect(manager.SetAccountDisabled("alice", true)).To(Succeed())
+		Expect(statusOf(manager, "alice").Status).To(Equal(twitter.AccountStatusDisabled))
+
+		for i := 0; i < 3; i++ {
+			Expect(manager.GetNextAccount(twitter.EndpointSearch).Username).To(Equal("bob"))
+		}
+
+		Expect(manager.SetAccountDisabled("carol", true)).To(MatchError(twitter.ErrAccountNotFound))
+	})
+
+	It("counts usage per account", func() {
+		manager.RecordRequest(accounts[0])
+		manager.RecordRequest(accounts[0])
+		manager.RecordError(accounts[0])
+
+		usage := statusOf(manager, "alice").Usage
+		Expect(usage.Requests).To(Equal(int64(2)))
+		Expect(usage.Errors).To(Equal(int64(1)))
+		Expect(usage.LastUsed).To(BeTemporally("~", time.Now(), time.Second))
+	})
+
+	It("writes usage counters with the next state change", func() {
+		manager.RecordRequest(accounts[0])
+		manager.RecordError(accounts[0])
+		Expect(statePath).NotTo(BeAnExistingFile())
+
+		manager.MarkAccountRateLimited(accounts[1], twitter.EndpointSearch, time.Time{})
+		data, err := os.ReadFile(statePath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(ContainSubstring(`"requests":1`))
+
+		restarted := twitter.NewTwitterAccountManager(newAccounts())
+		Expect(restarted.LoadState(statePath)).To(Succeed())
+		Expect(statusOf(restarted, "alice").Usage.Requests).To(Equal(int64(1)))
+		Expect(statusOf(restarted, "alice").Usage.Errors).To(Equal(int64(1)))
+	})
+
+	It("restores rate limits and disabled accounts after a restart", func() {
+		manager.MarkAccountRateLimited(accounts[0], twitter.EndpointSearch, time.Time{})
+		Expect(manager.SetAccountDisabled("bob", true)).To(Succeed())
+
+		restarted := twitter.NewTwitterAccountManager(newAccounts())
+		Expect(restarted.LoadState(statePath)).To(Succeed())
+
+		Expect(statusOf(restarted, "alice").Status).To(Equal(twitter.AccountStatusRateLimited))
+		Expect(statusOf(restarted, "alice").Usage.RateLimits).To(Equal(int64(1)))
+		Expect(statusOf(restarted, "bob").Status).To(Equal(twitter.AccountStatusDisabled))
+		Expect(restarted.GetNextAccount(twitter.EndpointSearch)).To(BeNil())
+	})
+
+	Describe("adaptive rate limiting", func() {
+		It("limits each endpoint type independently", func() {
+			manager.MarkAccountRateLimited(accounts[0], twitter.EndpointSearch, time.Time{})
+			manager.MarkAccountRateLimited(accounts[1], twitter.EndpointSearch, time.Time{})
+
+			Expect(manager.GetNextAccount(twitter.EndpointSearch)).To(BeNil())
+			Expect(manager.GetNextAccount(twitter.EndpointProfile)).NotTo(BeNil())
+		})
+
+		It("spreads requests across accounts", func() {
+			used := map[string]int{}
+			for i := 0; i < 10; i++ {
+				used[manager.GetNextAccount(twitter.EndpointSearch).Username]++
+			}
+			Expect(used["alice"]).To(Equal(5))
+			Expect(used["bob"]).To(Equal(5))
+		})
+
+		It("stops handing out an account once its budget is spent", func() {
+			Expect(manager.SetAccountDisabled("bob", true)).To(Succeed())
+			capacity := twitter.DefaultBucketConfigs[twitter.EndpointFollowers].Capacity
+			for i := 0; i < capacity; i++ {
+				Expect(manager.GetNextAccount(twitter.EndpointFollowers)).NotTo(BeNil())
+			}
+			Expect(manager.GetNextAccount(twitter.EndpointFollowers)).To(BeNil())
+			Expect(manager.RemainingBudget()[twitter.EndpointFollowers]).To(Equal(0))
+		})
+
+		It("honours the reset time reported by Twitter", func() {
+			resetAt := time.Now().Add(3 * time.Minute).Truncate(time.Second)
+			manager.MarkAccountRateLimited(accounts[0], twitter.EndpointSearch, resetAt)
+
+			budget := statusOf(manager, "alice").Budget[twitter.EndpointSearch]
+			Expect(budget.Remaining).To(Equal(0))
+			Expect(budget.LimitedUntil).NotTo(BeNil())
+			Expect(*budget.LimitedUntil).To(BeTemporally("==", resetAt))
+		})
+
+		It("shrinks the budget on rate limits and grows it back on success", func() {
+			capacity := twitter.DefaultBucketConfigs[twitter.EndpointSearch].Capacity
+			manager.MarkAccountRateLimited(accounts[0], twitter.EndpointSearch, time.Time{})
+			Expect(statusOf(manager, "alice").Budget[twitter.EndpointSearch].Capacity).To(Equal(capacity / 2))
+
+			manager.MarkAccountSucceeded(accounts[0], twitter.EndpointSearch)
+			Expect(statusOf(manager, "alice").Budget[twitter.EndpointSearch].Capacity).To(Equal(capacity/2 + 1))
+		})
+
+		It("backs off longer on repeated rate limits without a reset hint", func() {
+			manager.MarkAccountRateLimited(accounts[0], twitter.EndpointSearch, time.Time{})
+			first := *statusOf(manager, "alice").RateLimitedUntil
+			manager.MarkAccountRateLimited(accounts[0], twitter.EndpointSearch, time.Time{})
+			second := *statusOf(manager, "alice").RateLimitedUntil
+
+			Expect(first).To(BeTemporally("~", time.Now().Add(time.Minute), time.Second))
+			Expect(second).To(BeTemporally("~", time.Now().Add(2*time.Minute), time.Second))
+		})
+	})
+})
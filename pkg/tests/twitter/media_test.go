@@ -0,0 +1,100 @@
+package scrapers_test
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	twitterscraper "github.com/masa-finance/masa-twitter-scraper"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/Gzgod/masa-oracle/pkg/blobstore"
+	"github.com/Gzgod/masa-oracle/pkg/masacrypto"
+	"github.com/Gzgod/masa-oracle/pkg/scrapers/twitter"
+)
+
+var _ = Describe("Twitter media download", func() {
+	var (
+		server *httptest.Server
+		store  *blobstore.Store
+	)
+
+	image := []byte("\x89PNG\r\n\x1a\nimage")
+
+	BeforeEach(func() {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/photo.png", func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write(image)
+		})
+		mux.HandleFunc("/missing.mp4", http.NotFound)
+		// /large/<n>/<c> serves n MiB of the byte c.
+		mux.HandleFunc("/large/", func(w http.ResponseWriter, r *http.Request) {
+			var size int
+			var fill byte
+			if _, err := fmt.Sscanf(r.URL.Path, "/large/%d/%c", &size, &fill); err != nil {
+				http.NotFound(w, r)
+				return
+			}
+			_, _ = w.Write(bytes.Repeat([]byte{fill}, size*1024*1024))
+		})
+		server = httptest.NewServer(mux)
+
+		var err error
+		store, err = blobstore.New(GinkgoT().TempDir())
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("stores media content-addressed and records the CIDs", func() {
+		results := []*twitter.TweetResult{{Tweet: &twitterscraper.Tweet{
+			ID:     "1",
+			Photos: []twitterscraper.Photo{{URL: server.URL + "/photo.png"}},
+			Videos: []twitterscraper.Video{{URL: server.URL + "/missing.mp4"}},
+		}}}
+
+		blobs := twitter.DownloadTweetMedia(store, results)
+
+		expected, err := masacrypto.ComputeSha256CidFromBytes(image)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(blobs).To(HaveKeyWithValue(expected, image))
+		Expect(store.Has(expected)).To(BeTrue())
+
+		media := results[0].Media
+		Expect(media).To(HaveLen(2))
+		Expect(media[0].CID).To(Equal(expected))
+		Expect(media[0].MimeType).To(Equal("image/png"))
+		Expect(media[1].CID).To(BeEmpty())
+		Expect(media[1].Error).NotTo(BeEmpty())
+
+		posts := twitter.TweetsToPosts(results)
+		Expect(posts[0].Media[0].CID).To(Equal(expected))
+	})
+
+	It("never downloads more than the limit for one request", func() {
+		sizes := []int{20, 20, 20, 20, 19, 20, 1}
+		tweet := &twitterscraper.Tweet{ID: "1"}
+		for i, size := range sizes {
+			tweet.Videos = append(tweet.Videos, twitterscraper.Video{URL: fmt.Sprintf("%s/large/%d/%c", server.URL, size, 'a'+i)})
+		}
+		results := []*twitter.TweetResult{{Tweet: tweet}}
+
+		blobs := twitter.DownloadTweetMedia(store, results)
+
+		var total int
+		for _, data := range blobs {
+			total += len(data)
+		}
+		Expect(total).To(Equal(twitter.MaxMediaPerRequest))
+
+		media := results[0].Media
+		Expect(media).To(HaveLen(len(sizes)))
+		Expect(media[5].CID).To(BeEmpty())
+		Expect(media[5].Error).To(ContainSubstring("media limit"))
+		Expect(media[6].CID).NotTo(BeEmpty())
+	})
+})
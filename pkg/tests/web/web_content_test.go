@@ -0,0 +1,144 @@
+package scrapers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/Gzgod/masa-oracle/pkg/scrapers/web"
+)
+
+var _ = Describe("Web main content extraction", func() {
+	extract := func(format string) web.Document {
+		page, err := os.Open("testdata/article.html")
+		Expect(err).NotTo(HaveOccurred())
+		defer page.Close()
+		doc, err := web.ExtractContent(page, "https://example.com/guides/node/", format)
+		Expect(err).NotTo(HaveOccurred())
+		return doc
+	}
+
+	It("returns the page metadata", func() {
+		doc := extract(web.ContentMarkdown)
+		Expect(doc.URL).To(Equal("https://example.com/guides/node/"))
+		Expect(doc.CanonicalURL).To(Equal("https://example.com/guides/running-a-node"))
+		Expect(doc.Title).To(Equal("Running a Masa node"))
+		Expect(doc.Description).To(Equal("How to run and stake a node."))
+		Expect(doc.Language).To(Equal("en-GB"))
+		Expect(doc.Format).To(Equal(web.ContentMarkdown))
+	})
+
+	It("strips navigation, sidebars, banners and footers", func() {
+		content := extract(web.ContentMarkdown).Content
+		for _, boilerplate := range []string{"Guides", "Related guides", "cookies", "Share", "Copyright", "analytics", "margin"} {
+			Expect(content).NotTo(ContainSubstring(boilerplate))
+		}
+		Expect(content).To(HavePrefix("# Running a node\n\nBy the Masa team\n\n"))
+	})
+
+	It("renders headings, lists, tables, code and links as Markdown", func() {
+		content := extract(web.ContentMarkdown).Content
+		Expect(content).To(ContainSubstring("Read the [overview](https://example.com/guides/docs/overview) first, it explains **how work is shared** between *workers*."))
+		Expect(content).To(ContainSubstring("## Requirements\n\n- Go 1.22 or later\n- A wallet with tokens\n  - Testnet tokens are enough\n\n### Ports"))
+		Expect(content).To(ContainSubstring("| Port | Use |\n| --- | --- |\n| 4001 | Peers \\| bootstrap |\n| 8080 | API |"))
+		Expect(content).To(ContainSubstring("1. Clone the repository\n2. Build the binary"))
+		Expect(content).To(ContainSubstring("```go\nfunc main() {\n\tnode.Start()\n}\n```"))
+		Expect(content).To(ContainSubstring("> Keep your keys safe."))
+		Expect(content).To(HaveSuffix("![A node](https://example.com/img/node.png)"))
+	})
+
+	It("renders plain text", func() {
+		content := extract(web.ContentText).Content
+		Expect(content).To(HavePrefix("Running a node\n\nBy the Masa team\n\nA node scrapes data for the network. Read the overview first"))
+		Expect(content).To(ContainSubstring("Port\tUse\n4001\tPeers | bootstrap"))
+		Expect(content).To(ContainSubstring("func main() {\n\tnode.Start()\n}"))
+		Expect(content).NotTo(ContainSubstring("**"))
+		Expect(content).NotTo(ContainSubstring("https://"))
+	})
+
+	It("finds the main content of pages without main or article elements", func() {
+		page := `<html><body>
+			<div class="links"><a href="/a">First link</a> <a href="/b">Second link</a> <a href="/c">Third link</a></div>
+			<div class="content"><h2>Release notes</h2>
+				<p>This release makes the scraper faster and uses less memory on large pages.</p>
+				<p>Workers now report how many pages they scraped and why they stopped.</p>
+			</div>
+			<div class="links"><p><a href="/d">A paragraph made of a single long link only</a></p></div>
+		</body></html>`
+		doc, err := web.ExtractContent(strings.NewReader(page), "https://example.com/", web.ContentMarkdown)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(doc.Title).To(BeEmpty())
+		Expect(doc.Content).To(Equal("## Release notes\n\n" +
+			"This release makes the scraper faster and uses less memory on large pages.\n\n" +
+			"Workers now report how many pages they scraped and why they stopped."))
+	})
+
+	It("rejects unknown formats", func() {
+		_, err := web.ExtractContent(strings.NewReader("<p>text</p>"), "https://example.com/", "html")
+		Expect(err).To(MatchError(ContainSubstring("invalid content format")))
+	})
+
+	Context("when crawling", func() {
+		var (
+			server   *httptest.Server
+			previous web.Policy
+		)
+
+		BeforeEach(func() {
+			article, err := os.ReadFile("testdata/article.html")
+			Expect(err).NotTo(HaveOccurred())
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Language", "en, fr")
+				if r.URL.Path == "/plain" {
+					_, _ = w.Write([]byte("<html><body><main><h1>Plain</h1><p>No language attribute.</p></main></body></html>"))
+					return
+				}
+				_, _ = w.Write(article)
+			}))
+			previous = web.DefaultPolicy()
+			web.SetDefaultPolicy(web.Policy{AllowPrivateNetworks: true})
+		})
+
+		AfterEach(func() {
+			web.SetDefaultPolicy(previous)
+			server.Close()
+		})
+
+		It("adds a document per page to the result", func() {
+			data, err := web.ScrapeWebDataWithOptions([]string{server.URL + "/guides/node", server.URL + "/plain"}, web.CrawlOptions{Depth: 1, Content: web.ContentText})
+			Expect(err).NotTo(HaveOccurred())
+			var result web.CollectedData
+			Expect(json.Unmarshal(data, &result)).To(Succeed())
+			Expect(result.Documents).To(HaveLen(2))
+
+			documents := map[string]web.Document{}
+			for _, doc := range result.Documents {
+				documents[doc.URL] = doc
+			}
+			Expect(documents[server.URL+"/guides/node"].CanonicalURL).To(Equal(server.URL + "/guides/running-a-node"))
+			Expect(documents[server.URL+"/guides/node"].Language).To(Equal("en-GB"))
+			Expect(documents[server.URL+"/plain"].Language).To(Equal("en"))
+			Expect(documents[server.URL+"/plain"].Content).To(Equal("Plain\n\nNo language attribute."))
+
+			posts := web.CollectedDataToPosts(server.URL+"/guides/node", result)
+			Expect(posts).To(HaveLen(2))
+			Expect([]string{posts[0].URL, posts[1].URL}).To(ContainElement(server.URL + "/guides/running-a-node"))
+		})
+
+		It("leaves the documents out unless asked", func() {
+			data, err := web.ScrapeWebDataWithOptions([]string{server.URL}, web.CrawlOptions{Depth: 1})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(data)).NotTo(ContainSubstring("documents"))
+		})
+
+		It("rejects unknown formats", func() {
+			_, err := web.ScrapeWebDataWithOptions([]string{server.URL}, web.CrawlOptions{Content: "pdf"})
+			Expect(err).To(MatchError(ContainSubstring("invalid content format")))
+		})
+	})
+})
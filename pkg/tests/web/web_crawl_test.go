@@ -0,0 +1,130 @@
+package scrapers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/Gzgod/masa-oracle/pkg/scrapers/web"
+)
+
+var _ = Describe("Web crawl budgets", func() {
+	var (
+		server   *httptest.Server
+		previous web.Policy
+		mutex    sync.Mutex
+		visited  []string
+	)
+
+	links := map[string][]string{
+		"/":         {"/a", "/b", "/docs/", "/slow"},
+		"/a":        {"/a1", "/a2"},
+		"/b":        {"/b1"},
+		"/docs/":    {"/docs/intro", "/docs/api?page=2", "/about"},
+		"/docs/api": {"/docs/intro"},
+	}
+
+	BeforeEach(func() {
+		mutex.Lock()
+		visited = nil
+		mutex.Unlock()
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mutex.Lock()
+			visited = append(visited, r.URL.Path)
+			mutex.Unlock()
+			if r.URL.Path == "/slow" {
+				select {
+				case <-r.Context().Done():
+				case <-time.After(5 * time.Second):
+				}
+				return
+			}
+			var body strings.Builder
+			fmt.Fprintf(&body, "<html><body><h1>%s</h1><p>%s</p>", r.URL.Path, strings.Repeat("text ", 100))
+			for _, link := range links[r.URL.Path] {
+				fmt.Fprintf(&body, `<a href="%s">%s</a>`, link, link)
+			}
+			// The same site under another host name
+			fmt.Fprintf(&body, `<a href="%s/elsewhere">Elsewhere</a>`, strings.Replace(server.URL, "127.0.0.1", "localhost", 1))
+			body.WriteString("</body></html>")
+			_, _ = w.Write([]byte(body.String()))
+		}))
+		previous = web.DefaultPolicy()
+		web.SetDefaultPolicy(web.Policy{AllowPrivateNetworks: true})
+	})
+
+	AfterEach(func() {
+		web.SetDefaultPolicy(previous)
+		server.Close()
+	})
+
+	scrape := func(start string, opts web.CrawlOptions) web.CollectedData {
+		data, err := web.ScrapeWebDataWithOptions([]string{server.URL + start}, opts)
+		Expect(err).NotTo(HaveOccurred())
+		var result web.CollectedData
+		Expect(json.Unmarshal(data, &result)).To(Succeed())
+		return result
+	}
+
+	paths := func() []string {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return append([]string(nil), visited...)
+	}
+
+	It("ends when every page in scope was scraped", func() {
+		result := scrape("/docs/", web.CrawlOptions{Depth: 3, Scope: web.ScopeSamePrefix})
+		Expect(result.StopReason).To(Equal(web.StopEnd))
+		Expect(paths()).To(ConsistOf("/docs/", "/docs/intro", "/docs/api"))
+		Expect(result.PageCount).To(Equal(3))
+	})
+
+	It("stops after the maximum number of pages", func() {
+		result := scrape("/", web.CrawlOptions{Depth: 3, MaxPages: 3, Scope: web.ScopeSameDomain, Exclude: []string{"/slow"}})
+		Expect(result.StopReason).To(Equal(web.StopMaxPages))
+		Expect(result.PageCount).To(Equal(3))
+		Expect(paths()).To(HaveLen(3))
+	})
+
+	It("stops after the maximum number of bytes", func() {
+		result := scrape("/", web.CrawlOptions{Depth: 3, MaxBytes: 1200, Scope: web.ScopeSameDomain, Exclude: []string{"/slow"}})
+		Expect(result.StopReason).To(Equal(web.StopMaxBytes))
+		Expect(result.ByteCount).To(BeNumerically(">=", 1200))
+		Expect(len(paths())).To(BeNumerically("<", 9))
+	})
+
+	It("stops and cancels the requests in flight when the time budget runs out", func() {
+		started := time.Now()
+		result := scrape("/", web.CrawlOptions{Depth: 2, MaxDuration: 300 * time.Millisecond, Include: []string{"/slow$"}})
+		Expect(time.Since(started)).To(BeNumerically("<", 3*time.Second))
+		Expect(result.StopReason).To(Equal(web.StopTime))
+		Expect(result.Sections).NotTo(BeEmpty())
+	})
+
+	It("keeps to the host of the start URL", func() {
+		result := scrape("/b", web.CrawlOptions{Depth: 2, Scope: web.ScopeSameDomain})
+		Expect(result.StopReason).To(Equal(web.StopEnd))
+		Expect(result.Pages).To(ConsistOf(server.URL + "/b1"))
+		Expect(paths()).To(ConsistOf("/b", "/b1"))
+	})
+
+	It("follows the links that match the include and not the exclude patterns", func() {
+		scrape("/", web.CrawlOptions{Depth: 3, Include: []string{`/a\d?$`, `/docs/`}, Exclude: []string{`page=2`}})
+		Expect(paths()).To(ConsistOf("/", "/a", "/a1", "/a2", "/docs/", "/docs/intro"))
+	})
+
+	It("rejects invalid options", func() {
+		_, err := web.ScrapeWebDataWithOptions([]string{server.URL}, web.CrawlOptions{Scope: "everywhere"})
+		Expect(err).To(MatchError(ContainSubstring("invalid scope")))
+		_, err = web.ScrapeWebDataWithOptions([]string{server.URL}, web.CrawlOptions{Include: []string{"("}})
+		Expect(err).To(MatchError(ContainSubstring("invalid include pattern")))
+		Expect(paths()).To(BeEmpty())
+	})
+})
@@ -0,0 +1,115 @@
+package scrapers_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/Gzgod/masa-oracle/pkg/scrapers/web"
+)
+
+var _ = Describe("Web field extraction", func() {
+	fields := []web.Field{
+		{Name: "title", CSS: "h1.title"},
+		{Name: "updated", CSS: ".updated time", Attribute: "datetime"},
+		{Name: "tags", XPath: `//ul[@class="tags"]/li`, Multiple: true},
+		{Name: "tokenCount", XPath: `count(//tr[@class="token"])`},
+		{Name: "missing", CSS: ".missing"},
+		{Name: "tokens", CSS: "#prices tr.token", Multiple: true, Fields: []web.Field{
+			{Name: "symbol", XPath: "@data-symbol"},
+			{Name: "name", CSS: "a"},
+			{Name: "link", CSS: "a", Attribute: "href"},
+			{Name: "price", XPath: `td[@class="price"]`},
+			{Name: "change", CSS: ".change"},
+		}},
+	}
+
+	extract := func(fields []web.Field) map[string]any {
+		page, err := os.Open("testdata/prices.html")
+		Expect(err).NotTo(HaveOccurred())
+		defer page.Close()
+		data, err := web.ExtractFields(page, "https://example.com/prices", fields)
+		Expect(err).NotTo(HaveOccurred())
+		return data
+	}
+
+	It("returns the named fields of the page", func() {
+		data := extract(fields)
+		Expect(data).To(HaveKeyWithValue("title", "Token prices"))
+		Expect(data).To(HaveKeyWithValue("updated", "2024-06-01T12:00:00Z"))
+		Expect(data).To(HaveKeyWithValue("tags", []any{"defi", "ai"}))
+		Expect(data).To(HaveKeyWithValue("tokenCount", "2"))
+		Expect(data).To(HaveKeyWithValue("missing", BeNil()))
+	})
+
+	It("returns an object per element of a repeated group", func() {
+		Expect(extract(fields)["tokens"]).To(Equal([]any{
+			map[string]any{
+				"symbol": "MASA", "name": "Masa Network",
+				"link": "https://example.com/tokens/masa", "price": "$0.05", "change": "+2.1%",
+			},
+			map[string]any{
+				"symbol": "ETH", "name": "Ethereum",
+				"link": "https://example.com/tokens/eth", "price": "$3,500.12", "change": "-0.4%",
+			},
+		}))
+	})
+
+	It("returns the first match of a field that is not repeated", func() {
+		data := extract([]web.Field{
+			{Name: "firstPrice", CSS: ".price"},
+			{Name: "firstToken", XPath: `//tr[@class="token"]`, Fields: []web.Field{{Name: "name", XPath: ".//a"}}},
+			{Name: "noTokens", CSS: ".none", Multiple: true},
+		})
+		Expect(data).To(Equal(map[string]any{
+			"firstPrice": "$0.05",
+			"firstToken": map[string]any{"name": "Masa Network"},
+			"noTokens":   []any{},
+		}))
+	})
+
+	DescribeTable("rejects invalid fields",
+		func(fields []web.Field, message string) {
+			err := web.ValidateFields(fields)
+			Expect(errors.Is(err, web.ErrInvalidField)).To(BeTrue())
+			Expect(err).To(MatchError(ContainSubstring(message)))
+		},
+		Entry("without a name", []web.Field{{CSS: "h1"}}, "has no name"),
+		Entry("with a duplicate name", []web.Field{{Name: "a", CSS: "h1"}, {Name: "a", CSS: "h2"}}, `"a" is defined twice`),
+		Entry("without a selector", []web.Field{{Name: "a"}}, "needs either a css or an xpath selector"),
+		Entry("with both selectors", []web.Field{{Name: "a", CSS: "h1", XPath: "//h1"}}, "needs either a css or an xpath selector"),
+		Entry("with an invalid css selector", []web.Field{{Name: "a", CSS: "h1["}}, `css selector of "a"`),
+		Entry("with an invalid xpath", []web.Field{{Name: "a", XPath: "//h1["}}, `xpath of "a"`),
+		Entry("with an invalid nested field", []web.Field{{Name: "a", CSS: "tr", Fields: []web.Field{{Name: "b", XPath: "("}}}}, `xpath of "a.b"`),
+		Entry("reading an attribute of a group", []web.Field{{Name: "a", CSS: "tr", Attribute: "id", Fields: []web.Field{{Name: "b", CSS: "td"}}}}, "cannot read an attribute"),
+	)
+
+	It("adds the fields of every page to the result of a crawl", func() {
+		page, err := os.ReadFile("testdata/prices.html")
+		Expect(err).NotTo(HaveOccurred())
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write(page)
+		}))
+		defer server.Close()
+		previous := web.DefaultPolicy()
+		web.SetDefaultPolicy(web.Policy{AllowPrivateNetworks: true})
+		defer web.SetDefaultPolicy(previous)
+
+		data, err := web.ScrapeWebDataWithOptions([]string{server.URL + "/prices"}, web.CrawlOptions{Depth: 1, Extract: fields})
+		Expect(err).NotTo(HaveOccurred())
+		var result web.CollectedData
+		Expect(json.Unmarshal(data, &result)).To(Succeed())
+		Expect(result.Extracted).To(HaveLen(1))
+		Expect(result.Extracted[0].URL).To(Equal(server.URL + "/prices"))
+		Expect(result.Extracted[0].Data).To(HaveKeyWithValue("title", "Token prices"))
+		Expect(result.Extracted[0].Data["tokens"]).To(HaveLen(2))
+
+		_, err = web.ScrapeWebDataWithOptions([]string{server.URL}, web.CrawlOptions{Extract: []web.Field{{Name: "a"}}})
+		Expect(errors.Is(err, web.ErrInvalidField)).To(BeTrue())
+	})
+})
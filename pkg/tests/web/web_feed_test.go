@@ -0,0 +1,267 @@
+package scrapers_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/Gzgod/masa-oracle/pkg/scrapers/normalized"
+	"github.com/Gzgod/masa-oracle/pkg/scrapers/web"
+)
+
+var _ = Describe("Web feeds and sitemaps", func() {
+	var (
+		server   *httptest.Server
+		previous web.Policy
+		mutex    sync.Mutex
+		visited  []string
+		robots   bool
+	)
+
+	fixture := func(name string) []byte {
+		data, err := os.ReadFile("testdata/feeds/" + name)
+		Expect(err).NotTo(HaveOccurred())
+		return data
+	}
+
+	urlset := func(entries ...string) string {
+		var b bytes.Buffer
+		b.WriteString(`<?xml version="1.0" encoding="UTF-8"?><urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`)
+		for i := 0; i < len(entries); i += 2 {
+			fmt.Fprintf(&b, "<url><loc>%s%s</loc>", server.URL, entries[i])
+			if entries[i+1] != "" {
+				fmt.Fprintf(&b, "<lastmod>%s</lastmod>", entries[i+1])
+			}
+			b.WriteString("</url>")
+		}
+		b.WriteString("</urlset>")
+		return b.String()
+	}
+
+	BeforeEach(func() {
+		mutex.Lock()
+		visited = nil
+		mutex.Unlock()
+		robots = true
+		rss, atom, jsonFeed := fixture("rss.xml"), fixture("atom.xml"), fixture("feed.json")
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mutex.Lock()
+			visited = append(visited, r.URL.Path)
+			mutex.Unlock()
+			switch r.URL.Path {
+			case "/robots.txt":
+				if !robots {
+					http.NotFound(w, r)
+					return
+				}
+				fmt.Fprintf(w, "User-agent: *\nDisallow: /private\nSitemap: %s/sitemap_index.xml\n", server.URL)
+			case "/sitemap_index.xml":
+				fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?>
+					<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+						<sitemap><loc>%[1]s/sitemap-pages.xml.gz</loc><lastmod>2024-06-03</lastmod></sitemap>
+						<sitemap><loc>%[1]s/sitemap-old.xml</loc><lastmod>2023-01-01</lastmod></sitemap>
+					</sitemapindex>`, server.URL)
+			case "/sitemap-pages.xml.gz":
+				var compressed bytes.Buffer
+				gz := gzip.NewWriter(&compressed)
+				_, _ = gz.Write([]byte(urlset("/page/b", "2024-05-01", "/page/a", "2024-06-03T10:00:00+00:00", "/page/c", "")))
+				_ = gz.Close()
+				w.Header().Set("Content-Type", "application/x-gzip")
+				_, _ = w.Write(compressed.Bytes())
+			case "/sitemap-old.xml":
+				_, _ = w.Write([]byte(urlset("/page/old", "2023-01-01")))
+			case "/sitemap.xml":
+				_, _ = w.Write([]byte(urlset("/page/root", "2024-06-05")))
+			case "/blog/rss.xml":
+				w.Header().Set("Content-Type", "application/rss+xml")
+				_, _ = w.Write(rss)
+			case "/docs/atom.xml":
+				_, _ = w.Write(atom)
+			case "/changelog.json":
+				_, _ = w.Write(jsonFeed)
+			case "/blog":
+				_, _ = w.Write([]byte(`<html><head>
+					<link rel="alternate" type="application/rss+xml" title="Blog" href="/blog/rss.xml">
+					<link rel="alternate" type="application/atom+xml" title="Docs" href="/docs/atom.xml">
+				</head><body><h1>Blog</h1></body></html>`))
+			default:
+				fmt.Fprintf(w, `<html><body><h1>%s</h1><p>Page text</p><a href="/page/linked">Linked</a></body></html>`, r.URL.Path)
+			}
+		}))
+		previous = web.DefaultPolicy()
+		web.SetDefaultPolicy(web.Policy{AllowPrivateNetworks: true})
+	})
+
+	AfterEach(func() {
+		web.SetDefaultPolicy(previous)
+		server.Close()
+	})
+
+	paths := func() []string {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return append([]string(nil), visited...)
+	}
+
+	date := func(value string) *time.Time {
+		t, err := time.Parse(time.RFC3339, value)
+		Expect(err).NotTo(HaveOccurred())
+		return &t
+	}
+
+	Describe("ReadFeeds", func() {
+		read := func(path string, opts web.FeedOptions) *web.FeedResult {
+			result, err := web.ReadFeeds(context.Background(), server.URL+path, opts)
+			Expect(err).NotTo(HaveOccurred())
+			return result
+		}
+
+		It("reads RSS feeds", func() {
+			result := read("/blog/rss.xml", web.FeedOptions{})
+			Expect(result.Feeds).To(Equal([]web.Feed{{URL: server.URL + "/blog/rss.xml", Type: web.FeedRSS, Title: "Masa Blog"}}))
+			Expect(result.Items).To(HaveLen(3))
+			Expect(result.Items[0]).To(Equal(web.FeedItem{
+				ID:          "post-3",
+				URL:         server.URL + "/blog/mainnet",
+				Title:       "Oracle mainnet is live",
+				Summary:     "The mainnet is <b>live</b>.",
+				Content:     "<p>The oracle mainnet is <strong>live</strong>.</p><ul><li>Faster</li><li>Cheaper</li></ul>",
+				Authors:     []string{"Ada Lovelace"},
+				Categories:  []string{"release", "oracle"},
+				PublishedAt: date("2024-06-03T10:00:00Z"),
+				Enclosures:  []web.Enclosure{{URL: "https://example.com/media/launch.mp3", Type: "audio/mpeg", Length: 12345}},
+				FeedURL:     server.URL + "/blog/rss.xml",
+			}))
+			Expect(result.Items[1].ID).To(Equal("https://example.com/blog/testnet"))
+			Expect(result.Items[1].Authors).To(Equal([]string{"Masa Team"}))
+			Expect(result.Items[1].PublishedAt).To(Equal(date("2024-06-01T08:00:00Z")))
+		})
+
+		It("reads Atom feeds", func() {
+			item := read("/docs/atom.xml", web.FeedOptions{}).Items[0]
+			Expect(item.ID).To(Equal("urn:uuid:1225c695-cfb8-4ebb-aaaa-80da344efa6a"))
+			Expect(item.URL).To(Equal("https://example.com/docs/worker"))
+			Expect(item.Summary).To(Equal("<p>How to run a worker</p>"))
+			Expect(item.Content).To(ContainSubstring("<p>Install the node, then <em>stake</em>.</p>"))
+			Expect(item.Authors).To(Equal([]string{"Grace Hopper"}))
+			Expect(item.Categories).To(Equal([]string{"Guides"}))
+			Expect(item.PublishedAt).To(Equal(date("2024-05-01T09:00:00Z")))
+			Expect(item.UpdatedAt).To(Equal(date("2024-06-02T12:00:00Z")))
+			Expect(item.Enclosures).To(Equal([]web.Enclosure{{URL: server.URL + "/img/worker.png", Type: "image/png", Length: 2048}}))
+		})
+
+		It("reads JSON feeds", func() {
+			result := read("/changelog.json", web.FeedOptions{})
+			Expect(result.Feeds[0].Type).To(Equal(web.FeedJSON))
+			Expect(result.Items).To(HaveLen(1))
+			Expect(result.Items[0].ID).To(Equal("42"))
+			Expect(result.Items[0].Content).To(Equal("Bug fixes."))
+			Expect(result.Items[0].Authors).To(Equal([]string{"Alan Turing"}))
+		})
+
+		It("reads the feeds a web page links to, the newest entries first", func() {
+			result := read("/blog", web.FeedOptions{})
+			Expect(result.Feeds).To(HaveLen(2))
+			Expect(result.Feeds[1].Title).To(Equal("Masa Docs"))
+			var titles []string
+			for _, item := range result.Items {
+				titles = append(titles, item.Title)
+			}
+			Expect(titles).To(Equal([]string{"Oracle mainnet is live", "Running a worker", "Testnet update", "Old post"}))
+		})
+
+		It("skips the entries that did not change since the given date, and limits their number", func() {
+			result := read("/blog", web.FeedOptions{Since: *date("2024-06-01T12:00:00Z"), Limit: 1})
+			Expect(result.Items).To(HaveLen(1))
+			Expect(result.Items[0].Title).To(Equal("Oracle mainnet is live"))
+
+			result = read("/blog", web.FeedOptions{Since: *date("2024-06-01T12:00:00Z")})
+			Expect(result.Items).To(HaveLen(2))
+			Expect(result.Items[1].Title).To(Equal("Running a worker"))
+		})
+
+		It("fails for pages without feeds", func() {
+			_, err := web.ReadFeeds(context.Background(), server.URL+"/page/a", web.FeedOptions{})
+			Expect(errors.Is(err, web.ErrNoFeed)).To(BeTrue())
+		})
+
+		It("converts the entries to normalized posts", func() {
+			posts := web.FeedItemsToPosts(read("/blog/rss.xml", web.FeedOptions{}).Items)
+			Expect(posts).To(HaveLen(3))
+			Expect(posts[0].ID).To(Equal("post-3"))
+			Expect(posts[0].Source).To(Equal(normalized.SourceWeb))
+			Expect(posts[0].Text).To(Equal("The oracle mainnet is live.\n\n- Faster\n- Cheaper"))
+			Expect(posts[0].Author.DisplayName).To(Equal("Ada Lovelace"))
+			Expect(posts[0].Tags).To(Equal([]string{"release", "oracle"}))
+			Expect(posts[0].ChannelID).To(Equal(server.URL + "/blog/rss.xml"))
+			Expect(posts[0].Media).To(Equal([]normalized.Media{{Type: normalized.MediaAudio, URL: "https://example.com/media/launch.mp3", MimeType: "audio/mpeg", Size: 12345}}))
+			Expect(posts[1].Text).To(Equal("Plain text summary"))
+		})
+	})
+
+	Describe("discovering pages", func() {
+		scrape := func(start string, opts web.CrawlOptions) web.CollectedData {
+			data, err := web.ScrapeWebDataWithOptions([]string{server.URL + start}, opts)
+			Expect(err).NotTo(HaveOccurred())
+			var result web.CollectedData
+			Expect(json.Unmarshal(data, &result)).To(Succeed())
+			return result
+		}
+
+		discovered := func(result web.CollectedData) []string {
+			var pages []string
+			for _, page := range result.Discovered {
+				pages = append(pages, page.URL)
+			}
+			return pages
+		}
+
+		It("scrapes the pages of the sitemaps listed in robots.txt, without following links", func() {
+			result := scrape("/", web.CrawlOptions{Depth: 3, Discover: web.DiscoverSitemap})
+			Expect(discovered(result)).To(Equal([]string{server.URL + "/page/a", server.URL + "/page/b", server.URL + "/page/old", server.URL + "/page/c"}))
+			Expect(result.Discovered[0].Source).To(Equal(server.URL + "/sitemap-pages.xml.gz"))
+			Expect(result.PageCount).To(Equal(4))
+			Expect(paths()).NotTo(ContainElement("/page/linked"))
+			Expect(result.Sections).To(HaveLen(4))
+		})
+
+		It("only scrapes the pages that changed since the given date", func() {
+			result := scrape("/", web.CrawlOptions{Discover: web.DiscoverSitemap, Since: *date("2024-05-15T00:00:00Z")})
+			Expect(discovered(result)).To(Equal([]string{server.URL + "/page/a", server.URL + "/page/c"}))
+			// The old sitemap did not change either
+			Expect(paths()).NotTo(ContainElement("/sitemap-old.xml"))
+			Expect(paths()).NotTo(ContainElement("/page/b"))
+		})
+
+		It("falls back to /sitemap.xml", func() {
+			robots = false
+			result := scrape("/page/a", web.CrawlOptions{Discover: web.DiscoverSitemap})
+			Expect(discovered(result)).To(Equal([]string{server.URL + "/page/root"}))
+		})
+
+		It("scrapes the pages of the entries of feeds", func() {
+			result := scrape("/blog", web.CrawlOptions{Discover: web.DiscoverFeed, Since: *date("2024-05-15T00:00:00Z"), Scope: web.ScopeSameDomain})
+			// The other entries link to another host
+			Expect(discovered(result)).To(Equal([]string{server.URL + "/blog/mainnet"}))
+			Expect(paths()).To(ContainElement("/blog/mainnet"))
+		})
+
+		It("fails when there is nothing to discover", func() {
+			_, err := web.ScrapeWebDataWithOptions([]string{server.URL + "/page/a"}, web.CrawlOptions{Discover: web.DiscoverFeed})
+			Expect(errors.Is(err, web.ErrNoFeed)).To(BeTrue())
+			_, err = web.ScrapeWebDataWithOptions([]string{server.URL}, web.CrawlOptions{Discover: "everything"})
+			Expect(err).To(MatchError(ContainSubstring("invalid discover mode")))
+		})
+	})
+})
@@ -0,0 +1,147 @@
+package scrapers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/Gzgod/masa-oracle/pkg/scrapers/web"
+)
+
+var _ = Describe("Web files", func() {
+	var report []byte
+
+	BeforeEach(func() {
+		var err error
+		report, err = os.ReadFile("testdata/report.pdf")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("extracts the text and document information of PDFs", func() {
+		file := web.ReadFile(report, "https://example.com/report.pdf", "application/pdf")
+		Expect(file.Kind).To(Equal(web.FilePDF))
+		Expect(file.ContentType).To(Equal("application/pdf"))
+		Expect(file.Size).To(Equal(len(report)))
+		Expect(file.SHA256).To(HaveLen(64))
+		Expect(file.Title).To(Equal("Quarterly Report"))
+		Expect(file.Author).To(Equal("Jane Doe"))
+		Expect(file.Pages).To(Equal(2))
+		Expect(file.Text).To(Equal("Quarterly Report\nRevenue grew by 12 percent.\n\nOutlook\nWe expect steady growth."))
+		Expect(file.Error).To(BeEmpty())
+	})
+
+	It("sniffs files without a specific content type", func() {
+		Expect(web.ReadFile(report, "https://example.com/download", "application/octet-stream").Kind).To(Equal(web.FilePDF))
+		Expect(web.ReadFile([]byte("just text"), "https://example.com/notes", "").Kind).To(Equal(web.FileText))
+	})
+
+	It("passes text and JSON documents through", func() {
+		text := web.ReadFile([]byte("id,name\n1,masa\n"), "https://example.com/data.csv", "text/csv; charset=utf-8")
+		Expect(text.Kind).To(Equal(web.FileText))
+		Expect(text.ContentType).To(Equal("text/csv"))
+		Expect(text.Text).To(Equal("id,name\n1,masa\n"))
+
+		data := web.ReadFile([]byte(`{"price": 1.5}`), "https://example.com/api", "application/ld+json")
+		Expect(data.Kind).To(Equal(web.FileJSON))
+		Expect(string(data.JSON)).To(Equal(`{"price": 1.5}`))
+
+		invalid := web.ReadFile([]byte(`{"price":`), "https://example.com/api", "application/json")
+		Expect(invalid.JSON).To(BeNil())
+		Expect(invalid.Error).To(Equal("invalid JSON"))
+	})
+
+	It("only describes binaries", func() {
+		png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+		file := web.ReadFile(png, "https://example.com/logo", "")
+		Expect(file.Kind).To(Equal(web.FileBinary))
+		Expect(file.ContentType).To(Equal("image/png"))
+		Expect(file.Size).To(Equal(len(png)))
+		Expect(file.Text).To(BeEmpty())
+	})
+
+	It("reports PDFs it cannot read", func() {
+		file := web.ReadFile([]byte("%PDF-1.4\nbroken"), "https://example.com/broken.pdf", "application/pdf")
+		Expect(file.Kind).To(Equal(web.FilePDF))
+		Expect(file.Error).To(ContainSubstring("unable to read PDF"))
+	})
+
+	Context("when crawling", func() {
+		var (
+			server   *httptest.Server
+			previous web.Policy
+		)
+
+		BeforeEach(func() {
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/":
+					fmt.Fprint(w, `<html><body><h1>Downloads</h1>
+						<a href="/report.pdf">Report</a> <a href="/notes.txt">Notes</a>
+						<a href="/prices.json">Prices</a> <a href="/logo.png">Logo</a>
+					</body></html>`)
+				case "/report.pdf":
+					w.Header().Set("Content-Type", "application/pdf")
+					_, _ = w.Write(report)
+				case "/notes.txt":
+					w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+					fmt.Fprint(w, "Release notes\nFaster scraping.")
+				case "/prices.json":
+					w.Header().Set("Content-Type", "application/json")
+					fmt.Fprint(w, `{"masa":0.05}`)
+				case "/logo.png":
+					w.Header().Set("Content-Type", "image/png")
+					_, _ = w.Write([]byte("\x89PNG\r\n\x1a\n"))
+				}
+			}))
+			previous = web.DefaultPolicy()
+			web.SetDefaultPolicy(web.Policy{AllowPrivateNetworks: true})
+		})
+
+		AfterEach(func() {
+			web.SetDefaultPolicy(previous)
+			server.Close()
+		})
+
+		files := func(result web.CollectedData) map[string]web.File {
+			byURL := map[string]web.File{}
+			for _, file := range result.Files {
+				byURL[file.URL] = file
+			}
+			return byURL
+		}
+
+		It("adds the files it reaches to the result", func() {
+			data, err := web.ScrapeWebDataWithOptions([]string{server.URL + "/"}, web.CrawlOptions{Depth: 2})
+			Expect(err).NotTo(HaveOccurred())
+			var result web.CollectedData
+			Expect(json.Unmarshal(data, &result)).To(Succeed())
+
+			byURL := files(result)
+			Expect(byURL).To(HaveLen(4))
+			Expect(byURL[server.URL+"/report.pdf"].Text).To(HavePrefix("Quarterly Report\n"))
+			Expect(byURL[server.URL+"/notes.txt"].Text).To(Equal("Release notes\nFaster scraping."))
+			Expect(string(byURL[server.URL+"/prices.json"].JSON)).To(Equal(`{"masa":0.05}`))
+			Expect(byURL[server.URL+"/logo.png"].Kind).To(Equal(web.FileBinary))
+
+			// A post per section and per file with text
+			posts := web.CollectedDataToPosts(server.URL, result)
+			Expect(posts).To(HaveLen(3))
+		})
+
+		It("adds the text of files to the documents", func() {
+			data, err := web.ScrapeWebDataWithOptions([]string{server.URL + "/report.pdf"}, web.CrawlOptions{Depth: 1, Content: web.ContentMarkdown})
+			Expect(err).NotTo(HaveOccurred())
+			var result web.CollectedData
+			Expect(json.Unmarshal(data, &result)).To(Succeed())
+			Expect(result.Documents).To(HaveLen(1))
+			Expect(result.Documents[0].Title).To(Equal("Quarterly Report"))
+			Expect(result.Documents[0].Format).To(Equal(web.ContentText))
+			Expect(result.Documents[0].Content).To(ContainSubstring("We expect steady growth."))
+		})
+	})
+})
@@ -0,0 +1,139 @@
+package scrapers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/Gzgod/masa-oracle/pkg/scrapers/web"
+)
+
+var _ = Describe("Web structured metadata", func() {
+	extract := func() web.Metadata {
+		page, err := os.Open("testdata/metadata.html")
+		Expect(err).NotTo(HaveOccurred())
+		defer page.Close()
+		meta, err := web.ExtractMetadata(page, "https://example.com/news/launch")
+		Expect(err).NotTo(HaveOccurred())
+		return meta
+	}
+
+	It("returns the JSON-LD objects, flattening graphs and skipping invalid scripts", func() {
+		meta := extract()
+		Expect(meta.URL).To(Equal("https://example.com/news/launch"))
+		Expect(meta.JSONLD).To(HaveLen(2))
+		Expect(meta.JSONLD[0]).To(HaveKeyWithValue("@type", "WebSite"))
+		Expect(meta.JSONLD[1]).To(HaveKeyWithValue("headline", "Masa launches the oracle mainnet"))
+	})
+
+	It("returns the OpenGraph and Twitter Card tags", func() {
+		meta := extract()
+		Expect(meta.OpenGraph).To(Equal(map[string]string{
+			"og:title":               "Masa launches the oracle mainnet",
+			"og:type":                "article",
+			"og:image":               "https://example.com/img/launch.png",
+			"article:published_time": "2024-03-01T09:30:00+01:00",
+		}))
+		Expect(meta.Twitter).To(Equal(map[string]string{
+			"twitter:card": "summary_large_image",
+			"twitter:site": "@getmasafi",
+		}))
+	})
+
+	It("returns the microdata items", func() {
+		meta := extract()
+		Expect(meta.Microdata).To(HaveLen(1))
+		article := meta.Microdata[0]
+		Expect(article.Type).To(Equal([]string{"https://schema.org/Article"}))
+		Expect(article.Properties).To(HaveKeyWithValue("headline", []any{"Masa launches the oracle mainnet"}))
+		Expect(article.Properties).To(HaveKeyWithValue("datePublished", []any{"2024-02-29"}))
+		Expect(article.Properties).To(HaveKeyWithValue("image", []any{"https://example.com/img/launch.png"}))
+		Expect(article.Properties).NotTo(HaveKey("name"))
+		Expect(article.Properties["author"]).To(Equal([]any{web.MicrodataItem{
+			Type: []string{"https://schema.org/Person"},
+			Properties: map[string][]any{
+				"name": {"Grace Hopper"},
+				"url":  {"https://example.com/authors/grace"},
+			},
+		}}))
+	})
+
+	It("returns the linked feeds", func() {
+		Expect(extract().Feeds).To(Equal([]web.Feed{
+			{URL: "https://example.com/feed.xml", Type: "application/rss+xml", Title: "Masa blog"},
+			{URL: "https://example.com/atom.xml", Type: "application/atom+xml"},
+		}))
+	})
+
+	It("prefers the dates and authors of the JSON-LD", func() {
+		meta := extract()
+		Expect(*meta.PublishedAt).To(Equal(time.Date(2024, 3, 1, 8, 30, 0, 0, time.UTC)))
+		Expect(*meta.ModifiedAt).To(Equal(time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC)))
+		Expect(meta.Authors).To(Equal([]string{"Ada Lovelace", "Alan Turing"}))
+	})
+
+	It("falls back to meta tags and microdata for dates and authors", func() {
+		page := `<html><head><meta property="article:published_time" content="2024-05-06T07:08:09+02:00"></head><body>
+			<div itemscope itemtype="https://schema.org/BlogPosting">
+				<span itemprop="author" itemscope itemtype="https://schema.org/Person"><span itemprop="name">Grace Hopper</span></span>
+				<meta itemprop="dateModified" content="2024-05-07">
+			</div></body></html>`
+		meta, err := web.ExtractMetadata(strings.NewReader(page), "https://example.com/")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*meta.PublishedAt).To(Equal(time.Date(2024, 5, 6, 5, 8, 9, 0, time.UTC)))
+		Expect(*meta.ModifiedAt).To(Equal(time.Date(2024, 5, 7, 0, 0, 0, 0, time.UTC)))
+		Expect(meta.Authors).To(Equal([]string{"Grace Hopper"}))
+		Expect(meta.JSONLD).To(BeEmpty())
+		Expect(meta.Feeds).To(BeEmpty())
+	})
+
+	Context("when crawling", func() {
+		var (
+			server   *httptest.Server
+			previous web.Policy
+		)
+
+		BeforeEach(func() {
+			page, err := os.ReadFile("testdata/metadata.html")
+			Expect(err).NotTo(HaveOccurred())
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write(page)
+			}))
+			previous = web.DefaultPolicy()
+			web.SetDefaultPolicy(web.Policy{AllowPrivateNetworks: true})
+		})
+
+		AfterEach(func() {
+			web.SetDefaultPolicy(previous)
+			server.Close()
+		})
+
+		It("adds the metadata of every page to the result", func() {
+			data, err := web.ScrapeWebDataWithOptions([]string{server.URL + "/news/launch"}, web.CrawlOptions{Depth: 1, Metadata: true, Content: web.ContentMarkdown})
+			Expect(err).NotTo(HaveOccurred())
+			var result web.CollectedData
+			Expect(json.Unmarshal(data, &result)).To(Succeed())
+			Expect(result.Metadata).To(HaveLen(1))
+			Expect(result.Metadata[0].URL).To(Equal(server.URL + "/news/launch"))
+			Expect(result.Metadata[0].Feeds[0].URL).To(Equal(server.URL + "/feed.xml"))
+			Expect(result.Sections).NotTo(BeEmpty())
+
+			posts := web.CollectedDataToPosts(server.URL+"/news/launch", result)
+			Expect(posts).To(HaveLen(1))
+			Expect(*posts[0].CreatedAt).To(Equal(time.Date(2024, 3, 1, 8, 30, 0, 0, time.UTC)))
+			Expect(posts[0].Author.DisplayName).To(Equal("Ada Lovelace"))
+		})
+
+		It("leaves the metadata out unless asked", func() {
+			data, err := web.ScrapeWebDataWithOptions([]string{server.URL}, web.CrawlOptions{Depth: 1})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(data)).NotTo(ContainSubstring(`"metadata"`))
+		})
+	})
+})
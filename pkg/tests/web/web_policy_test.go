@@ -0,0 +1,123 @@
+package scrapers_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/Gzgod/masa-oracle/pkg/scrapers/web"
+)
+
+var _ = Describe("Web scraper policy", func() {
+	var (
+		server   *httptest.Server
+		hits     atomic.Int32
+		previous web.Policy
+	)
+
+	BeforeEach(func() {
+		hits.Store(0)
+		mux := http.NewServeMux()
+		mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+			hits.Add(1)
+			port := strings.TrimPrefix(server.URL, "http://127.0.0.1:")
+			fmt.Fprintf(w, `<html><body><h1>Home</h1><p>Welcome</p>
+				<a href="/about">About</a>
+				<a href="http://localhost:%s/internal">Internal</a>
+				<a href="mailto:admin@example.com">Mail</a>
+			</body></html>`, port)
+		})
+		mux.HandleFunc("/about", func(w http.ResponseWriter, r *http.Request) {
+			hits.Add(1)
+			fmt.Fprint(w, `<html><body><h1>About</h1></body></html>`)
+		})
+		mux.HandleFunc("/redirect", func(w http.ResponseWriter, r *http.Request) {
+			hits.Add(1)
+			http.Redirect(w, r, strings.Replace(server.URL, "127.0.0.1", "localhost", 1)+"/", http.StatusFound)
+		})
+		server = httptest.NewServer(mux)
+		previous = web.DefaultPolicy()
+	})
+
+	AfterEach(func() {
+		web.SetDefaultPolicy(previous)
+		server.Close()
+	})
+
+	expectViolation := func(err error, target error) *web.PolicyError {
+		Expect(errors.Is(err, target)).To(BeTrue(), "unexpected error %v", err)
+		var policyErr *web.PolicyError
+		Expect(errors.As(err, &policyErr)).To(BeTrue())
+		return policyErr
+	}
+
+	It("blocks loopback, private and link-local addresses", func() {
+		web.SetDefaultPolicy(web.Policy{})
+		for _, target := range []string{
+			server.URL,
+			"http://10.0.0.1/",
+			"http://192.168.1.1/",
+			"http://169.254.169.254/latest/meta-data/",
+			"http://[::1]/",
+			"http://[::ffff:127.0.0.1]/",
+			"http://0.0.0.0/",
+		} {
+			_, err := web.ScrapeWebData([]string{target}, 1)
+			expectViolation(err, web.ErrBlockedAddress)
+		}
+		Expect(hits.Load()).To(BeZero())
+	})
+
+	It("blocks host names after resolving them", func() {
+		web.SetDefaultPolicy(web.Policy{})
+		_, err := web.ScrapeWebData([]string{strings.Replace(server.URL, "127.0.0.1", "localhost", 1)}, 1)
+		policyErr := expectViolation(err, web.ErrBlockedAddress)
+		Expect(policyErr.URL).To(HavePrefix("http://localhost:"))
+		Expect(policyErr.Address).To(Or(Equal("127.0.0.1"), Equal("::1")))
+		Expect(hits.Load()).To(BeZero())
+	})
+
+	It("applies the policy to redirects", func() {
+		web.SetDefaultPolicy(web.Policy{AllowPrivateNetworks: true, DeniedDomains: []string{"localhost"}})
+		_, err := web.ScrapeWebData([]string{server.URL + "/redirect"}, 1)
+		policyErr := expectViolation(err, web.ErrDomainDenied)
+		Expect(policyErr.URL).To(HavePrefix("http://localhost:"))
+		Expect(hits.Load()).To(Equal(int32(1)))
+	})
+
+	It("skips links the policy blocks", func() {
+		web.SetDefaultPolicy(web.Policy{AllowPrivateNetworks: true, DeniedDomains: []string{"LOCALHOST"}})
+		data, err := web.ScrapeWebData([]string{server.URL}, 2)
+		Expect(err).NotTo(HaveOccurred())
+
+		var result web.CollectedData
+		Expect(json.Unmarshal(data, &result)).To(Succeed())
+		Expect(result.Pages).To(ConsistOf(server.URL + "/about"))
+		Expect(hits.Load()).To(Equal(int32(2)))
+	})
+
+	It("limits the scraper to the allowed domains", func() {
+		policy := web.Policy{AllowedDomains: []string{"*.example.com"}, DeniedDomains: []string{"private.example.com"}}
+		check := func(raw string) error {
+			u, err := url.Parse(raw)
+			Expect(err).NotTo(HaveOccurred())
+			return policy.CheckURL(u)
+		}
+
+		Expect(check("https://example.com/")).To(Succeed())
+		Expect(check("https://docs.Example.com./page")).To(Succeed())
+		expectViolation(check("https://notexample.com/"), web.ErrDomainNotAllowed)
+		expectViolation(check("https://example.org/"), web.ErrDomainNotAllowed)
+		expectViolation(check("https://api.private.example.com/"), web.ErrDomainDenied)
+		expectViolation(check("ftp://example.com/file"), web.ErrUnsupportedScheme)
+		expectViolation(check("file:///etc/passwd"), web.ErrUnsupportedScheme)
+	})
+})
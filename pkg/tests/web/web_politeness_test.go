@@ -0,0 +1,247 @@
+package scrapers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/Gzgod/masa-oracle/pkg/scrapers/web"
+)
+
+var _ = Describe("Web scraper politeness", func() {
+	var (
+		previousPolicy     web.Policy
+		previousPoliteness web.Politeness
+		mutex              sync.Mutex
+		requests           map[string][]time.Time
+		agents             []string
+	)
+
+	record := func(r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		requests[r.Host+r.URL.Path] = append(requests[r.Host+r.URL.Path], time.Now())
+		agents = append(agents, r.UserAgent())
+	}
+
+	requested := func(server *httptest.Server, path string) []time.Time {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return requests[server.Listener.Addr().String()+path]
+	}
+
+	scrape := func(uri []string, opts web.CrawlOptions) web.CollectedData {
+		data, err := web.ScrapeWebDataWithOptions(uri, opts)
+		Expect(err).NotTo(HaveOccurred())
+		var result web.CollectedData
+		Expect(json.Unmarshal(data, &result)).To(Succeed())
+		return result
+	}
+
+	BeforeEach(func() {
+		requests = map[string][]time.Time{}
+		agents = nil
+		previousPolicy = web.DefaultPolicy()
+		previousPoliteness = web.DefaultPoliteness()
+		web.SetDefaultPolicy(web.Policy{AllowPrivateNetworks: true})
+	})
+
+	AfterEach(func() {
+		web.SetDefaultPolicy(previousPolicy)
+		web.SetDefaultPoliteness(previousPoliteness)
+	})
+
+	Context("with robots.txt", func() {
+		var server *httptest.Server
+
+		BeforeEach(func() {
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				record(r)
+				switch r.URL.Path {
+				case "/robots.txt":
+					fmt.Fprint(w, "User-agent: *\nDisallow: /\n\nUser-agent: TestBot\nDisallow: /private\nCrawl-delay: 1\n")
+				case "/":
+					fmt.Fprint(w, `<html><body><h1>Home</h1><a href="/public">Public</a> <a href="/private/data">Private</a></body></html>`)
+				default:
+					fmt.Fprintf(w, `<html><body><h1>%s</h1></body></html>`, r.URL.Path)
+				}
+			}))
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("sends the configured user agent", func() {
+			web.SetDefaultPoliteness(web.Politeness{UserAgent: "TestBot/2.0 (+https://example.com/bot)"})
+			scrape([]string{server.URL + "/"}, web.CrawlOptions{Depth: 1})
+			Expect(agents).To(ConsistOf("TestBot/2.0 (+https://example.com/bot)"))
+		})
+
+		It("sends a default user agent", func() {
+			web.SetDefaultPoliteness(web.Politeness{})
+			scrape([]string{server.URL + "/"}, web.CrawlOptions{Depth: 1})
+			Expect(agents).To(ConsistOf(web.DefaultUserAgent))
+		})
+
+		It("ignores robots.txt unless asked", func() {
+			web.SetDefaultPoliteness(web.Politeness{UserAgent: "TestBot/2.0", Delay: -1})
+			result := scrape([]string{server.URL + "/"}, web.CrawlOptions{Depth: 2})
+			Expect(result.Disallowed).To(BeEmpty())
+			Expect(requested(server, "/robots.txt")).To(BeEmpty())
+			Expect(requested(server, "/private/data")).To(HaveLen(1))
+		})
+
+		It("skips disallowed pages and waits the crawl delay", func() {
+			web.SetDefaultPoliteness(web.Politeness{UserAgent: "Mozilla/5.0 (compatible; TestBot/2.0)", RespectRobotsTxt: true, Delay: -1})
+			result := scrape([]string{server.URL + "/"}, web.CrawlOptions{Depth: 2})
+			Expect(result.Disallowed).To(Equal([]string{server.URL + "/private/data"}))
+			Expect(requested(server, "/robots.txt")).To(HaveLen(1))
+			Expect(requested(server, "/private/data")).To(BeEmpty())
+
+			home, public := requested(server, "/"), requested(server, "/public")
+			Expect(home).To(HaveLen(1))
+			Expect(public).To(HaveLen(1))
+			Expect(public[0].Sub(home[0])).To(BeNumerically(">=", 900*time.Millisecond))
+		})
+
+		It("applies the rules of the wildcard group to other agents", func() {
+			web.SetDefaultPoliteness(web.Politeness{UserAgent: "OtherBot/1.0", RespectRobotsTxt: true, Delay: -1})
+			result := scrape([]string{server.URL + "/"}, web.CrawlOptions{Depth: 2})
+			Expect(result.Disallowed).To(Equal([]string{server.URL + "/"}))
+			Expect(result.PageCount).To(BeZero())
+		})
+	})
+
+	Context("with rate limits", func() {
+		var (
+			limited, other *httptest.Server
+			attempts       atomic.Int32
+		)
+
+		BeforeEach(func() {
+			attempts.Store(0)
+			limited = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				record(r)
+				switch r.URL.Path {
+				case "/once":
+					if attempts.Add(1) == 1 {
+						w.Header().Set("Retry-After", "1")
+						w.WriteHeader(http.StatusTooManyRequests)
+						return
+					}
+					fmt.Fprint(w, `<html><body><h1>Finally</h1></body></html>`)
+				case "/down":
+					w.Header().Set("Retry-After", "0")
+					w.WriteHeader(http.StatusServiceUnavailable)
+				}
+			}))
+			other = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				record(r)
+				if r.URL.Path == "/" {
+					fmt.Fprint(w, `<html><body><h1>Other</h1><a href="/next">Next</a></body></html>`)
+					return
+				}
+				fmt.Fprint(w, `<html><body><h1>Next</h1></body></html>`)
+			}))
+			web.SetDefaultPoliteness(web.Politeness{Delay: -1})
+		})
+
+		AfterEach(func() {
+			limited.Close()
+			other.Close()
+		})
+
+		It("retries later without holding up other requests", func() {
+			result := scrape([]string{limited.URL + "/once", other.URL + "/"}, web.CrawlOptions{Depth: 2})
+
+			once := requested(limited, "/once")
+			Expect(once).To(HaveLen(2))
+			Expect(once[1].Sub(once[0])).To(BeNumerically(">=", 900*time.Millisecond))
+			next := requested(other, "/next")
+			Expect(next).To(HaveLen(1))
+			Expect(next[0].Before(once[1])).To(BeTrue())
+
+			titles := []string{}
+			for _, section := range result.Sections {
+				titles = append(titles, section.Title)
+			}
+			Expect(titles).To(ContainElements("Finally", "Other", "Next"))
+		})
+
+		It("gives up after a few retries", func() {
+			scrape([]string{limited.URL + "/down"}, web.CrawlOptions{Depth: 1})
+			Expect(requested(limited, "/down")).To(HaveLen(4))
+		})
+	})
+
+	Context("with per-domain limits", func() {
+		var (
+			server            *httptest.Server
+			inFlight, maxSeen atomic.Int32
+		)
+
+		BeforeEach(func() {
+			inFlight.Store(0)
+			maxSeen.Store(0)
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := inFlight.Add(1)
+				defer inFlight.Add(-1)
+				for {
+					seen := maxSeen.Load()
+					if n <= seen || maxSeen.CompareAndSwap(seen, n) {
+						break
+					}
+				}
+				time.Sleep(100 * time.Millisecond)
+				if r.URL.Path == "/" {
+					fmt.Fprint(w, `<html><body><h1>Home</h1><a href="/1">1</a><a href="/2">2</a><a href="/3">3</a><a href="/4">4</a></body></html>`)
+					return
+				}
+				fmt.Fprint(w, `<html><body><h1>Page</h1></body></html>`)
+			}))
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("sends requests to a host in parallel", func() {
+			web.SetDefaultPoliteness(web.Politeness{Delay: -1})
+			scrape([]string{server.URL + "/"}, web.CrawlOptions{Depth: 2})
+			Expect(maxSeen.Load()).To(BeNumerically(">", 1))
+		})
+
+		It("applies the limits of the domain", func() {
+			web.SetDefaultPoliteness(web.Politeness{Delay: -1, Domains: []web.DomainLimit{{Domain: "127.0.0.1", Parallelism: 1}}})
+			scrape([]string{server.URL + "/"}, web.CrawlOptions{Depth: 2})
+			Expect(maxSeen.Load()).To(Equal(int32(1)))
+		})
+	})
+
+	It("parses domain limits", func() {
+		limit, err := web.ParseDomainLimit("example.com=1/2s")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(limit).To(Equal(web.DomainLimit{Domain: "example.com", Parallelism: 1, Delay: 2 * time.Second}))
+
+		limit, err = web.ParseDomainLimit(" wikipedia.org = /250ms")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(limit).To(Equal(web.DomainLimit{Domain: "wikipedia.org", Delay: 250 * time.Millisecond}))
+
+		limit, err = web.ParseDomainLimit("example.org=2")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(limit).To(Equal(web.DomainLimit{Domain: "example.org", Parallelism: 2}))
+
+		for _, invalid := range []string{"example.com", "=1/1s", "example.com=0", "example.com=1/soon"} {
+			_, err := web.ParseDomainLimit(invalid)
+			Expect(err).To(HaveOccurred(), invalid)
+		}
+	})
+})
@@ -0,0 +1,161 @@
+package scrapers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/Gzgod/masa-oracle/pkg/scrapers/web"
+)
+
+var _ = Describe("Web crawl results", func() {
+	var (
+		server             *httptest.Server
+		previous           web.Policy
+		previousPoliteness web.Politeness
+		mutex              sync.Mutex
+		visited            []string
+	)
+
+	pages := map[string]string{
+		"/": `<h1>Home</h1><p>Welcome</p>
+			<a href="/b">B</a> <a href="/a#top">A</a> <a href="/a">A again</a>
+			<a href="/list?sort=asc&page=1">List</a> <a href="/moved">Moved</a>`,
+		"/a":    `<p>Before any heading</p><h1>A</h1><p>First</p><p>First</p><img src="/a.png"><h2>More</h2><p>Second</p><a href="/deep">Deep</a>`,
+		"/b":    `<h1>B</h1><p>Only</p><a href="/list?page=1&sort=asc">List</a> <a href="/deep">Deep</a> <a href="/">Home</a>`,
+		"/list": `<h1>List</h1>`,
+		"/deep": `<h1>Deep</h1>`,
+		// A long and a slow short path to /x
+		"/chain":    `<h1>Chain</h1><a href="/c1">C1</a> <a href="/shortcut">Shortcut</a>`,
+		"/c1":       `<h1>C1</h1><a href="/c2">C2</a>`,
+		"/c2":       `<h1>C2</h1><a href="/x">X</a>`,
+		"/shortcut": `<h1>Shortcut</h1><a href="/x">X</a>`,
+		"/x":        `<h1>X</h1><a href="/y">Y</a>`,
+		"/y":        `<h1>Y</h1>`,
+	}
+
+	BeforeEach(func() {
+		mutex.Lock()
+		visited = nil
+		mutex.Unlock()
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mutex.Lock()
+			visited = append(visited, r.URL.RequestURI())
+			mutex.Unlock()
+			// Answer in a random order
+			time.Sleep(time.Duration(rand.Intn(30)) * time.Millisecond)
+			if r.URL.Path == "/shortcut" {
+				time.Sleep(300 * time.Millisecond)
+			}
+			if r.URL.Path == "/moved" {
+				http.Redirect(w, r, "/b", http.StatusMovedPermanently)
+				return
+			}
+			body, ok := pages[r.URL.Path]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			fmt.Fprintf(w, "<html><body>%s</body></html>", body)
+		}))
+		previous = web.DefaultPolicy()
+		web.SetDefaultPolicy(web.Policy{AllowPrivateNetworks: true})
+		previousPoliteness = web.DefaultPoliteness()
+		web.SetDefaultPoliteness(web.Politeness{Delay: -1})
+	})
+
+	AfterEach(func() {
+		web.SetDefaultPolicy(previous)
+		web.SetDefaultPoliteness(previousPoliteness)
+		server.Close()
+	})
+
+	scrape := func(start string, depth int) ([]byte, web.CollectedData) {
+		data, err := web.ScrapeWebData([]string{start}, depth)
+		Expect(err).NotTo(HaveOccurred())
+		var result web.CollectedData
+		Expect(json.Unmarshal(data, &result)).To(Succeed())
+		return data, result
+	}
+
+	It("returns every page with its depth, parent and sections", func() {
+		_, result := scrape(server.URL, 3)
+
+		home := server.URL + "/"
+		Expect(result.Results).To(HaveLen(5))
+		Expect(result.Results[0]).To(Equal(web.Page{URL: home, Depth: 1, Sections: []web.Section{{Title: "Home", Paragraphs: []string{"Welcome"}}}}))
+
+		urls := []string{}
+		for _, page := range result.Results {
+			urls = append(urls, page.URL)
+		}
+		Expect(urls).To(Equal([]string{home, server.URL + "/a", server.URL + "/b", server.URL + "/list?page=1&sort=asc", server.URL + "/deep"}))
+
+		a := result.Results[1]
+		Expect(a.Depth).To(Equal(2))
+		Expect(a.Parent).To(Equal(home))
+		Expect(a.Sections).To(Equal([]web.Section{
+			{Title: "A", Paragraphs: []string{"First"}, Images: []string{server.URL + "/a.png"}},
+			{Title: "More", Paragraphs: []string{"Second"}},
+		}))
+
+		// Linked to from /a and /b, the smallest URL is the parent
+		deep := result.Results[4]
+		Expect(deep.Depth).To(Equal(3))
+		Expect(deep.Parent).To(Equal(server.URL + "/a"))
+
+		Expect(result.Sections).To(HaveLen(6))
+		Expect(result.Sections[0].Title).To(Equal("Home"))
+		Expect(result.Sections[5].Title).To(Equal("Deep"))
+	})
+
+	It("requests every page once", func() {
+		_, result := scrape(server.URL, 3)
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		Expect(visited).To(ConsistOf("/", "/a", "/b", "/list?page=1&sort=asc", "/moved", "/b", "/deep"))
+		// The redirect is followed within the same request
+		Expect(result.PageCount).To(Equal(6))
+		Expect(result.Pages).To(Equal([]string{
+			server.URL + "/", server.URL + "/a", server.URL + "/b", server.URL + "/deep",
+			server.URL + "/list?page=1&sort=asc", server.URL + "/moved",
+		}))
+	})
+
+	It("returns the same result for the same pages", func() {
+		first, _ := scrape(server.URL, 3)
+		for i := 0; i < 3; i++ {
+			again, _ := scrape(server.URL, 3)
+			Expect(string(again)).To(Equal(string(first)))
+		}
+	})
+
+	It("gives a page found through a slower, shorter path its smallest depth", func() {
+		_, result := scrape(server.URL+"/chain", 4)
+
+		depths, parents := map[string]int{}, map[string]string{}
+		for _, page := range result.Results {
+			path := strings.TrimPrefix(page.URL, server.URL)
+			depths[path], parents[path] = page.Depth, strings.TrimPrefix(page.Parent, server.URL)
+		}
+		Expect(depths).To(Equal(map[string]int{"/chain": 1, "/c1": 2, "/shortcut": 2, "/c2": 3, "/x": 3, "/y": 4}))
+		Expect(parents["/x"]).To(Equal("/shortcut"))
+		Expect(parents["/y"]).To(Equal("/x"))
+	})
+
+	It("identifies pages by their canonical URL", func() {
+		start := strings.Replace(server.URL, "http://127.0.0.1", "HTTP://127.0.0.1", 1) + "#intro"
+		_, result := scrape(start, 1)
+		Expect(result.Results).To(HaveLen(1))
+		Expect(result.Results[0].URL).To(Equal(server.URL + "/"))
+	})
+})
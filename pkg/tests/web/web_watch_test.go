@@ -0,0 +1,228 @@
+package scrapers_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	ds "github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/Gzgod/masa-oracle/pkg/scrapers/web"
+)
+
+var _ = Describe("Web page watching", func() {
+	var (
+		server      *httptest.Server
+		previous    web.Policy
+		mutex       sync.Mutex
+		price       string
+		conditional []string
+		changes     []web.Change
+		failures    int
+		watcher     *web.Watcher
+		ctx         = context.Background()
+	)
+
+	page := func() string {
+		return fmt.Sprintf(`<html><head><title>Pricing</title></head><body>
+			<nav><a href="/">Home</a></nav>
+			<main><h1>Pricing</h1><p>Starter</p><p>%s per month</p><p>Pro</p><p>Contact us</p></main>
+			<footer>Rendered at %d</footer>
+		</body></html>`, price, time.Now().UnixNano())
+	}
+
+	BeforeEach(func() {
+		price = "$10"
+		conditional = nil
+		changes = nil
+		failures = 0
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			etag := `"` + price + `"`
+			if match := r.Header.Get("If-None-Match"); match != "" {
+				conditional = append(conditional, match)
+				if match == etag && r.URL.Path == "/pricing" {
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+			}
+			switch r.URL.Path {
+			case "/pricing":
+				w.Header().Set("ETag", etag)
+				fmt.Fprint(w, page())
+			case "/footer":
+				// No ETag, the page is rendered anew every time
+				fmt.Fprint(w, page())
+			case "/prices.txt":
+				w.Header().Set("Content-Type", "text/plain")
+				fmt.Fprintf(w, "starter %s\npro $50\n", price)
+			default:
+				http.NotFound(w, r)
+			}
+		}))
+		previous = web.DefaultPolicy()
+		web.SetDefaultPolicy(web.Policy{AllowPrivateNetworks: true})
+
+		var err error
+		watcher, err = web.NewWatcher(web.WatchConfig{
+			URLs:  []string{server.URL + "/pricing", server.URL + "/footer", server.URL + "/prices.txt"},
+			Store: dssync.MutexWrap(ds.NewMapDatastore()),
+		}, func(change web.Change) error {
+			if failures > 0 {
+				failures--
+				return errors.New("delivery failed")
+			}
+			changes = append(changes, change)
+			return nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		web.SetDefaultPolicy(previous)
+		server.Close()
+	})
+
+	setPrice := func(p string) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		price = p
+	}
+
+	It("records the first check without reporting a change", func() {
+		change, err := watcher.CheckPage(ctx, server.URL+"/pricing")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(change).To(BeNil())
+
+		state, err := watcher.State(ctx, server.URL+"/pricing")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(state.ETag).To(Equal(`"$10"`))
+		Expect(state.Title).To(Equal("Pricing"))
+		Expect(state.Text).To(ContainSubstring("$10 per month"))
+		Expect(state.Text).NotTo(ContainSubstring("Rendered at"))
+		Expect(state.Hash).To(HaveLen(64))
+	})
+
+	It("uses conditional requests and keeps unchanged pages", func() {
+		_, err := watcher.CheckPage(ctx, server.URL+"/pricing")
+		Expect(err).NotTo(HaveOccurred())
+		first, _ := watcher.State(ctx, server.URL+"/pricing")
+
+		change, err := watcher.CheckPage(ctx, server.URL+"/pricing")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(change).To(BeNil())
+		Expect(conditional).To(Equal([]string{`"$10"`}))
+
+		second, _ := watcher.State(ctx, server.URL+"/pricing")
+		Expect(second.Hash).To(Equal(first.Hash))
+		Expect(second.ChangedAt).To(Equal(first.ChangedAt))
+		Expect(second.CheckedAt.Before(first.CheckedAt)).To(BeFalse())
+	})
+
+	It("ignores changes outside of the main content", func() {
+		_, err := watcher.CheckPage(ctx, server.URL+"/footer")
+		Expect(err).NotTo(HaveOccurred())
+		change, err := watcher.CheckPage(ctx, server.URL+"/footer")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(change).To(BeNil())
+	})
+
+	It("reports changes with a diff of the text", func() {
+		watcher.Check(ctx)
+		Expect(changes).To(BeEmpty())
+
+		setPrice("$12")
+		watcher.Check(ctx)
+		Expect(changes).To(HaveLen(3))
+
+		pricing := changes[0]
+		Expect(pricing.URL).To(Equal(server.URL + "/pricing"))
+		Expect(pricing.Title).To(Equal("Pricing"))
+		Expect(pricing.Hash).NotTo(Equal(pricing.PreviousHash))
+		Expect(pricing.Diff).To(Equal("@@ -2,7 +2,7 @@\n \n Starter\n \n-$10 per month\n+$12 per month\n \n Pro\n \n"))
+		Expect(pricing.ChangedAt.After(pricing.PreviousChangeAt)).To(BeTrue())
+
+		Expect(changes[1].URL).To(Equal(server.URL + "/footer"))
+		Expect(changes[2].Diff).To(Equal("@@ -1,2 +1,2 @@\n-starter $10\n+starter $12\n pro $50\n"))
+
+		state, err := watcher.State(ctx, server.URL+"/pricing")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(state.Text).To(ContainSubstring("$12 per month"))
+		Expect(state.ChangedAt).To(Equal(pricing.ChangedAt))
+
+		watcher.Check(ctx)
+		Expect(changes).To(HaveLen(3))
+	})
+
+	It("reports a change again when it could not be handled", func() {
+		_, err := watcher.CheckPage(ctx, server.URL+"/pricing")
+		Expect(err).NotTo(HaveOccurred())
+		first, _ := watcher.State(ctx, server.URL+"/pricing")
+
+		setPrice("$12")
+		failures = 1
+		change, err := watcher.CheckPage(ctx, server.URL+"/pricing")
+		Expect(err).To(MatchError(ContainSubstring("delivery failed")))
+		Expect(change).NotTo(BeNil())
+		Expect(changes).To(BeEmpty())
+		state, _ := watcher.State(ctx, server.URL+"/pricing")
+		Expect(state.Hash).To(Equal(first.Hash))
+		Expect(state.ETag).To(BeEmpty())
+
+		change, err = watcher.CheckPage(ctx, server.URL+"/pricing")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(change.PreviousHash).To(Equal(first.Hash))
+		Expect(changes).To(HaveLen(1))
+		Expect(changes[0].Diff).To(ContainSubstring("+$12 per month"))
+
+		change, err = watcher.CheckPage(ctx, server.URL+"/pricing")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(change).To(BeNil())
+	})
+
+	It("rejects invalid configurations", func() {
+		_, err := web.NewWatcher(web.WatchConfig{}, nil)
+		Expect(err).To(HaveOccurred())
+		_, err = web.NewWatcher(web.WatchConfig{URLs: []string{"ftp://example.com"}}, nil)
+		Expect(err).To(HaveOccurred())
+		_, err = web.NewWatcher(web.WatchConfig{URLs: []string{"https://example.com"}, Interval: time.Second}, nil)
+		Expect(err).To(HaveOccurred())
+		_, err = web.NewWatcher(web.WatchConfig{URLs: []string{"https://example.com"}, Format: "html"}, nil)
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("Unified diff", func() {
+	lines := func(ls ...string) string { return strings.Join(ls, "\n") + "\n" }
+
+	It("is empty for equal texts", func() {
+		Expect(web.UnifiedDiff("a\nb\n", "a\nb\n")).To(BeEmpty())
+	})
+
+	It("shows additions to and removals from empty texts", func() {
+		Expect(web.UnifiedDiff("", "a\nb")).To(Equal("@@ -0,0 +1,2 @@\n+a\n+b\n"))
+		Expect(web.UnifiedDiff("a", "")).To(Equal("@@ -1 +0,0 @@\n-a\n"))
+	})
+
+	It("splits distant changes into hunks with context", func() {
+		before := lines("1", "2", "3", "4", "5", "6", "7", "8", "9", "10", "11", "12", "13", "14", "15")
+		after := lines("1", "two", "3", "4", "5", "6", "7", "8", "9", "10", "11", "12", "13", "14", "15", "16")
+		Expect(web.UnifiedDiff(before, after)).To(Equal(
+			"@@ -1,5 +1,5 @@\n 1\n-2\n+two\n 3\n 4\n 5\n" +
+				"@@ -13,3 +13,4 @@\n 13\n 14\n 15\n+16\n"))
+	})
+
+	It("merges close changes into one hunk", func() {
+		Expect(web.UnifiedDiff(lines("a", "b", "c", "d", "e"), lines("A", "b", "c", "d", "E"))).To(Equal(
+			"@@ -1,5 +1,5 @@\n-a\n+A\n b\n c\n d\n-e\n+E\n"))
+	})
+})
@@ -0,0 +1,80 @@
+package workers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/Gzgod/masa-oracle/node"
+	"github.com/Gzgod/masa-oracle/pkg/scrapers/discord"
+)
+
+// DiscordGatewayDir is the directory in the masa dir where received Discord messages are stored.
+const DiscordGatewayDir = "discord"
+
+// DiscordGatewayOptions configures the Discord gateway ingestion of a node.
+type DiscordGatewayOptions struct {
+	Gateway discord.GatewayConfig
+	// Topic is the pubsub topic the messages are published to. No messages are published if empty.
+	Topic string
+	// StoreDir is the directory messages are appended to, one JSON lines file per channel. No
+	// messages are stored if empty.
+	StoreDir string
+}
+
+// IngestDiscordGateway returns a node service that receives Discord messages in real time over
+// the gateway, converts them to normalized posts and publishes them to the configured pubsub topic
+// and/or appends them to the local store.
+func IngestDiscordGateway(opts DiscordGatewayOptions) func(ctx context.Context, node *node.OracleNode) {
+	return func(ctx context.Context, node *node.OracleNode) {
+		store := &discordMessageStore{dir: opts.StoreDir}
+		gateway := discord.NewGateway(opts.Gateway, func(message discord.ChannelMessage) {
+			data, err := json.Marshal(discord.MessageToPost(message))
+			if err != nil {
+				logrus.Errorf("[-] Unable to marshal Discord message %s: %v", message.ID, err)
+				return
+			}
+			if opts.Topic != "" {
+				if err := node.PublishTopicMessage(opts.Topic, string(data)); err != nil {
+					logrus.Errorf("[-] Unable to publish Discord message %s: %v", message.ID, err)
+				}
+			}
+			if opts.StoreDir != "" {
+				if err := store.append(message.ChannelID, data); err != nil {
+					logrus.Errorf("[-] Unable to store Discord message %s: %v", message.ID, err)
+				}
+			}
+		})
+
+		logrus.Info("[+] Starting Discord gateway ingestion")
+		if err := gateway.Run(ctx); err != nil && !errors.Is(err, context.Canceled) {
+			logrus.Errorf("[-] Discord gateway ingestion stopped: %v", err)
+		}
+	}
+}
+
+// discordMessageStore appends messages to a JSON lines file per channel.
+type discordMessageStore struct {
+	dir string
+	mu  sync.Mutex
+}
+
+func (s *discordMessageStore) append(channelID string, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(filepath.Join(s.dir, filepath.Base(channelID)+".jsonl"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = file.Write(append(data, '\n'))
+	return err
+}
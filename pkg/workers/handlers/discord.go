@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/sirupsen/logrus"
 
@@ -11,6 +12,11 @@ import (
 
 type DiscordProfileHandler struct{}
 type DiscordChannelHandler struct{}
+type DiscordChannelHistoryHandler struct{}
+type DiscordThreadsHandler struct{}
+type DiscordThreadMessagesHandler struct{}
+type DiscordPinnedMessagesHandler struct{}
+type DiscordReactionUsersHandler struct{}
 type DiscordGuildHandler struct{}
 type DiscoreUserGuildsHandler struct{}
 
@@ -27,6 +33,9 @@ func (h *DiscordProfileHandler) HandleWork(data []byte) data_types.WorkResponse
 		return data_types.WorkResponse{Data: resp, Error: fmt.Sprintf("unable to get discord user profile: %v", err)}
 	}
 	logrus.Infof("[+] DiscordProfileHandler Work response for %s: %d records returned", data_types.DiscordProfile, 1)
+	if wantsNormalized(dataMap) {
+		return data_types.WorkResponse{Data: discord.UserProfileToAuthor(resp), RecordCount: 1}
+	}
 	return data_types.WorkResponse{Data: resp, RecordCount: 1}
 }
 
@@ -38,13 +47,154 @@ func (h *DiscordChannelHandler) HandleWork(data []byte) data_types.WorkResponse
 		return data_types.WorkResponse{Error: fmt.Sprintf("unable to parse discord json data: %v", err)}
 	}
 	channelID := dataMap["channelID"].(string)
-	limit := dataMap["limit"].(string)
-	prompt := dataMap["prompt"].(string)
-	resp, err := discord.GetChannelMessages(channelID, limit, prompt)
+	limit, _ := dataMap["limit"].(string)
+	before, _ := dataMap["before"].(string)
+	resp, err := discord.GetChannelMessages(channelID, limit, before)
 	if err != nil {
 		return data_types.WorkResponse{Error: fmt.Sprintf("unable to get discord channel messages: %v", err)}
 	}
 	logrus.Infof("[+] DiscordChannelHandler Work response for %s: %d records returned", data_types.DiscordChannelMessages, len(resp))
+	if wantsNormalized(dataMap) {
+		return data_types.WorkResponse{Data: discord.MessagesToPosts(resp), RecordCount: len(resp)}
+	}
+	return data_types.WorkResponse{Data: resp, RecordCount: len(resp)}
+}
+
+// discordHistoryTimeBudget keeps a history walk within the worker response timeout. Walks that
+// take longer return a cursor to resume from.
+const discordHistoryTimeBudget = 30 * time.Second
+
+// HandleWork implements the WorkHandler interface for DiscordChannelHistoryHandler.
+func (h *DiscordChannelHistoryHandler) HandleWork(data []byte) data_types.WorkResponse {
+	logrus.Infof("[+] DiscordChannelHistoryHandler %s", data)
+	dataMap, err := JsonBytesToMap(data)
+	if err != nil {
+		return data_types.WorkResponse{Error: fmt.Sprintf("unable to parse discord json data: %v", err)}
+	}
+	opts := discord.HistoryOptions{MaxDuration: discordHistoryTimeBudget}
+	opts.ChannelID, _ = dataMap["channelID"].(string)
+	opts.Direction, _ = dataMap["direction"].(string)
+	opts.Before, _ = dataMap["before"].(string)
+	opts.After, _ = dataMap["after"].(string)
+	if limit, ok := dataMap["limit"].(float64); ok {
+		opts.Limit = int(limit)
+	}
+	if chunkSize, ok := dataMap["chunkSize"].(float64); ok {
+		opts.ChunkSize = int(chunkSize)
+	}
+	for key, bound := range map[string]*time.Time{"since": &opts.Since, "until": &opts.Until} {
+		value, _ := dataMap[key].(string)
+		if value == "" {
+			continue
+		}
+		if *bound, err = time.Parse(time.RFC3339, value); err != nil {
+			return data_types.WorkResponse{Error: fmt.Sprintf("invalid %s date %q: %v", key, value, err)}
+		}
+	}
+
+	history, err := discord.GetChannelHistory(opts)
+	if err != nil && history != nil && history.MessageCount > 0 {
+		// Keep the pages fetched so far, the client can resume from the cursor.
+		logrus.Warnf("[-] DiscordChannelHistoryHandler stopped after %d messages: %v", history.MessageCount, err)
+		history.StopReason = discord.StopError
+		history.Error = err.Error()
+	} else if err != nil {
+		return data_types.WorkResponse{Error: fmt.Sprintf("unable to get discord channel history: %v", err)}
+	}
+	logrus.Infof("[+] DiscordChannelHistoryHandler Work response for %s: %d records returned, stopped on %s", data_types.DiscordChannelHistory, history.MessageCount, history.StopReason)
+	if wantsNormalized(dataMap) {
+		history.NormalizeChunks()
+	}
+	return data_types.WorkResponse{Data: history, RecordCount: history.MessageCount}
+}
+
+// HandleWork implements the WorkHandler interface for DiscordThreadsHandler.
+func (h *DiscordThreadsHandler) HandleWork(data []byte) data_types.WorkResponse {
+	logrus.Infof("[+] DiscordThreadsHandler %s", data)
+	dataMap, err := JsonBytesToMap(data)
+	if err != nil {
+		return data_types.WorkResponse{Error: fmt.Sprintf("unable to parse discord json data: %v", err)}
+	}
+	channelID, _ := dataMap["channelID"].(string)
+	archived, _ := dataMap["archived"].(string)
+	if archived == "" {
+		resp, err := discord.GetActiveThreads(channelID)
+		if err != nil {
+			return data_types.WorkResponse{Error: fmt.Sprintf("unable to get discord active threads: %v", err)}
+		}
+		logrus.Infof("[+] DiscordThreadsHandler Work response for %s: %d records returned", data_types.DiscordChannelThreads, len(resp))
+		return data_types.WorkResponse{Data: discord.ThreadList{Threads: resp}, RecordCount: len(resp)}
+	}
+	before, _ := dataMap["before"].(string)
+	limit, _ := dataMap["limit"].(string)
+	resp, err := discord.GetArchivedThreads(channelID, archived == "private", before, limit)
+	if err != nil {
+		return data_types.WorkResponse{Error: fmt.Sprintf("unable to get discord archived threads: %v", err)}
+	}
+	logrus.Infof("[+] DiscordThreadsHandler Work response for %s: %d records returned", data_types.DiscordChannelThreads, len(resp.Threads))
+	return data_types.WorkResponse{Data: resp, RecordCount: len(resp.Threads)}
+}
+
+// HandleWork implements the WorkHandler interface for DiscordThreadMessagesHandler.
+func (h *DiscordThreadMessagesHandler) HandleWork(data []byte) data_types.WorkResponse {
+	logrus.Infof("[+] DiscordThreadMessagesHandler %s", data)
+	dataMap, err := JsonBytesToMap(data)
+	if err != nil {
+		return data_types.WorkResponse{Error: fmt.Sprintf("unable to parse discord json data: %v", err)}
+	}
+	threadID, _ := dataMap["threadID"].(string)
+	limit, _ := dataMap["limit"].(string)
+	before, _ := dataMap["before"].(string)
+	resp, err := discord.GetThreadMessages(threadID, limit, before)
+	if err != nil {
+		return data_types.WorkResponse{Error: fmt.Sprintf("unable to get discord thread messages: %v", err)}
+	}
+	logrus.Infof("[+] DiscordThreadMessagesHandler Work response for %s: %d records returned", data_types.DiscordThreadMessages, len(resp))
+	if wantsNormalized(dataMap) {
+		return data_types.WorkResponse{Data: discord.MessagesToPosts(resp), RecordCount: len(resp)}
+	}
+	return data_types.WorkResponse{Data: resp, RecordCount: len(resp)}
+}
+
+// HandleWork implements the WorkHandler interface for DiscordPinnedMessagesHandler.
+func (h *DiscordPinnedMessagesHandler) HandleWork(data []byte) data_types.WorkResponse {
+	logrus.Infof("[+] DiscordPinnedMessagesHandler %s", data)
+	dataMap, err := JsonBytesToMap(data)
+	if err != nil {
+		return data_types.WorkResponse{Error: fmt.Sprintf("unable to parse discord json data: %v", err)}
+	}
+	channelID, _ := dataMap["channelID"].(string)
+	resp, err := discord.GetPinnedMessages(channelID)
+	if err != nil {
+		return data_types.WorkResponse{Error: fmt.Sprintf("unable to get discord pinned messages: %v", err)}
+	}
+	logrus.Infof("[+] DiscordPinnedMessagesHandler Work response for %s: %d records returned", data_types.DiscordPinnedMessages, len(resp))
+	if wantsNormalized(dataMap) {
+		return data_types.WorkResponse{Data: discord.MessagesToPosts(resp), RecordCount: len(resp)}
+	}
+	return data_types.WorkResponse{Data: resp, RecordCount: len(resp)}
+}
+
+// HandleWork implements the WorkHandler interface for DiscordReactionUsersHandler.
+func (h *DiscordReactionUsersHandler) HandleWork(data []byte) data_types.WorkResponse {
+	logrus.Infof("[+] DiscordReactionUsersHandler %s", data)
+	dataMap, err := JsonBytesToMap(data)
+	if err != nil {
+		return data_types.WorkResponse{Error: fmt.Sprintf("unable to parse discord json data: %v", err)}
+	}
+	channelID, _ := dataMap["channelID"].(string)
+	messageID, _ := dataMap["messageID"].(string)
+	emoji, _ := dataMap["emoji"].(string)
+	after, _ := dataMap["after"].(string)
+	limit, _ := dataMap["limit"].(string)
+	resp, err := discord.GetReactionUsers(channelID, messageID, emoji, after, limit)
+	if err != nil {
+		return data_types.WorkResponse{Error: fmt.Sprintf("unable to get discord reaction users: %v", err)}
+	}
+	logrus.Infof("[+] DiscordReactionUsersHandler Work response for %s: %d records returned", data_types.DiscordReactionUsers, len(resp))
+	if wantsNormalized(dataMap) {
+		return data_types.WorkResponse{Data: discord.UserProfilesToAuthors(resp), RecordCount: len(resp)}
+	}
 	return data_types.WorkResponse{Data: resp, RecordCount: len(resp)}
 }
 
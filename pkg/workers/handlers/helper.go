@@ -2,6 +2,8 @@ package handlers
 
 import (
 	"encoding/json"
+
+	"github.com/Gzgod/masa-oracle/pkg/scrapers/normalized"
 )
 
 func JsonBytesToMap(jsonBytes []byte) (map[string]interface{}, error) {
@@ -12,3 +14,9 @@ func JsonBytesToMap(jsonBytes []byte) (map[string]interface{}, error) {
 	}
 	return jsonMap, nil
 }
+
+// wantsNormalized reports whether the request asked for the result in the normalized post schema.
+func wantsNormalized(dataMap map[string]interface{}) bool {
+	format, _ := dataMap["format"].(string)
+	return format == normalized.FormatNormalized
+}
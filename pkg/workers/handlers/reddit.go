@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/Gzgod/masa-oracle/pkg/scrapers/reddit"
+	data_types "github.com/Gzgod/masa-oracle/pkg/workers/types"
+)
+
+type RedditSubredditHandler struct{}
+type RedditCommentsHandler struct{}
+type RedditUserPostsHandler struct{}
+type RedditSearchHandler struct{}
+
+// redditTimeBudget keeps the pages of a listing within the worker response timeout. Listings
+// that take longer return the posts fetched so far and the token to continue from.
+const redditTimeBudget = 30 * time.Second
+
+// HandleWork implements the WorkHandler interface for RedditSubredditHandler.
+func (h *RedditSubredditHandler) HandleWork(data []byte) data_types.WorkResponse {
+	logrus.Infof("[+] RedditSubredditHandler %s", data)
+	dataMap, err := JsonBytesToMap(data)
+	if err != nil {
+		return data_types.WorkResponse{Error: fmt.Sprintf("unable to parse reddit json data: %v", err)}
+	}
+	subreddit, _ := dataMap["subreddit"].(string)
+	ctx, cancel := context.WithTimeout(context.Background(), redditTimeBudget)
+	defer cancel()
+	listing, err := reddit.GetSubredditPosts(ctx, subreddit, redditListingOptions(dataMap))
+	return redditListingResponse(dataMap, data_types.RedditSubreddit, listing, err)
+}
+
+// HandleWork implements the WorkHandler interface for RedditUserPostsHandler.
+func (h *RedditUserPostsHandler) HandleWork(data []byte) data_types.WorkResponse {
+	logrus.Infof("[+] RedditUserPostsHandler %s", data)
+	dataMap, err := JsonBytesToMap(data)
+	if err != nil {
+		return data_types.WorkResponse{Error: fmt.Sprintf("unable to parse reddit json data: %v", err)}
+	}
+	username, _ := dataMap["username"].(string)
+	ctx, cancel := context.WithTimeout(context.Background(), redditTimeBudget)
+	defer cancel()
+	listing, err := reddit.GetUserPosts(ctx, username, redditListingOptions(dataMap))
+	return redditListingResponse(dataMap, data_types.RedditUserPosts, listing, err)
+}
+
+// HandleWork implements the WorkHandler interface for RedditSearchHandler.
+func (h *RedditSearchHandler) HandleWork(data []byte) data_types.WorkResponse {
+	logrus.Infof("[+] RedditSearchHandler %s", data)
+	dataMap, err := JsonBytesToMap(data)
+	if err != nil {
+		return data_types.WorkResponse{Error: fmt.Sprintf("unable to parse reddit json data: %v", err)}
+	}
+	listingOpts := redditListingOptions(dataMap)
+	opts := reddit.SearchOptions{Sort: listingOpts.Sort, Time: listingOpts.Time, After: listingOpts.After, Limit: listingOpts.Limit}
+	opts.Query, _ = dataMap["query"].(string)
+	opts.Subreddit, _ = dataMap["subreddit"].(string)
+	ctx, cancel := context.WithTimeout(context.Background(), redditTimeBudget)
+	defer cancel()
+	listing, err := reddit.Search(ctx, opts)
+	return redditListingResponse(dataMap, data_types.RedditSearch, listing, err)
+}
+
+// HandleWork implements the WorkHandler interface for RedditCommentsHandler.
+func (h *RedditCommentsHandler) HandleWork(data []byte) data_types.WorkResponse {
+	logrus.Infof("[+] RedditCommentsHandler %s", data)
+	dataMap, err := JsonBytesToMap(data)
+	if err != nil {
+		return data_types.WorkResponse{Error: fmt.Sprintf("unable to parse reddit json data: %v", err)}
+	}
+	postID, _ := dataMap["postId"].(string)
+	var opts reddit.CommentOptions
+	opts.Sort, _ = dataMap["sort"].(string)
+	if depth, ok := dataMap["depth"].(float64); ok {
+		opts.Depth = int(depth)
+	}
+	if limit, ok := dataMap["limit"].(float64); ok {
+		opts.Limit = int(limit)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), redditTimeBudget)
+	defer cancel()
+	tree, err := reddit.GetComments(ctx, postID, opts)
+	if err != nil {
+		return data_types.WorkResponse{Error: fmt.Sprintf("unable to get reddit comments: %v", err)}
+	}
+	count := reddit.CountComments(tree.Comments)
+	logrus.Infof("[+] RedditCommentsHandler Work response for %s: %d records returned", data_types.RedditComments, count)
+	if wantsNormalized(dataMap) {
+		return data_types.WorkResponse{Data: reddit.CommentTreeToPosts(tree), RecordCount: count + 1}
+	}
+	return data_types.WorkResponse{Data: tree, RecordCount: count + 1}
+}
+
+func redditListingOptions(dataMap map[string]interface{}) reddit.ListingOptions {
+	var opts reddit.ListingOptions
+	opts.Sort, _ = dataMap["sort"].(string)
+	opts.Time, _ = dataMap["t"].(string)
+	opts.After, _ = dataMap["after"].(string)
+	if limit, ok := dataMap["limit"].(float64); ok {
+		opts.Limit = int(limit)
+	}
+	return opts
+}
+
+func redditListingResponse(dataMap map[string]interface{}, workType data_types.WorkerType, listing *reddit.Listing, err error) data_types.WorkResponse {
+	if err != nil && listing != nil && len(listing.Posts) > 0 {
+		// Keep the pages fetched so far, listing.Error tells the client to continue from the after token.
+		logrus.Warnf("[-] Reddit %s stopped after %d posts: %v", workType, len(listing.Posts), err)
+	} else if err != nil {
+		return data_types.WorkResponse{Error: fmt.Sprintf("unable to get reddit posts: %v", err)}
+	}
+	logrus.Infof("[+] Reddit Work response for %s: %d records returned", workType, len(listing.Posts))
+	if wantsNormalized(dataMap) {
+		return data_types.WorkResponse{Data: reddit.NormalizeListing(listing), RecordCount: len(listing.Posts)}
+	}
+	return data_types.WorkResponse{Data: listing, RecordCount: len(listing.Posts)}
+}
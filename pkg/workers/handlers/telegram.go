@@ -2,7 +2,10 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/sirupsen/logrus"
 
@@ -12,6 +15,10 @@ import (
 
 type TelegramChannelHandler struct{}
 
+// telegramHistoryTimeBudget keeps a history walk within the worker response timeout. Walks that
+// take longer return the messages fetched so far.
+const telegramHistoryTimeBudget = 30 * time.Second
+
 // HandleWork implements the WorkHandler interface for TelegramChannelHandler.
 func (h *TelegramChannelHandler) HandleWork(data []byte) data_types.WorkResponse {
 	logrus.Infof("[+] TelegramChannelHandler %s", data)
@@ -19,11 +26,48 @@ func (h *TelegramChannelHandler) HandleWork(data []byte) data_types.WorkResponse
 	if err != nil {
 		return data_types.WorkResponse{Error: fmt.Sprintf("unable to parse telegram json data: %v", err)}
 	}
-	userName := dataMap["username"].(string)
-	resp, err := telegram.FetchChannelMessages(context.Background(), userName)
-	if err != nil {
+	var target telegram.Target
+	target.Username, _ = dataMap["username"].(string)
+	if chatID, ok := dataMap["chatId"].(float64); ok {
+		target.ChatID = int64(chatID)
+	}
+	if postID, ok := dataMap["postId"].(float64); ok {
+		target.PostID = int(postID)
+	}
+	opts := telegram.HistoryOptions{MaxDuration: telegramHistoryTimeBudget}
+	if offsetID, ok := dataMap["offsetId"].(float64); ok {
+		opts.OffsetID = int(offsetID)
+	}
+	if limit, ok := dataMap["limit"].(float64); ok {
+		opts.Limit = int(limit)
+	}
+	for key, bound := range map[string]*time.Time{"since": &opts.Since, "until": &opts.Until} {
+		value, _ := dataMap[key].(string)
+		if value == "" {
+			continue
+		}
+		if *bound, err = time.Parse(time.RFC3339, value); err != nil {
+			return data_types.WorkResponse{Error: fmt.Sprintf("invalid %s date %q: %v", key, value, err)}
+		}
+	}
+
+	resp, err := telegram.FetchChatHistory(context.Background(), target, opts)
+	if err != nil && len(resp) == 0 && !errors.Is(err, telegram.ErrTimeBudget) {
 		return data_types.WorkResponse{Error: fmt.Sprintf("unable to get telegram channel messages: %v", err)}
 	}
-	logrus.Infof("[+] TelegramChannelHandler Work response for %s: %d records returned", data_types.TelegramChannelMessages, len(resp))
-	return data_types.WorkResponse{Data: resp, RecordCount: len(resp)}
+	history := telegram.NewChannelHistory(resp, opts, err)
+	if history.StopReason == telegram.StopError {
+		// Keep the messages fetched so far, the client can resume from NextOffsetID.
+		logrus.Warnf("[-] TelegramChannelHandler stopped after %d messages: %v", history.MessageCount, err)
+	}
+	logrus.Infof("[+] TelegramChannelHandler Work response for %s: %d records returned, stopped on %s", data_types.TelegramChannelMessages, history.MessageCount, history.StopReason)
+	if wantsNormalized(dataMap) {
+		chat := target.Username
+		if chat == "" {
+			chat = strconv.FormatInt(target.ChatID, 10)
+		}
+		history.Posts = telegram.MessagesToPosts(chat, resp)
+		history.Messages = nil
+	}
+	return data_types.WorkResponse{Data: history, RecordCount: history.MessageCount}
 }
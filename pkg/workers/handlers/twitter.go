@@ -5,6 +5,7 @@ import (
 
 	"github.com/sirupsen/logrus"
 
+	"github.com/Gzgod/masa-oracle/pkg/blobstore"
 	"github.com/Gzgod/masa-oracle/pkg/scrapers/twitter"
 	data_types "github.com/Gzgod/masa-oracle/pkg/workers/types"
 )
@@ -37,7 +38,19 @@ func (h *TwitterQueryHandler) HandleWork(data []byte) data_types.WorkResponse {
 		logrus.Infof("[+] First tweet: ID: %s, Text: %s, Author: %s, CreatedAt: %s",
 			tweet.ID, tweet.Text, tweet.Username, tweet.TimeParsed)
 	}
-	return data_types.WorkResponse{Data: resp, RecordCount: len(resp)}
+	var blobs map[string][]byte
+	if downloadMedia, _ := dataMap["downloadMedia"].(bool); downloadMedia {
+		store, err := blobstore.New(blobstore.Dir(h.MasaDir))
+		if err != nil {
+			return data_types.WorkResponse{Error: fmt.Sprintf("unable to open blob store: %v", err)}
+		}
+		blobs = twitter.DownloadTweetMedia(store, resp)
+		logrus.Infof("[+] TwitterQueryHandler downloaded %d media files", len(blobs))
+	}
+	if wantsNormalized(dataMap) {
+		return data_types.WorkResponse{Data: twitter.TweetsToPosts(resp), RecordCount: len(resp), Blobs: blobs}
+	}
+	return data_types.WorkResponse{Data: resp, RecordCount: len(resp), Blobs: blobs}
 }
 
 func (h *TwitterFollowersHandler) HandleWork(data []byte) data_types.WorkResponse {
@@ -54,6 +67,9 @@ func (h *TwitterFollowersHandler) HandleWork(data []byte) data_types.WorkRespons
 	}
 
 	logrus.Infof("[+] TwitterFollowersHandler Work response for %s: %d records returned", data_types.TwitterFollowers, len(resp))
+	if wantsNormalized(dataMap) {
+		return data_types.WorkResponse{Data: twitter.FollowersToAuthors(resp), RecordCount: len(resp)}
+	}
 	return data_types.WorkResponse{Data: resp, RecordCount: len(resp)}
 }
 
@@ -69,5 +85,8 @@ func (h *TwitterProfileHandler) HandleWork(data []byte) data_types.WorkResponse
 		return data_types.WorkResponse{Error: fmt.Sprintf("unable to get twitter profile: %v", err)}
 	}
 	logrus.Infof("[+] TwitterProfileHandler Work response for %s: %d records returned", data_types.TwitterProfile, 1)
+	if wantsNormalized(dataMap) {
+		return data_types.WorkResponse{Data: twitter.ProfileToAuthor(resp), RecordCount: 1}
+	}
 	return data_types.WorkResponse{Data: resp, RecordCount: 1}
 }
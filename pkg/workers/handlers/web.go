@@ -1,7 +1,10 @@
 package handlers
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/sirupsen/logrus"
 
@@ -12,22 +15,129 @@ import (
 // WebHandler - All the web handlers implement the WorkHandler interface.
 type WebHandler struct{}
 
+// WebFeedHandler reads RSS, Atom and JSON feeds.
+type WebFeedHandler struct{}
+
+// webTimeBudget keeps a crawl within the worker response timeout. Crawls that take longer return
+// the pages scraped so far.
+const webTimeBudget = 30 * time.Second
+
 func (h *WebHandler) HandleWork(data []byte) data_types.WorkResponse {
 	logrus.Infof("[+] WebHandler %s", data)
 	dataMap, err := JsonBytesToMap(data)
 	if err != nil {
 		return data_types.WorkResponse{Error: fmt.Sprintf("unable to parse web data: %v", err)}
 	}
-	depth := int(dataMap["depth"].(float64))
-	urls := []string{dataMap["url"].(string)}
-	resp, err := web.ScrapeWebData(urls, depth)
+	url, _ := dataMap["url"].(string)
+	urls := []string{url}
+	opts, err := webCrawlOptions(dataMap)
+	if err != nil {
+		return data_types.WorkResponse{Error: fmt.Sprintf("unable to parse web data: %v", err)}
+	}
+	resp, err := web.ScrapeWebDataWithOptions(urls, opts)
 	if err != nil {
 		return data_types.WorkResponse{Error: fmt.Sprintf("unable to get web data: %v", err)}
 	}
+	var collectedData web.CollectedData
+	if err = json.Unmarshal(resp, &collectedData); err != nil {
+		return data_types.WorkResponse{Error: fmt.Sprintf("unable to parse web data: %v", err)}
+	}
+	logrus.Infof("[+] WebHandler Work response for %s: %d pages scraped, stopped on %s", data_types.Web, collectedData.PageCount, collectedData.StopReason)
+	if wantsNormalized(dataMap) {
+		posts := web.CollectedDataToPosts(urls[0], collectedData)
+		return data_types.WorkResponse{Data: posts, RecordCount: len(posts)}
+	}
 	result, err := JsonBytesToMap(resp)
 	if err != nil {
 		logrus.Errorf("unable to parse web data: %v", err)
 	}
-	logrus.Infof("[+] WebHandler Work response for %s: %d records returned", data_types.Web, 1)
 	return data_types.WorkResponse{Data: result, RecordCount: 1}
 }
+
+// webCrawlOptions reads the crawl options of a web request. Budgets that are not set, or that
+// exceed the defaults of the worker, are set to the defaults.
+func webCrawlOptions(dataMap map[string]interface{}) (web.CrawlOptions, error) {
+	opts := web.CrawlOptions{
+		MaxPages:    web.DefaultMaxPages,
+		MaxBytes:    web.DefaultMaxBytes,
+		MaxDuration: webTimeBudget,
+	}
+	if depth, ok := dataMap["depth"].(float64); ok {
+		opts.Depth = int(depth)
+	}
+	if maxPages, ok := dataMap["maxPages"].(float64); ok && maxPages > 0 {
+		opts.MaxPages = min(int(maxPages), web.DefaultMaxPages)
+	}
+	if maxBytes, ok := dataMap["maxBytes"].(float64); ok && maxBytes > 0 {
+		opts.MaxBytes = min(int(maxBytes), web.DefaultMaxBytes)
+	}
+	if timeBudget, ok := dataMap["timeBudget"].(float64); ok && timeBudget > 0 {
+		opts.MaxDuration = min(time.Duration(timeBudget*float64(time.Second)), webTimeBudget)
+	}
+	opts.Scope, _ = dataMap["scope"].(string)
+	opts.Include = stringList(dataMap["include"])
+	opts.Exclude = stringList(dataMap["exclude"])
+	opts.Content, _ = dataMap["content"].(string)
+	opts.Metadata, _ = dataMap["metadata"].(bool)
+	opts.Discover, _ = dataMap["discover"].(string)
+	if since, ok := dataMap["since"].(string); ok && since != "" {
+		parsed, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			return opts, fmt.Errorf("invalid since: %w", err)
+		}
+		opts.Since = parsed
+	}
+	if extract, ok := dataMap["extract"]; ok {
+		// The fields are nested, decode them again rather than walking the map
+		raw, err := json.Marshal(extract)
+		if err != nil {
+			return opts, err
+		}
+		if err := json.Unmarshal(raw, &opts.Extract); err != nil {
+			return opts, fmt.Errorf("invalid extract: %w", err)
+		}
+	}
+	return opts, nil
+}
+
+// stringList returns the strings of a JSON array, skipping other values.
+func stringList(value interface{}) []string {
+	items, _ := value.([]interface{})
+	list := make([]string, 0, len(items))
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			list = append(list, s)
+		}
+	}
+	return list
+}
+
+// HandleWork implements the WorkHandler interface for WebFeedHandler.
+func (h *WebFeedHandler) HandleWork(data []byte) data_types.WorkResponse {
+	logrus.Infof("[+] WebFeedHandler %s", data)
+	dataMap, err := JsonBytesToMap(data)
+	if err != nil {
+		return data_types.WorkResponse{Error: fmt.Sprintf("unable to parse web feed data: %v", err)}
+	}
+	uri, _ := dataMap["url"].(string)
+	var opts web.FeedOptions
+	if since, ok := dataMap["since"].(string); ok && since != "" {
+		if opts.Since, err = time.Parse(time.RFC3339, since); err != nil {
+			return data_types.WorkResponse{Error: fmt.Sprintf("unable to parse web feed data: invalid since: %v", err)}
+		}
+	}
+	if limit, ok := dataMap["limit"].(float64); ok {
+		opts.Limit = min(int(limit), web.DefaultFeedLimit)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), webTimeBudget)
+	defer cancel()
+	result, err := web.ReadFeeds(ctx, uri, opts)
+	if err != nil {
+		return data_types.WorkResponse{Error: fmt.Sprintf("unable to get web feed: %v", err)}
+	}
+	logrus.Infof("[+] WebFeedHandler Work response for %s: %d records returned", data_types.WebFeed, len(result.Items))
+	if wantsNormalized(dataMap) {
+		return data_types.WorkResponse{Data: web.FeedItemsToPosts(result.Items), RecordCount: len(result.Items)}
+	}
+	return data_types.WorkResponse{Data: result, RecordCount: len(result.Items)}
+}
@@ -4,6 +4,8 @@ type WorkerOption struct {
 	isTwitterWorker        bool
 	isWebScraperWorker     bool
 	isDiscordScraperWorker bool
+	isTelegramWorker       bool
+	isRedditWorker         bool
 	masaDir                string
 }
 
@@ -21,6 +23,14 @@ var EnableDiscordScraperWorker = func(o *WorkerOption) {
 	o.isDiscordScraperWorker = true
 }
 
+var EnableTelegramWorker = func(o *WorkerOption) {
+	o.isTelegramWorker = true
+}
+
+var EnableRedditWorker = func(o *WorkerOption) {
+	o.isRedditWorker = true
+}
+
 func WithMasaDir(dir string) WorkerOptionFunc {
 	return func(o *WorkerOption) {
 		o.masaDir = dir
@@ -0,0 +1,97 @@
+package workers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/Gzgod/masa-oracle/node"
+	"github.com/Gzgod/masa-oracle/pkg/scrapers/normalized"
+	"github.com/Gzgod/masa-oracle/pkg/scrapers/telegram"
+)
+
+// TelegramUpdatesDir is the directory in the masa dir where the Telegram update state is kept.
+const TelegramUpdatesDir = "telegram"
+
+// telegramWebhookTimeout bounds the delivery of a message to the webhook.
+const telegramWebhookTimeout = 10 * time.Second
+
+// TelegramUpdatesOptions configures the Telegram live update ingestion of a node.
+type TelegramUpdatesOptions struct {
+	Updates telegram.UpdatesConfig
+	// Topic is the pubsub topic the messages are published to. No messages are published if empty.
+	Topic string
+	// WebhookURL receives each message as a JSON POST request. No requests are sent if empty.
+	WebhookURL string
+}
+
+// IngestTelegramUpdates returns a node service that receives new Telegram messages in real time,
+// converts them to normalized posts and publishes them to the configured pubsub topic and/or
+// posts them to the webhook.
+func IngestTelegramUpdates(opts TelegramUpdatesOptions) func(ctx context.Context, node *node.OracleNode) {
+	return func(ctx context.Context, node *node.OracleNode) {
+		httpClient := &http.Client{Timeout: telegramWebhookTimeout}
+		updates, err := telegram.NewUpdates(opts.Updates, func(message telegram.Message) {
+			data, err := json.Marshal(telegramMessageToPost(message))
+			if err != nil {
+				logrus.Errorf("[-] Unable to marshal Telegram message %d: %v", message.ID, err)
+				return
+			}
+			if opts.Topic != "" {
+				if err := node.PublishTopicMessage(opts.Topic, string(data)); err != nil {
+					logrus.Errorf("[-] Unable to publish Telegram message %d: %v", message.ID, err)
+				}
+			}
+			if opts.WebhookURL != "" {
+				if err := postWebhook(ctx, httpClient, opts.WebhookURL, data); err != nil {
+					logrus.Errorf("[-] Unable to send Telegram message %d to the webhook: %v", message.ID, err)
+				}
+			}
+		})
+		if err != nil {
+			logrus.Errorf("[-] Unable to start Telegram updates: %v", err)
+			return
+		}
+
+		logrus.Info("[+] Starting Telegram update ingestion")
+		if err := updates.Run(ctx); err != nil {
+			logrus.Errorf("[-] Telegram update ingestion stopped: %v", err)
+		}
+	}
+}
+
+// telegramMessageToPost converts a message to a post of its chat, named by username when it has
+// one and by ID otherwise.
+func telegramMessageToPost(message telegram.Message) normalized.Post {
+	chat := ""
+	if message.Chat != nil {
+		chat = message.Chat.Username
+		if chat == "" {
+			chat = strconv.FormatInt(message.Chat.ID, 10)
+		}
+	}
+	return telegram.MessageToPost(chat, message)
+}
+
+func postWebhook(ctx context.Context, client *http.Client, url string, data []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status code %d", resp.StatusCode)
+	}
+	return nil
+}
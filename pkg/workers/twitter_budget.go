@@ -0,0 +1,45 @@
+package workers
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/Gzgod/masa-oracle/node"
+	"github.com/Gzgod/masa-oracle/pkg/scrapers/twitter"
+)
+
+// TwitterBudgetInterval is how often a Twitter worker refreshes the budget it advertises.
+const TwitterBudgetInterval = 60 * time.Second
+
+// AdvertiseTwitterBudget returns a node service that periodically publishes the remaining request
+// budget of the node's Twitter accounts as part of its node data, so that other nodes can avoid
+// sending work to a worker whose accounts are exhausted.
+func AdvertiseTwitterBudget(masaDir string) func(ctx context.Context, node *node.OracleNode) {
+	return func(ctx context.Context, node *node.OracleNode) {
+		ticker := time.NewTicker(TwitterBudgetInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				nodeData := node.NodeTracker.GetNodeData(node.Host.ID().String())
+				if nodeData == nil {
+					continue
+				}
+				budget := make(map[string]int)
+				for endpoint, remaining := range twitter.GetAccountManager(masaDir).RemainingBudget() {
+					budget[string(endpoint)] = remaining
+				}
+				nodeData.TwitterBudget = budget
+				nodeData.LastUpdatedUnix = time.Now().Unix()
+				if err := node.NodeTracker.AddOrUpdateNodeData(nodeData, true); err != nil {
+					logrus.Errorf("[-] Error advertising Twitter budget: %v", err)
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
@@ -54,4 +54,6 @@ type WorkResponse struct {
 	Error        string       `json:"error,omitempty"`
 	WorkerPeerId string       `json:"workerPeerId,omitempty"`
 	RecordCount  int          `json:"recordCount,omitempty"`
+	// Blobs holds content-addressed data produced by the worker, such as downloaded media, keyed by CID.
+	Blobs map[string][]byte `json:"blobs,omitempty"`
 }
@@ -12,35 +12,50 @@ const (
 	Discord                 WorkerType = "discord"
 	DiscordProfile          WorkerType = "discord-profile"
 	DiscordChannelMessages  WorkerType = "discord-channel-messages"
+	DiscordChannelHistory   WorkerType = "discord-channel-history"
+	DiscordChannelThreads   WorkerType = "discord-channel-threads"
+	DiscordThreadMessages   WorkerType = "discord-thread-messages"
+	DiscordPinnedMessages   WorkerType = "discord-pinned-messages"
+	DiscordReactionUsers    WorkerType = "discord-reaction-users"
 	TelegramChannelMessages WorkerType = "telegram-channel-messages"
 	DiscordGuildChannels    WorkerType = "discord-guild-channels"
 	DiscordUserGuilds       WorkerType = "discord-user-guilds"
+	RedditSubreddit         WorkerType = "reddit-subreddit"
+	RedditComments          WorkerType = "reddit-comments"
+	RedditUserPosts         WorkerType = "reddit-user-posts"
+	RedditSearch            WorkerType = "reddit-search"
 	Twitter                 WorkerType = "twitter"
 	TwitterFollowers        WorkerType = "twitter-followers"
 	TwitterProfile          WorkerType = "twitter-profile"
 	Web                     WorkerType = "web"
+	WebFeed                 WorkerType = "web-feed"
 	Test                    WorkerType = "test"
 
 	DataSourceTwitter  = "twitter"
 	DataSourceDiscord  = "discord"
 	DataSourceWeb      = "web"
 	DataSourceTelegram = "telegram"
+	DataSourceReddit   = "reddit"
 )
 
 // WorkerTypeToCategory maps WorkerType to WorkerCategory
 func WorkerTypeToCategory(wt WorkerType) pubsub.WorkerCategory {
 	logrus.Infof("Mapping WorkerType %s to WorkerCategory", wt)
 	switch wt {
-	case Discord, DiscordProfile, DiscordChannelMessages, DiscordGuildChannels, DiscordUserGuilds:
+	case Discord, DiscordProfile, DiscordChannelMessages, DiscordChannelHistory, DiscordChannelThreads, DiscordThreadMessages,
+		DiscordPinnedMessages, DiscordReactionUsers, DiscordGuildChannels, DiscordUserGuilds:
 		logrus.Info("WorkerType is related to Discord")
 		return pubsub.CategoryDiscord
 	case TelegramChannelMessages:
 		logrus.Info("WorkerType is related to Telegram")
 		return pubsub.CategoryTelegram
+	case RedditSubreddit, RedditComments, RedditUserPosts, RedditSearch:
+		logrus.Info("WorkerType is related to Reddit")
+		return pubsub.CategoryReddit
 	case Twitter, TwitterFollowers, TwitterProfile:
 		logrus.Info("WorkerType is related to Twitter")
 		return pubsub.CategoryTwitter
-	case Web:
+	case Web, WebFeed:
 		logrus.Info("WorkerType is related to Web")
 		return pubsub.CategoryWeb
 	default:
@@ -53,16 +68,20 @@ func WorkerTypeToCategory(wt WorkerType) pubsub.WorkerCategory {
 func WorkerTypeToDataSource(wt WorkerType) string {
 	logrus.Infof("Mapping WorkerType %s to WorkerCategory", wt)
 	switch wt {
-	case Discord, DiscordProfile, DiscordChannelMessages, DiscordGuildChannels, DiscordUserGuilds:
+	case Discord, DiscordProfile, DiscordChannelMessages, DiscordChannelHistory, DiscordChannelThreads, DiscordThreadMessages,
+		DiscordPinnedMessages, DiscordReactionUsers, DiscordGuildChannels, DiscordUserGuilds:
 		logrus.Info("WorkerType is related to Discord")
 		return DataSourceDiscord
 	case TelegramChannelMessages:
 		logrus.Info("WorkerType is related to Telegram")
 		return DataSourceTelegram
+	case RedditSubreddit, RedditComments, RedditUserPosts, RedditSearch:
+		logrus.Info("WorkerType is related to Reddit")
+		return DataSourceReddit
 	case Twitter, TwitterFollowers, TwitterProfile:
 		logrus.Info("WorkerType is related to Twitter")
 		return DataSourceTwitter
-	case Web:
+	case Web, WebFeed:
 		logrus.Info("WorkerType is related to Web")
 		return DataSourceWeb
 	default:
@@ -0,0 +1,82 @@
+package workers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	leveldb "github.com/ipfs/go-ds-leveldb"
+	"github.com/sirupsen/logrus"
+
+	"github.com/Gzgod/masa-oracle/node"
+	"github.com/Gzgod/masa-oracle/pkg/scrapers/web"
+)
+
+// WebWatchDir is the directory in the node's cache path where the state of the watched pages is
+// cached.
+const WebWatchDir = "web-watch"
+
+// webWebhookTimeout bounds the delivery of a change to the webhook.
+const webWebhookTimeout = 10 * time.Second
+
+// WebWatchOptions configures the change detection of web pages of a node.
+type WebWatchOptions struct {
+	Watch web.WatchConfig
+	// StoreDir is the directory of the LevelDB cache of the page states. The states are kept in
+	// memory if empty, so that every page is checked anew after a restart.
+	StoreDir string
+	// Topic is the pubsub topic the changes are published to. No changes are published if empty.
+	Topic string
+	// WebhookURL receives each change as a JSON POST request. No requests are sent if empty.
+	WebhookURL string
+}
+
+// WatchWebPages returns a node service that periodically checks web pages for changes and
+// publishes the changes to the configured pubsub topic and/or posts them to the webhook.
+func WatchWebPages(opts WebWatchOptions) func(ctx context.Context, node *node.OracleNode) {
+	return func(ctx context.Context, node *node.OracleNode) {
+		if opts.StoreDir != "" {
+			store, err := leveldb.NewDatastore(opts.StoreDir, nil)
+			if err != nil {
+				logrus.Errorf("[-] Unable to open the web watch cache: %v", err)
+				return
+			}
+			defer store.Close()
+			opts.Watch.Store = store
+		}
+
+		httpClient := &http.Client{Timeout: webWebhookTimeout}
+		// A failed delivery is retried at the next check, which may deliver the change twice to
+		// the topic or webhook that did receive it.
+		watcher, err := web.NewWatcher(opts.Watch, func(change web.Change) error {
+			data, err := json.Marshal(change)
+			if err != nil {
+				return fmt.Errorf("unable to marshal the change: %w", err)
+			}
+			var errs []error
+			if opts.Topic != "" {
+				if err := node.PublishTopicMessage(opts.Topic, string(data)); err != nil {
+					errs = append(errs, fmt.Errorf("unable to publish the change: %w", err))
+				}
+			}
+			if opts.WebhookURL != "" {
+				if err := postWebhook(ctx, httpClient, opts.WebhookURL, data); err != nil {
+					errs = append(errs, fmt.Errorf("unable to send the change to the webhook: %w", err))
+				}
+			}
+			return errors.Join(errs...)
+		})
+		if err != nil {
+			logrus.Errorf("[-] Unable to start watching web pages: %v", err)
+			return
+		}
+
+		logrus.Infof("[+] Watching %d web pages for changes", len(opts.Watch.URLs))
+		if err := watcher.Run(ctx); err != nil {
+			logrus.Errorf("[-] Web page watching stopped: %v", err)
+		}
+	}
+}
@@ -39,15 +39,32 @@ func NewWorkHandlerManager(opts ...WorkerOptionFunc) *WorkHandlerManager {
 
 	if options.isWebScraperWorker {
 		whm.addWorkHandler(data_types.Web, &handlers.WebHandler{})
+		whm.addWorkHandler(data_types.WebFeed, &handlers.WebFeedHandler{})
 	}
 
 	if options.isDiscordScraperWorker {
 		whm.addWorkHandler(data_types.Discord, &handlers.DiscordProfileHandler{})
 		whm.addWorkHandler(data_types.DiscordChannelMessages, &handlers.DiscordChannelHandler{})
+		whm.addWorkHandler(data_types.DiscordChannelHistory, &handlers.DiscordChannelHistoryHandler{})
+		whm.addWorkHandler(data_types.DiscordChannelThreads, &handlers.DiscordThreadsHandler{})
+		whm.addWorkHandler(data_types.DiscordThreadMessages, &handlers.DiscordThreadMessagesHandler{})
+		whm.addWorkHandler(data_types.DiscordPinnedMessages, &handlers.DiscordPinnedMessagesHandler{})
+		whm.addWorkHandler(data_types.DiscordReactionUsers, &handlers.DiscordReactionUsersHandler{})
 		whm.addWorkHandler(data_types.DiscordGuildChannels, &handlers.DiscordGuildHandler{})
 		whm.addWorkHandler(data_types.DiscordUserGuilds, &handlers.DiscoreUserGuildsHandler{})
 	}
 
+	if options.isTelegramWorker {
+		whm.addWorkHandler(data_types.TelegramChannelMessages, &handlers.TelegramChannelHandler{})
+	}
+
+	if options.isRedditWorker {
+		whm.addWorkHandler(data_types.RedditSubreddit, &handlers.RedditSubredditHandler{})
+		whm.addWorkHandler(data_types.RedditComments, &handlers.RedditCommentsHandler{})
+		whm.addWorkHandler(data_types.RedditUserPosts, &handlers.RedditUserPostsHandler{})
+		whm.addWorkHandler(data_types.RedditSearch, &handlers.RedditSearchHandler{})
+	}
+
 	return whm
 }
 
@@ -29,6 +29,7 @@ func GetEligibleWorkers(node *node.OracleNode, category pubsub.WorkerCategory, l
 
 // getTwitterWorkers selects and shuffles a pool of top-performing Twitter workers
 func getTwitterWorkers(node *node.OracleNode, nodes []pubsub.NodeData, limit int) ([]data_types.Worker, *data_types.Worker) {
+	nodes = filterExhaustedTwitterWorkers(nodes)
 	poolSize := calculatePoolSize(len(nodes), limit)
 	topPerformers := nodes[:poolSize]
 
@@ -40,6 +41,25 @@ func getTwitterWorkers(node *node.OracleNode, nodes []pubsub.NodeData, limit int
 	return createWorkerList(node, topPerformers, limit)
 }
 
+// filterExhaustedTwitterWorkers drops the nodes that advertise no remaining Twitter budget at all.
+// Nodes that do not advertise a budget are kept.
+func filterExhaustedTwitterWorkers(nodes []pubsub.NodeData) []pubsub.NodeData {
+	filtered := make([]pubsub.NodeData, 0, len(nodes))
+	for _, nd := range nodes {
+		if nd.TwitterBudget == nil {
+			filtered = append(filtered, nd)
+			continue
+		}
+		for _, remaining := range nd.TwitterBudget {
+			if remaining > 0 {
+				filtered = append(filtered, nd)
+				break
+			}
+		}
+	}
+	return filtered
+}
+
 // getAllWorkers returns all eligible workers for non-Twitter categories
 func getAllWorkers(node *node.OracleNode, nodes []pubsub.NodeData, limit int) ([]data_types.Worker, *data_types.Worker) {
 	return createWorkerList(node, nodes, limit)